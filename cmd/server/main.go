@@ -13,9 +13,11 @@ import (
 	"github.com/GoPolymarket/polygate/internal/handler"
 	"github.com/GoPolymarket/polygate/internal/market"
 	"github.com/GoPolymarket/polygate/internal/middleware"
+	"github.com/GoPolymarket/polygate/internal/model"
 	"github.com/GoPolymarket/polygate/internal/pkg/logger"
 	"github.com/GoPolymarket/polygate/internal/repository"
 	"github.com/GoPolymarket/polygate/internal/service"
+	"github.com/GoPolymarket/polymarket-go-sdk"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
@@ -36,49 +38,111 @@ func main() {
 	}
 
 	// 2. Initialize Persistence
-	// Risk Persistence (Redis > Memory)
-	var riskRepo service.UsageRepo
+	var redisClient *repository.RedisClient
 	if cfg.Redis.Addr != "" {
-		redisClient, err := repository.NewRedisClient(cfg)
+		client, err := repository.NewRedisClient(cfg)
 		if err == nil {
 			logger.Info("✅ Connected to Redis")
-			riskRepo = redisClient
+			redisClient = client
 		} else {
 			logger.Error("⚠️ Failed to connect to Redis, falling back to memory", "error", err)
 		}
 	}
-	if riskRepo == nil {
-		riskRepo = service.NewRiskUsageStore()
-	}
 
 	// Audit Persistence (Postgres > Local File)
 	var auditRepo service.AuditRepo
+	var db *repository.DB
 	if cfg.Database.DSN != "" {
-		db, err := repository.NewDB(cfg)
+		conn, err := repository.NewDB(cfg)
 		if err == nil {
 			logger.Info("✅ Connected to PostgreSQL")
-			auditRepo = repository.NewPostgresAuditRepo(db)
+			db = conn
+			auditRepo = repository.NewPostgresAuditRepo(conn)
 		} else {
 			logger.Error("⚠️ Failed to connect to DB, audit logs will be file-only", "error", err)
 		}
 	}
 
+	// Risk Persistence (Postgres > Redis > Memory)
+	var riskRepo service.UsageRepo
+	if db != nil {
+		pgUsage, err := repository.NewPostgresUsageRepo(db)
+		if err == nil {
+			riskRepo = pgUsage
+		} else {
+			logger.Error("⚠️ Failed to prepare risk tables, daily usage will not be persisted to DB", "error", err)
+		}
+	}
+	if riskRepo == nil && redisClient != nil {
+		riskRepo = redisClient
+	}
+	if riskRepo == nil {
+		riskRepo = service.NewRiskUsageStore()
+	}
+
+	// Fill Persistence (Postgres > Redis > Memory)
+	var fillRepo market.FillRepo
+	if db != nil {
+		pgFills, err := repository.NewPostgresFillRepo(db)
+		if err == nil {
+			fillRepo = pgFills
+		} else {
+			logger.Error("⚠️ Failed to prepare fill tables, fills will not be persisted to DB", "error", err)
+		}
+	}
+	if fillRepo == nil && redisClient != nil {
+		fillRepo = redisClient
+	}
+	fillStore := market.NewFillStore(market.DefaultFillsPerTenant, fillRepo)
+
+	// Order Lifecycle Persistence (Postgres > Redis > Memory)
+	var orderRepo market.OrderTrackerRepo
+	if db != nil {
+		pgOrders, err := repository.NewPostgresOrderRepo(db)
+		if err == nil {
+			orderRepo = pgOrders
+		} else {
+			logger.Error("⚠️ Failed to prepare order tables, order lifecycle will not be persisted to DB", "error", err)
+		}
+	}
+	if orderRepo == nil && redisClient != nil {
+		orderRepo = redisClient
+	}
+	orderTracker := market.NewOrderTracker(orderRepo)
+	fillStore.AddListener(orderTracker)
+
 	// 3. Initialize Core Services
 	tenantManager := service.NewTenantManager(cfg, nil)
 	idempotencyStore := middleware.NewInMemIdempotencyStore()
 
 	// Market Data Service
 	marketSvc := market.NewMarketService()
+	if cfg.Market.ResyncSeconds > 0 {
+		// Public CLOB client: periodic REST snapshots keep the shadow books honest
+		publicClient := polymarket.NewClient()
+		marketSvc.SetBookSource(publicClient.CLOB, time.Duration(cfg.Market.ResyncSeconds)*time.Second)
+	}
+	marketSvc.SetMarketInfoSource(market.NewCLOBMarketInfoSource(cfg.Market.CLOBURL, nil), time.Duration(cfg.Market.MarketInfoTTLSeconds)*time.Second)
 	marketSvc.Start()
 
-	// User Execution Stream
-	var userStream *market.UserStream
-	if cfg.Polymarket.ApiKey != "" {
-		userStream = market.NewUserStream(cfg.Polymarket.ApiKey, cfg.Polymarket.ApiSecret, cfg.Polymarket.ApiPassphrase)
-		userStream.Start()
+	// User Execution Streams (one per tenant with L2 credentials)
+	userStreams := market.NewUserStreamManager(fillStore)
+	for _, tenant := range tenantManager.ListTenants() {
+		userStreams.OnTenantUpsert(tenant)
 	}
+	tenantSvc := service.NewTenantService(tenantManager, nil)
+	tenantSvc.AddListener(userStreams)
+
+	// Positions & open orders, fed by the user streams
+	positions := market.NewPositionStore()
+	fillStore.AddListener(positions)
 
 	riskEngine := service.NewRiskEngine(riskRepo, marketSvc)
+	riskEngine.SetPositionStore(positions, model.ExposureLimits{
+		MaxPositionSize:   cfg.Risk.MaxPositionSize,
+		MaxEventValue:     cfg.Risk.MaxEventValue,
+		MaxOpenOrderValue: cfg.Risk.MaxOpenOrderValue,
+	})
 
 	auditSvc, err := service.NewAuditService("./logs", auditRepo)
 	if err != nil {
@@ -86,11 +150,33 @@ func main() {
 		os.Exit(1)
 	}
 
-	gatewaySvc, err := service.NewGatewayService(cfg, tenantManager, riskEngine, marketSvc, userStream)
+	// Panic State Persistence (Postgres > Redis > Memory)
+	var panicRepo service.PanicRepo
+	if db != nil {
+		pgPanic, err := repository.NewPostgresPanicRepo(db)
+		if err == nil {
+			panicRepo = pgPanic
+		} else {
+			logger.Error("⚠️ Failed to prepare panic table, panic state will not be persisted to DB", "error", err)
+		}
+	}
+	if panicRepo == nil && redisClient != nil {
+		panicRepo = redisClient
+	}
+	panicSvc := service.NewPanicService(context.Background(), panicRepo, auditSvc)
+
+	gatewaySvc, err := service.NewGatewayService(cfg, tenantManager, riskEngine, marketSvc, fillStore, panicSvc)
 	if err != nil {
 		logger.Error("Failed to initialize gateway service", "error", err)
 		os.Exit(1)
 	}
+	gatewaySvc.SetOrderTracker(orderTracker)
+	fillStore.AddListener(gatewaySvc)
+
+	// Exposure must include what was filled or left resting before this start
+	seedCtx, seedCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	gatewaySvc.SeedPositions(seedCtx, positions, fillRepo)
+	seedCancel()
 
 	builderConfig := &relayer.BuilderConfig{
 		Local: &relayer.BuilderCredentials{
@@ -100,11 +186,96 @@ func main() {
 		},
 	}
 
+	// Loss guard baseline persistence (Postgres > Redis > Memory)
+	var pnlRepo service.PnLRepo
+	if db != nil {
+		pgPnL, err := repository.NewPostgresPnLRepo(db)
+		if err == nil {
+			pnlRepo = pgPnL
+		} else {
+			logger.Error("⚠️ Failed to prepare pnl baseline table, loss guard peaks will not be persisted to DB", "error", err)
+		}
+	}
+	if pnlRepo == nil && redisClient != nil {
+		pnlRepo = redisClient
+	}
+
+	// Loss kill switch: marks positions at mid and halts tenants past their loss limits
+	pnlSvc := service.NewPnLService(context.Background(), pnlRepo, positions, marketSvc, tenantManager, gatewaySvc, auditSvc, model.LossLimits{
+		MaxDailyLoss: cfg.Risk.MaxDailyLoss,
+		MaxDrawdown:  cfg.Risk.MaxDrawdown,
+	})
+	pnlSvc.Start(time.Duration(cfg.Risk.PnLCheckSeconds) * time.Second)
+
+	// Dead man's switch: cancels a tenant's orders when its heartbeats stop
+	heartbeatSvc := service.NewHeartbeatService(tenantManager, gatewaySvc, auditSvc)
+	heartbeatSvc.Start(service.DefaultHeartbeatCheckInterval)
+
+	// Conditional Order Persistence (Postgres > Redis > Memory)
+	var conditionalRepo service.ConditionalRepo
+	if db != nil {
+		pgConditional, err := repository.NewPostgresConditionalRepo(db)
+		if err == nil {
+			conditionalRepo = pgConditional
+		} else {
+			logger.Error("⚠️ Failed to prepare conditional order table, triggers will not be persisted to DB", "error", err)
+		}
+	}
+	if conditionalRepo == nil && redisClient != nil {
+		conditionalRepo = redisClient
+	}
+	// Trigger engine: stop / take-profit / trailing orders placed through the regular risk path
+	conditionalSvc := service.NewConditionalService(context.Background(), conditionalRepo, gatewaySvc, marketSvc, tenantManager, auditSvc)
+
+	// Order Group Persistence (Postgres > Redis > Memory)
+	var orderGroupRepo service.OrderGroupRepo
+	if db != nil {
+		pgGroups, err := repository.NewPostgresOrderGroupRepo(db)
+		if err == nil {
+			orderGroupRepo = pgGroups
+		} else {
+			logger.Error("⚠️ Failed to prepare order group table, OCO / bracket groups will not be persisted to DB", "error", err)
+		}
+	}
+	if orderGroupRepo == nil && redisClient != nil {
+		orderGroupRepo = redisClient
+	}
+	// OCO / bracket groups, driven by user-channel fills and trigger firings
+	orderGroupSvc := service.NewOrderGroupService(context.Background(), orderGroupRepo, gatewaySvc, conditionalSvc, fillStore, tenantManager, auditSvc)
+	fillStore.AddListener(orderGroupSvc)
+	conditionalSvc.AddFireListener(orderGroupSvc)
+	conditionalSvc.Start(service.DefaultConditionalCheckInterval)
+
+	// Algo Persistence (Postgres > Redis > Memory)
+	var algoRepo service.AlgoRepo
+	if db != nil {
+		pgAlgos, err := repository.NewPostgresAlgoRepo(db)
+		if err == nil {
+			algoRepo = pgAlgos
+		} else {
+			logger.Error("⚠️ Failed to prepare algo table, algos will not be persisted to DB", "error", err)
+		}
+	}
+	if algoRepo == nil && redisClient != nil {
+		algoRepo = redisClient
+	}
+	// TWAP / VWAP / iceberg / pegged parent orders
+	algoSvc := service.NewAlgoService(context.Background(), algoRepo, gatewaySvc, marketSvc, fillStore, tenantManager, auditSvc)
+	fillStore.AddListener(algoSvc)
+	algoSvc.Start(service.DefaultAlgoCheckInterval)
+
 	accountSvc := service.NewAccountService(tenantManager, nil, builderConfig, cfg.Relayer)
 
 	// 4. Initialize Handlers
 	orderHandler := handler.NewOrderHandler(gatewaySvc)
 	accountHandler := handler.NewAccountHandler(accountSvc)
+	tenantHandler := handler.NewTenantHandler(tenantSvc)
+	panicHandler := handler.NewPanicHandler(gatewaySvc)
+	pnlHandler := handler.NewPnLHandler(pnlSvc)
+	heartbeatHandler := handler.NewHeartbeatHandler(heartbeatSvc)
+	conditionalHandler := handler.NewConditionalHandler(conditionalSvc)
+	orderGroupHandler := handler.NewOrderGroupHandler(orderGroupSvc)
+	algoHandler := handler.NewAlgoHandler(algoSvc)
 
 	// 5. Setup Router
 	r := gin.Default()
@@ -132,15 +303,54 @@ func main() {
 	v1.Use(middleware.IdempotencyMiddleware(idempotencyStore))
 	{
 		v1.POST("/orders", orderHandler.PlaceOrder)
+		v1.POST("/orders/batch", orderHandler.PlaceOrders)
+		v1.POST("/orders/market", orderHandler.PlaceMarketOrder)
+		v1.GET("/orders", orderHandler.ListOrders)
+		v1.GET("/orders/:id", orderHandler.GetOrder)
+		v1.GET("/orders/client/:client_order_id", orderHandler.GetClientOrder)
+		v1.DELETE("/orders/client/:client_order_id", orderHandler.CancelClientOrder)
+		v1.PUT("/orders/:id", orderHandler.AmendOrder)
 		v1.DELETE("/orders/:id", orderHandler.CancelOrder)
 		v1.DELETE("/orders", orderHandler.CancelAll)
-		v1.DELETE("/panic", orderHandler.Panic)
+		v1.DELETE("/panic", panicHandler.Panic)
+		v1.GET("/panic", panicHandler.Status)
+		v1.POST("/panic/resume", panicHandler.Resume)
 		v1.GET("/fills", orderHandler.GetFills)
+		v1.GET("/pnl", pnlHandler.Get)
+		v1.POST("/heartbeat", heartbeatHandler.Beat)
+		v1.GET("/heartbeat", heartbeatHandler.Status)
+		v1.POST("/conditional-orders", conditionalHandler.Create)
+		v1.GET("/conditional-orders", conditionalHandler.List)
+		v1.GET("/conditional-orders/:id", conditionalHandler.Get)
+		v1.DELETE("/conditional-orders/:id", conditionalHandler.Cancel)
+		v1.POST("/order-groups", orderGroupHandler.Create)
+		v1.GET("/order-groups", orderGroupHandler.List)
+		v1.GET("/order-groups/:id", orderGroupHandler.Get)
+		v1.DELETE("/order-groups/:id", orderGroupHandler.Cancel)
+		v1.POST("/algos", algoHandler.Create)
+		v1.GET("/algos", algoHandler.List)
+		v1.GET("/algos/:id", algoHandler.Get)
+		v1.POST("/algos/:id/pause", algoHandler.Pause)
+		v1.POST("/algos/:id/resume", algoHandler.Resume)
+		v1.DELETE("/algos/:id", algoHandler.Cancel)
 		v1.GET("/markets/:id/book", orderHandler.GetOrderbook)
+		v1.GET("/markets/:id/quote", orderHandler.GetQuote)
 		v1.GET("/account/proxy", accountHandler.GetProxy)
 		v1.POST("/account/proxy", accountHandler.DeployProxy)
 	}
 
+	// Admin Routes (Tenant CRUD): tenants added or re-keyed here get their user stream started / restarted
+	tenantHandler.Register(r, cfg)
+
+	// Admin Routes (Kill Switch)
+	adminPanic := r.Group("/v1/admin/panic")
+	adminPanic.Use(middleware.AdminMiddleware(cfg))
+	{
+		adminPanic.GET("", panicHandler.AdminList)
+		adminPanic.DELETE("", panicHandler.AdminPanic)
+		adminPanic.POST("/resume", panicHandler.AdminResume)
+	}
+
 	// 6. Start Server with Graceful Shutdown
 	srv := &http.Server{
 		Addr:              ":" + cfg.Server.Port,
@@ -168,13 +378,35 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	// Stop accepting new orders before sweeping the book
+	forced := false
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Error("Server forced to shutdown", "error", err)
+		forced = true
+	}
+
+	// Bots can no longer reach us; their lapsing heartbeats must not race the sweep
+	heartbeatSvc.Stop()
+	// No bracket exit, trigger or algo slice may place an order after the sweep
+	orderGroupSvc.Stop()
+	conditionalSvc.Stop()
+	algoSvc.Stop()
+
+	// Cancel resting orders so a redeploy never leaves orphaned quotes
+	sweepCtx, sweepCancel := context.WithTimeout(context.Background(), time.Duration(cfg.Shutdown.TimeoutSeconds)*time.Second)
+	gatewaySvc.CancelOnShutdown(sweepCtx, cfg.Shutdown.CancelPolicy, cfg.Shutdown.Retries, auditSvc)
+	sweepCancel()
+
+	pnlSvc.Stop()
 	marketSvc.Stop()
+	userStreams.StopAll()
+	// Persist the fills still queued once the user channels are quiet
+	fillStore.Close()
+	gatewaySvc.Stop()
 	auditSvc.Close()
 
-	if err := srv.Shutdown(ctx); err != nil {
-		logger.Error("Server forced to shutdown", "error", err)
+	if forced {
 		os.Exit(1)
 	}
-
 	logger.Info("Server exiting")
 }
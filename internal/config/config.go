@@ -14,12 +14,14 @@ type Config struct {
 	Database   DatabaseConfig   `mapstructure:"database"`
 	Redis      RedisConfig      `mapstructure:"redis"`
 	Chain      ChainConfig      `mapstructure:"chain"`
+	Market     MarketConfig     `mapstructure:"market"`
 	Polymarket PolymarketConfig `mapstructure:"polymarket"`
 	Builder    BuilderConfig    `mapstructure:"builder"`
 	Relayer    RelayerConfig    `mapstructure:"relayer"`
 	Risk       RiskConfig       `mapstructure:"risk"`
 	RateLimit  RateLimitConfig  `mapstructure:"rate_limit"`
 	Metrics    MetricsConfig    `mapstructure:"metrics"`
+	Shutdown   ShutdownConfig   `mapstructure:"shutdown"`
 	Tenants    []TenantConfig   `mapstructure:"tenants"`
 }
 
@@ -70,6 +72,16 @@ type ChainConfig struct {
 	EIP1271CacheSeconds int    `mapstructure:"eip1271_cache_seconds"`
 	EIP1271TimeoutMs    int    `mapstructure:"eip1271_timeout_ms"`
 	EIP1271Retries      int    `mapstructure:"eip1271_retries"`
+	NonceRefreshSeconds int    `mapstructure:"nonce_refresh_seconds"`
+}
+
+type MarketConfig struct {
+	// Interval for verifying shadow orderbooks against REST snapshots (0 disables)
+	ResyncSeconds int `mapstructure:"resync_seconds"`
+	// CLOB REST base URL, used for tick size / min order size lookups
+	CLOBURL string `mapstructure:"clob_url"`
+	// How long tick size / min size lookups are cached (tick_size_change events update it live)
+	MarketInfoTTLSeconds int `mapstructure:"market_info_ttl_seconds"`
 }
 
 type BuilderConfig struct {
@@ -89,10 +101,24 @@ type RiskConfig struct {
 	MaxOrderValue             float64  `mapstructure:"max_order_value"`             // e.g. 1000 USDC
 	MaxDailyValue             float64  `mapstructure:"max_daily_value"`             // e.g. 10000 USDC
 	MaxDailyOrders            int      `mapstructure:"max_daily_orders"`            // e.g. 1000 orders
+	MaxPositionSize           float64  `mapstructure:"max_position_size"`           // e.g. 5000 shares per token
+	MaxEventValue             float64  `mapstructure:"max_event_value"`             // e.g. 2000 USDC across an event's outcomes
+	MaxOpenOrderValue         float64  `mapstructure:"max_open_order_value"`        // e.g. 5000 USDC resting per tenant
+	MaxDailyLoss              float64  `mapstructure:"max_daily_loss"`              // e.g. 500 USDC; breach moves the tenant to cancel-only
+	MaxDrawdown               float64  `mapstructure:"max_drawdown"`                // e.g. 1000 USDC from the PnL high-water mark
+	PnLCheckSeconds           int      `mapstructure:"pnl_check_seconds"`           // how often PnL is marked and checked
 	BlacklistedTokenIDs       []string `mapstructure:"blacklisted_token_ids"`       // e.g. ["123", "456"]
+	RoundToTick               bool     `mapstructure:"round_to_tick"`               // round off-tick prices instead of rejecting
 	AllowUnverifiedSignatures bool     `mapstructure:"allow_unverified_signatures"` // allow EIP-1271 or unknown signature types
 }
 
+type ShutdownConfig struct {
+	// cancel_all | cancel_gateway_orders | none
+	CancelPolicy   string `mapstructure:"cancel_policy"`
+	TimeoutSeconds int    `mapstructure:"timeout_seconds"` // Deadline for the whole cancel sweep
+	Retries        int    `mapstructure:"retries"`         // Extra attempts per tenant
+}
+
 type MetricsConfig struct {
 	Enabled bool   `mapstructure:"enabled"`
 	Path    string `mapstructure:"path"`
@@ -128,6 +154,7 @@ func Load() (*Config, error) {
 	viper.SetDefault("server.port", "8080")
 	viper.SetDefault("server.read_only", false)
 	viper.SetDefault("risk.max_slippage", 0.05)
+	viper.SetDefault("risk.pnl_check_seconds", 5)
 	viper.SetDefault("auth.require_api_key", true)
 	viper.SetDefault("auth.admin_key", "")
 	viper.SetDefault("auth.admin_secret_key", "")
@@ -137,6 +164,10 @@ func Load() (*Config, error) {
 	viper.SetDefault("chain.eip1271_cache_seconds", 60)
 	viper.SetDefault("chain.eip1271_timeout_ms", 5000)
 	viper.SetDefault("chain.eip1271_retries", 1)
+	viper.SetDefault("chain.nonce_refresh_seconds", 30)
+	viper.SetDefault("market.resync_seconds", 30)
+	viper.SetDefault("market.clob_url", "https://clob.polymarket.com")
+	viper.SetDefault("market.market_info_ttl_seconds", 600)
 	viper.SetDefault("database.idempotency_retention_hours", 168)
 	viper.SetDefault("database.audit_retention_days", 30)
 	viper.SetDefault("database.risk_retention_days", 30)
@@ -145,6 +176,9 @@ func Load() (*Config, error) {
 	viper.SetDefault("rate_limit.burst", 100)
 	viper.SetDefault("metrics.enabled", true)
 	viper.SetDefault("metrics.path", "/metrics")
+	viper.SetDefault("shutdown.cancel_policy", "cancel_all")
+	viper.SetDefault("shutdown.timeout_seconds", 10)
+	viper.SetDefault("shutdown.retries", 2)
 	viper.SetDefault("redis.addr", "localhost:6379")
 	viper.SetDefault("redis.db", 0)
 
@@ -189,5 +223,11 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	switch c.Shutdown.CancelPolicy {
+	case "", "cancel_all", "cancel_gateway_orders", "none":
+	default:
+		return fmt.Errorf("shutdown.cancel_policy must be one of cancel_all, cancel_gateway_orders, none")
+	}
+
 	return nil
 }
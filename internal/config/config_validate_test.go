@@ -59,3 +59,10 @@ func TestValidateAllowsNoAPIKeyWhenDisabled(t *testing.T) {
 		t.Fatalf("expected require_api_key=false config to be valid, got: %v", err)
 	}
 }
+
+func TestValidateRejectsUnknownShutdownPolicy(t *testing.T) {
+	cfg := &Config{Shutdown: ShutdownConfig{CancelPolicy: "cancel_some"}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected unknown shutdown.cancel_policy to fail validation")
+	}
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/GoPolymarket/polygate/internal/middleware"
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/pkg/apperrors"
+	"github.com/GoPolymarket/polygate/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type AlgoHandler struct {
+	svc *service.AlgoService
+}
+
+func NewAlgoHandler(svc *service.AlgoService) *AlgoHandler {
+	return &AlgoHandler{svc: svc}
+}
+
+// Create handles POST /v1/algos
+func (h *AlgoHandler) Create(c *gin.Context) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+
+	var req model.AlgoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.NewInvalidRequest(err.Error()))
+		return
+	}
+	middleware.AddAuditContext(c, "action", "create_algo")
+	middleware.AddAuditContext(c, "type", req.Type)
+	middleware.AddAuditContext(c, "token_id", req.TokenID)
+	middleware.AddAuditContext(c, "side", req.Side)
+	middleware.AddAuditContext(c, "size", req.Size.String())
+	middleware.AddAuditContext(c, "limit_price", req.LimitPrice.String())
+
+	algo, err := h.svc.Create(c.Request.Context(), tenant, req)
+	if err != nil {
+		middleware.AddAuditContext(c, "error", err.Error())
+		c.Error(mapServiceError(err))
+		return
+	}
+	middleware.AddAuditContext(c, "algo_id", algo.ID)
+	c.JSON(http.StatusOK, algo)
+}
+
+// List handles GET /v1/algos?status=RUNNING
+func (h *AlgoHandler) List(c *gin.Context) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+
+	status := model.AlgoStatus(strings.ToUpper(c.Query("status")))
+	switch status {
+	case "", model.AlgoRunning, model.AlgoPaused, model.AlgoCompleted, model.AlgoExpired, model.AlgoCancelled, model.AlgoFailed:
+	default:
+		c.Error(apperrors.NewInvalidRequest("status must be one of RUNNING, PAUSED, COMPLETED, EXPIRED, CANCELLED, FAILED"))
+		return
+	}
+
+	algos := h.svc.List(tenant.ID, status)
+	c.JSON(http.StatusOK, gin.H{
+		"algos": algos,
+		"count": len(algos),
+	})
+}
+
+// Get handles GET /v1/algos/:id
+func (h *AlgoHandler) Get(c *gin.Context) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+
+	algo, err := h.svc.Get(tenant.ID, c.Param("id"))
+	if err != nil {
+		c.Error(mapServiceError(err))
+		return
+	}
+	c.JSON(http.StatusOK, algo)
+}
+
+// Pause handles POST /v1/algos/:id/pause
+func (h *AlgoHandler) Pause(c *gin.Context) {
+	h.transition(c, "pause_algo", h.svc.Pause)
+}
+
+// Resume handles POST /v1/algos/:id/resume
+func (h *AlgoHandler) Resume(c *gin.Context) {
+	h.transition(c, "resume_algo", h.svc.Resume)
+}
+
+// Cancel handles DELETE /v1/algos/:id
+func (h *AlgoHandler) Cancel(c *gin.Context) {
+	h.transition(c, "cancel_algo", h.svc.Cancel)
+}
+
+func (h *AlgoHandler) transition(c *gin.Context, action string, fn func(tenantID, id string) (*model.Algo, error)) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+	id := c.Param("id")
+
+	middleware.AddAuditContext(c, "action", action)
+	middleware.AddAuditContext(c, "algo_id", id)
+
+	algo, err := fn(tenant.ID, id)
+	if err != nil {
+		middleware.AddAuditContext(c, "error", err.Error())
+		c.Error(mapServiceError(err))
+		return
+	}
+	c.JSON(http.StatusOK, algo)
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/GoPolymarket/polygate/internal/middleware"
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/pkg/apperrors"
+	"github.com/GoPolymarket/polygate/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ConditionalHandler struct {
+	svc *service.ConditionalService
+}
+
+func NewConditionalHandler(svc *service.ConditionalService) *ConditionalHandler {
+	return &ConditionalHandler{svc: svc}
+}
+
+// Create handles POST /v1/conditional-orders
+func (h *ConditionalHandler) Create(c *gin.Context) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+
+	var req model.ConditionalOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.NewInvalidRequest(err.Error()))
+		return
+	}
+	middleware.AddAuditContext(c, "action", "create_conditional_order")
+	middleware.AddAuditContext(c, "type", req.Type)
+	middleware.AddAuditContext(c, "token_id", req.Order.TokenID)
+	middleware.AddAuditContext(c, "side", req.Order.Side)
+	middleware.AddAuditContext(c, "trigger_price", req.TriggerPrice.String())
+	middleware.AddAuditContext(c, "trail_amount", req.TrailAmount.String())
+
+	order, err := h.svc.Create(c.Request.Context(), tenant, req)
+	if err != nil {
+		middleware.AddAuditContext(c, "error", err.Error())
+		c.Error(mapServiceError(err))
+		return
+	}
+	middleware.AddAuditContext(c, "conditional_id", order.ID)
+	c.JSON(http.StatusOK, order)
+}
+
+// List handles GET /v1/conditional-orders?status=ACTIVE
+func (h *ConditionalHandler) List(c *gin.Context) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+
+	status := model.ConditionalStatus(strings.ToUpper(c.Query("status")))
+	switch status {
+	case "", model.ConditionalActive, model.ConditionalTriggered, model.ConditionalFailed, model.ConditionalCancelled:
+	default:
+		c.Error(apperrors.NewInvalidRequest("status must be one of ACTIVE, TRIGGERED, FAILED, CANCELLED"))
+		return
+	}
+
+	orders := h.svc.List(tenant.ID, status)
+	c.JSON(http.StatusOK, gin.H{
+		"conditional_orders": orders,
+		"count":              len(orders),
+	})
+}
+
+// Get handles GET /v1/conditional-orders/:id
+func (h *ConditionalHandler) Get(c *gin.Context) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+
+	order, err := h.svc.Get(tenant.ID, c.Param("id"))
+	if err != nil {
+		c.Error(mapServiceError(err))
+		return
+	}
+	c.JSON(http.StatusOK, order)
+}
+
+// Cancel handles DELETE /v1/conditional-orders/:id
+func (h *ConditionalHandler) Cancel(c *gin.Context) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+	id := c.Param("id")
+
+	middleware.AddAuditContext(c, "action", "cancel_conditional_order")
+	middleware.AddAuditContext(c, "conditional_id", id)
+
+	order, err := h.svc.Cancel(c.Request.Context(), tenant.ID, id)
+	if err != nil {
+		middleware.AddAuditContext(c, "error", err.Error())
+		c.Error(mapServiceError(err))
+		return
+	}
+	c.JSON(http.StatusOK, order)
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/GoPolymarket/polygate/internal/middleware"
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/pkg/apperrors"
+	"github.com/GoPolymarket/polygate/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type HeartbeatHandler struct {
+	svc *service.HeartbeatService
+}
+
+func NewHeartbeatHandler(svc *service.HeartbeatService) *HeartbeatHandler {
+	return &HeartbeatHandler{svc: svc}
+}
+
+// Beat handles POST /v1/heartbeat: arms / extends the dead man's switch, ttl_seconds 0 disarms it.
+func (h *HeartbeatHandler) Beat(c *gin.Context) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+
+	var req model.HeartbeatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.NewInvalidRequest(err.Error()))
+		return
+	}
+
+	hb, renewed, err := h.svc.Beat(c.Request.Context(), tenant, req)
+	if err != nil {
+		c.Error(mapServiceError(err))
+		return
+	}
+	if renewed {
+		// Renewals arrive every few seconds per bot and change nothing worth auditing
+		middleware.SkipAudit(c)
+	}
+	if hb == nil {
+		middleware.AddAuditContext(c, "action", "heartbeat_disarmed")
+		middleware.AddAuditContext(c, "session_id", req.SessionID)
+		c.JSON(http.StatusOK, gin.H{"armed": false, "session_id": req.SessionID})
+		return
+	}
+	middleware.AddAuditContext(c, "action", "heartbeat_armed")
+	middleware.AddAuditContext(c, "session_id", req.SessionID)
+	middleware.AddAuditContext(c, "ttl_seconds", req.TTLSeconds)
+	c.JSON(http.StatusOK, gin.H{"armed": true, "heartbeat": hb})
+}
+
+// Status handles GET /v1/heartbeat
+func (h *HeartbeatHandler) Status(c *gin.Context) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+	heartbeats := h.svc.Status(tenant.ID)
+	c.JSON(http.StatusOK, gin.H{"heartbeats": heartbeats, "count": len(heartbeats)})
+}
@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/GoPolymarket/polygate/internal/middleware"
@@ -9,6 +11,7 @@ import (
 	"github.com/GoPolymarket/polygate/internal/pkg/apperrors"
 	"github.com/GoPolymarket/polygate/internal/service"
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
 )
 
 type OrderHandler struct {
@@ -32,6 +35,14 @@ func (h *OrderHandler) PlaceOrder(c *gin.Context) {
 		c.Error(apperrors.NewInvalidRequest(err.Error()))
 		return
 	}
+	// 以字符串记录，避免审计日志中的价格/数量精度丢失
+	middleware.AddAuditContext(c, "token_id", req.TokenID)
+	middleware.AddAuditContext(c, "side", req.Side)
+	middleware.AddAuditContext(c, "price", req.Price.String())
+	middleware.AddAuditContext(c, "size", req.Size.String())
+	if req.ClientOrderID != "" {
+		middleware.AddAuditContext(c, "client_order_id", req.ClientOrderID)
+	}
 
 	resp, err := h.svc.PlaceOrder(c.Request.Context(), tenant, req)
 	if err != nil {
@@ -44,6 +55,63 @@ func (h *OrderHandler) PlaceOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// PlaceOrders handles POST /v1/orders/batch: a JSON array of orders, all signed by the gateway.
+func (h *OrderHandler) PlaceOrders(c *gin.Context) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+
+	var reqs []model.OrderRequest
+	if err := c.ShouldBindJSON(&reqs); err != nil {
+		c.Error(apperrors.NewInvalidRequest(err.Error()))
+		return
+	}
+	middleware.AddAuditContext(c, "action", "place_batch")
+	middleware.AddAuditContext(c, "orders", len(reqs))
+
+	resp, err := h.svc.PlaceOrders(c.Request.Context(), tenant, reqs)
+	if err != nil {
+		middleware.AddAuditContext(c, "error", err.Error())
+		c.Error(mapServiceError(err))
+		return
+	}
+
+	middleware.AddAuditContext(c, "placed", resp.Placed)
+	middleware.AddAuditContext(c, "failed", resp.Failed)
+	c.JSON(http.StatusOK, resp)
+}
+
+// PlaceMarketOrder handles POST /v1/orders/market: an amount or size swept through
+// the book as a FOK / FAK order, no further than worst_price.
+func (h *OrderHandler) PlaceMarketOrder(c *gin.Context) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+
+	var req model.MarketOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.NewInvalidRequest(err.Error()))
+		return
+	}
+	middleware.AddAuditContext(c, "action", "place_market_order")
+	middleware.AddAuditContext(c, "token_id", req.TokenID)
+	middleware.AddAuditContext(c, "side", req.Side)
+	middleware.AddAuditContext(c, "amount", req.Amount.String())
+	middleware.AddAuditContext(c, "size", req.Size.String())
+	middleware.AddAuditContext(c, "worst_price", req.WorstPrice.String())
+	if req.ClientOrderID != "" {
+		middleware.AddAuditContext(c, "client_order_id", req.ClientOrderID)
+	}
+
+	resp, err := h.svc.PlaceMarketOrder(c.Request.Context(), tenant, req)
+	if err != nil {
+		middleware.AddAuditContext(c, "error", err.Error())
+		c.Error(mapServiceError(err))
+		return
+	}
+
+	middleware.AddAuditContext(c, "order_id", resp.OrderID)
+	middleware.AddAuditContext(c, "limit_price", resp.LimitPrice.String())
+	middleware.AddAuditContext(c, "expected_size", resp.Expected.Size.String())
+	c.JSON(http.StatusOK, resp)
+}
+
 func (h *OrderHandler) BuildTypedOrder(c *gin.Context) {
 	tenantVal, exists := c.Get(middleware.ContextTenantKey)
 	if !exists {
@@ -89,9 +157,95 @@ func (h *OrderHandler) CancelOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// GetClientOrder handles GET /v1/orders/client/:client_order_id
+func (h *OrderHandler) GetClientOrder(c *gin.Context) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+
+	order, err := h.svc.GetClientOrder(c.Request.Context(), tenant, c.Param("client_order_id"))
+	if err != nil {
+		c.Error(mapServiceError(err))
+		return
+	}
+	c.JSON(http.StatusOK, order)
+}
+
+// CancelClientOrder handles DELETE /v1/orders/client/:client_order_id
+func (h *OrderHandler) CancelClientOrder(c *gin.Context) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+	clientOrderID := c.Param("client_order_id")
+
+	middleware.AddAuditContext(c, "action", "cancel_order")
+	middleware.AddAuditContext(c, "client_order_id", clientOrderID)
+
+	resp, err := h.svc.CancelClientOrder(c.Request.Context(), tenant, clientOrderID)
+	if err != nil {
+		middleware.AddAuditContext(c, "error", err.Error())
+		c.Error(mapServiceError(err))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// AmendOrder handles PUT /v1/orders/:id (cancel/replace). When a leg fails the
+// body still reports which legs went through.
+func (h *OrderHandler) AmendOrder(c *gin.Context) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+	orderID := c.Param("id")
+
+	var req model.AmendOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.NewInvalidRequest(err.Error()))
+		return
+	}
+	middleware.AddAuditContext(c, "action", "amend_order")
+	middleware.AddAuditContext(c, "order_id", orderID)
+	middleware.AddAuditContext(c, "price", req.Price.String())
+	middleware.AddAuditContext(c, "size", req.Size.String())
+
+	resp, err := h.svc.AmendOrder(c.Request.Context(), tenant, orderID, req)
+	if err != nil {
+		middleware.AddAuditContext(c, "error", err.Error())
+		appErr := apperrors.Wrap(mapServiceError(err))
+		if resp == nil {
+			c.Error(appErr)
+			return
+		}
+		middleware.AddAuditContext(c, "cancelled", resp.Cancelled)
+		c.JSON(appErr.HTTPStatus, resp)
+		return
+	}
+
+	middleware.AddAuditContext(c, "new_order_id", resp.OrderID)
+	c.JSON(http.StatusOK, resp)
+}
+
+// CancelAll handles DELETE /v1/orders. With ?market=&token_id=&side= only the
+// matching orders are cancelled; without a filter everything is.
 func (h *OrderHandler) CancelAll(c *gin.Context) {
 	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
 
+	filter, err := bindOrderFilter(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !filter.IsEmpty() {
+		middleware.AddAuditContext(c, "action", "cancel_filtered")
+		middleware.AddAuditContext(c, "market", filter.Market)
+		middleware.AddAuditContext(c, "token_id", filter.AssetID)
+		middleware.AddAuditContext(c, "side", filter.Side)
+
+		resp, err := h.svc.CancelOrders(c.Request.Context(), tenant, filter)
+		if err != nil {
+			middleware.AddAuditContext(c, "error", err.Error())
+			c.Error(mapServiceError(err))
+			return
+		}
+		middleware.AddAuditContext(c, "cancelled", resp.Count)
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
 	resp, err := h.svc.CancelAllOrders(c.Request.Context(), tenant)
 	if err != nil {
 		c.Error(mapServiceError(err))
@@ -103,6 +257,39 @@ func (h *OrderHandler) CancelAll(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// ListOrders handles GET /v1/orders (?market=&token_id=&side=)
+func (h *OrderHandler) ListOrders(c *gin.Context) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+
+	filter, err := bindOrderFilter(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	orders, err := h.svc.ListOrders(c.Request.Context(), tenant, filter)
+	if err != nil {
+		c.Error(mapServiceError(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"orders": orders,
+		"count":  len(orders),
+	})
+}
+
+// GetOrder handles GET /v1/orders/:id
+func (h *OrderHandler) GetOrder(c *gin.Context) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+
+	order, err := h.svc.GetOrder(c.Request.Context(), tenant, c.Param("id"))
+	if err != nil {
+		c.Error(mapServiceError(err))
+		return
+	}
+	c.JSON(http.StatusOK, order)
+}
+
 func (h *OrderHandler) GetOrderbook(c *gin.Context) {
 	tokenID := c.Param("id")
 	if tokenID == "" {
@@ -117,36 +304,85 @@ func (h *OrderHandler) GetOrderbook(c *gin.Context) {
 	}
 
 	bids, asks := book.GetCopy()
+	meta := book.Meta()
 	c.JSON(http.StatusOK, gin.H{
-		"token_id":     tokenID,
-		"last_updated": book.LastUpdated,
-		"bids":         bids,
-		"asks":         asks,
+		"token_id":         tokenID,
+		"market":           meta.Market,
+		"last_updated":     meta.LastUpdated,
+		"tick_size":        meta.TickSize,
+		"last_trade_price": meta.LastTradePrice,
+		"last_trade_size":  meta.LastTradeSize,
+		"last_trade_side":  meta.LastTradeSide,
+		"last_trade_at":    meta.LastTradeAt,
+		"hash":             meta.Hash,
+		"valid":            meta.Valid,
+		"invalid_reason":   meta.InvalidReason,
+		"bids":             bids,
+		"asks":             asks,
 	})
 }
 
-func (h *OrderHandler) GetFills(c *gin.Context) {
-	fills := h.svc.GetFills()
-	c.JSON(http.StatusOK, gin.H{
-		"fills": fills,
-		"count": len(fills),
-	})
-}
+// GetQuote handles GET /v1/markets/:id/quote?side=BUY&size=100: the expected
+// cost of taking size from the shadow orderbook.
+func (h *OrderHandler) GetQuote(c *gin.Context) {
+	size, err := decimal.NewFromString(c.Query("size"))
+	if err != nil {
+		c.Error(apperrors.NewInvalidRequest("size must be a decimal number"))
+		return
+	}
 
-func (h *OrderHandler) Panic(c *gin.Context) {
-	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
-	
-	if err := h.svc.ActivatePanicMode(c.Request.Context(), tenant); err != nil {
+	quote, err := h.svc.QuoteOrder(c.Param("id"), c.Query("side"), size)
+	if err != nil {
 		c.Error(mapServiceError(err))
 		return
 	}
+	c.JSON(http.StatusOK, quote)
+}
+
+func (h *OrderHandler) GetFills(c *gin.Context) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+
+	filter := model.FillFilter{
+		Market:  c.Query("market"),
+		AssetID: c.Query("asset_id"),
+		OrderID: c.Query("order_id"),
+		Limit:   100,
+	}
+	if raw := c.Query("limit"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil {
+			filter.Limit = parsed
+		}
+	}
+	if raw := c.Query("from"); raw != "" {
+		t, err := parseTime(raw)
+		if err != nil {
+			c.Error(apperrors.NewInvalidRequest(err.Error()))
+			return
+		}
+		filter.From = &t
+	}
+	if raw := c.Query("to"); raw != "" {
+		t, err := parseTime(raw)
+		if err != nil {
+			c.Error(apperrors.NewInvalidRequest(err.Error()))
+			return
+		}
+		filter.To = &t
+	}
 
-	middleware.AddAuditContext(c, "action", "panic_mode_activated")
-	c.JSON(http.StatusOK, gin.H{"status": "panic_mode_active", "message": "all trading suspended and orders cancelled"})
+	fills := h.svc.GetFills(c.Request.Context(), tenant, filter)
+	c.JSON(http.StatusOK, gin.H{
+		"fills": fills,
+		"count": len(fills),
+	})
 }
 
 // mapServiceError maps generic errors to AppErrors based on content
 func mapServiceError(err error) error {
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
 	msg := err.Error()
 	if strings.Contains(msg, "risk reject") {
 		return apperrors.NewRiskReject(msg)
@@ -161,4 +397,20 @@ func mapServiceError(err error) error {
 		return apperrors.New(apperrors.ErrSystemPanic, msg, err)
 	}
 	return apperrors.Wrap(err)
+}
+
+// bindOrderFilter reads ?market=&token_id=&side= (asset_id is accepted for token_id).
+func bindOrderFilter(c *gin.Context) (model.OrderFilter, error) {
+	filter := model.OrderFilter{
+		Market:  c.Query("market"),
+		AssetID: c.Query("token_id"),
+		Side:    strings.ToUpper(c.Query("side")),
+	}
+	if filter.AssetID == "" {
+		filter.AssetID = c.Query("asset_id")
+	}
+	if filter.Side != "" && filter.Side != "BUY" && filter.Side != "SELL" {
+		return filter, apperrors.NewInvalidRequest("side must be BUY or SELL")
+	}
+	return filter, nil
 }
\ No newline at end of file
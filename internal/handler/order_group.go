@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/GoPolymarket/polygate/internal/middleware"
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/pkg/apperrors"
+	"github.com/GoPolymarket/polygate/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type OrderGroupHandler struct {
+	svc *service.OrderGroupService
+}
+
+func NewOrderGroupHandler(svc *service.OrderGroupService) *OrderGroupHandler {
+	return &OrderGroupHandler{svc: svc}
+}
+
+// Create handles POST /v1/order-groups
+func (h *OrderGroupHandler) Create(c *gin.Context) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+
+	var req model.OrderGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.NewInvalidRequest(err.Error()))
+		return
+	}
+	middleware.AddAuditContext(c, "action", "create_order_group")
+	middleware.AddAuditContext(c, "type", req.Type)
+	middleware.AddAuditContext(c, "legs", len(req.Legs))
+
+	group, err := h.svc.Create(c.Request.Context(), tenant, req)
+	if err != nil {
+		middleware.AddAuditContext(c, "error", err.Error())
+		c.Error(mapServiceError(err))
+		return
+	}
+	middleware.AddAuditContext(c, "group_id", group.ID)
+	c.JSON(http.StatusOK, group)
+}
+
+// List handles GET /v1/order-groups?status=ACTIVE
+func (h *OrderGroupHandler) List(c *gin.Context) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+
+	status := model.OrderGroupStatus(strings.ToUpper(c.Query("status")))
+	switch status {
+	case "", model.OrderGroupPendingEntry, model.OrderGroupActive, model.OrderGroupDone, model.OrderGroupCancelled, model.OrderGroupFailed:
+	default:
+		c.Error(apperrors.NewInvalidRequest("status must be one of PENDING_ENTRY, ACTIVE, DONE, CANCELLED, FAILED"))
+		return
+	}
+
+	groups := h.svc.List(tenant.ID, status)
+	c.JSON(http.StatusOK, gin.H{
+		"order_groups": groups,
+		"count":        len(groups),
+	})
+}
+
+// Get handles GET /v1/order-groups/:id
+func (h *OrderGroupHandler) Get(c *gin.Context) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+
+	group, err := h.svc.Get(tenant.ID, c.Param("id"))
+	if err != nil {
+		c.Error(mapServiceError(err))
+		return
+	}
+	c.JSON(http.StatusOK, group)
+}
+
+// Cancel handles DELETE /v1/order-groups/:id
+func (h *OrderGroupHandler) Cancel(c *gin.Context) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+	id := c.Param("id")
+
+	middleware.AddAuditContext(c, "action", "cancel_order_group")
+	middleware.AddAuditContext(c, "group_id", id)
+
+	group, err := h.svc.Cancel(c.Request.Context(), tenant, id)
+	if err != nil {
+		middleware.AddAuditContext(c, "error", err.Error())
+		c.Error(mapServiceError(err))
+		return
+	}
+	c.JSON(http.StatusOK, group)
+}
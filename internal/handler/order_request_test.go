@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+func TestOrderRequestDecimalBinding(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var bound model.OrderRequest
+	router := gin.New()
+	router.POST("/orders", func(c *gin.Context) {
+		bound = model.OrderRequest{}
+		if err := c.ShouldBindJSON(&bound); err != nil {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	post := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	cases := []struct {
+		body  string
+		price string
+		size  string
+	}{
+		{`{"token_id":"1","side":"BUY","price":"0.55","size":"10"}`, "0.55", "10"},
+		{`{"token_id":"1","side":"BUY","price":0.55,"size":10}`, "0.55", "10"},
+		// Numbers are parsed from their literal, never through float64
+		{`{"token_id":"1","side":"SELL","price":0.123456789012345678,"size":"12345678901234.01"}`, "0.123456789012345678", "12345678901234.01"},
+		{`{"token_id":"1","side":"BUY","price":"1e-2","size":"5"}`, "0.01", "5"},
+	}
+	for _, tc := range cases {
+		if code := post(tc.body); code != http.StatusOK {
+			t.Fatalf("expected 200 for %s, got %d", tc.body, code)
+		}
+		if !bound.Price.Equal(decimal.RequireFromString(tc.price)) || !bound.Size.Equal(decimal.RequireFromString(tc.size)) {
+			t.Fatalf("body %s bound to price %s size %s", tc.body, bound.Price, bound.Size)
+		}
+	}
+
+	for _, body := range []string{
+		`{"token_id":"1","side":"BUY","price":"abc","size":"10"}`,
+		`{"token_id":"1","side":"BUY","price":"0.5"}`,
+		`{"token_id":"1","side":"BUY","price":true,"size":"10"}`,
+	} {
+		if code := post(body); code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %s, got %d", body, code)
+		}
+	}
+}
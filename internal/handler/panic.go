@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/GoPolymarket/polygate/internal/middleware"
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/pkg/apperrors"
+	"github.com/GoPolymarket/polygate/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+const adminActor = "admin"
+
+type PanicHandler struct {
+	svc *service.GatewayService
+}
+
+func NewPanicHandler(svc *service.GatewayService) *PanicHandler {
+	return &PanicHandler{svc: svc}
+}
+
+// Panic halts trading for the calling tenant and cancels its open orders.
+func (h *PanicHandler) Panic(c *gin.Context) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+	req, ok := bindPanicRequest(c)
+	if !ok {
+		return
+	}
+	if filter := req.Filter(); !filter.IsEmpty() {
+		h.scopedPanic(c, tenant, filter, "tenant:"+tenant.ID, req.Reason)
+		return
+	}
+
+	state, err := h.svc.ActivatePanicMode(c.Request.Context(), tenant, "tenant:"+tenant.ID, req.Reason)
+	middleware.AddAuditContext(c, "action", "panic_mode_activated")
+	middleware.AddAuditContext(c, "reason", req.Reason)
+	if err != nil {
+		// Halt is in force even if the cancel sweep failed
+		middleware.AddAuditContext(c, "error", err.Error())
+		c.Error(apperrors.New(apperrors.ErrUpstream, "trading suspended but cancel-all failed", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "panic_mode_active", "message": "all trading suspended and orders cancelled", "state": state})
+}
+
+// Resume lifts the calling tenant's halt. A global halt, or one raised by an admin
+// or the loss guard, can only be lifted by an admin.
+func (h *PanicHandler) Resume(c *gin.Context) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+	req, ok := bindPanicRequest(c)
+	if !ok {
+		return
+	}
+
+	state, err := h.svc.ResumeScoped(c.Request.Context(), tenant.ID, req.Filter(), "tenant:"+tenant.ID, req.Reason)
+	if err != nil {
+		c.Error(mapPanicError(err))
+		return
+	}
+	middleware.AddAuditContext(c, "action", "panic_mode_resumed")
+	middleware.AddAuditContext(c, "reason", req.Reason)
+
+	global, _ := h.svc.PanicStatus(tenant.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"status":        "resumed",
+		"state":         state,
+		"global_halted": global != nil && global.Active,
+	})
+}
+
+func (h *PanicHandler) Status(c *gin.Context) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+	global, scoped := h.svc.PanicStatus(tenant.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"halted": (global != nil && global.Active) || (scoped != nil && scoped.Active),
+		"global": global,
+		"tenant": scoped,
+		"scoped": h.svc.ScopedPanicStates(tenant.ID),
+	})
+}
+
+// AdminPanic halts trading globally, or for one tenant when tenant_id is given.
+func (h *PanicHandler) AdminPanic(c *gin.Context) {
+	req, ok := bindPanicRequest(c)
+	if !ok {
+		return
+	}
+	middleware.AddAuditContext(c, "reason", req.Reason)
+	filter := req.Filter()
+	if req.TenantID == "" && !filter.IsEmpty() {
+		c.Error(apperrors.NewInvalidRequest("tenant_id is required for a market / token / side scoped panic"))
+		return
+	}
+
+	if req.TenantID != "" {
+		tenant, found := h.svc.GetTenant(req.TenantID)
+		if !found {
+			c.Error(apperrors.New(apperrors.ErrNotFound, "tenant not found", nil))
+			return
+		}
+		if !filter.IsEmpty() {
+			h.scopedPanic(c, tenant, filter, adminActor, req.Reason)
+			return
+		}
+		middleware.AddAuditContext(c, "action", "panic_mode_activated")
+		middleware.AddAuditContext(c, "tenant_id", tenant.ID)
+		state, err := h.svc.ActivatePanicMode(c.Request.Context(), tenant, adminActor, req.Reason)
+		if err != nil {
+			middleware.AddAuditContext(c, "error", err.Error())
+			c.Error(apperrors.New(apperrors.ErrUpstream, "trading suspended but cancel-all failed", err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "panic_mode_active", "state": state})
+		return
+	}
+
+	middleware.AddAuditContext(c, "action", "global_panic_activated")
+	state, failed := h.svc.ActivateGlobalPanic(c.Request.Context(), adminActor, req.Reason)
+	if len(failed) > 0 {
+		middleware.AddAuditContext(c, "cancel_failed", failed)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "panic_mode_active", "state": state, "cancel_failed": failed})
+}
+
+// AdminResume lifts the global halt, or one tenant's halt when tenant_id is given.
+func (h *PanicHandler) AdminResume(c *gin.Context) {
+	req, ok := bindPanicRequest(c)
+	if !ok {
+		return
+	}
+	filter := req.Filter()
+	if req.TenantID == "" && !filter.IsEmpty() {
+		c.Error(apperrors.NewInvalidRequest("tenant_id is required for a market / token / side scoped resume"))
+		return
+	}
+	state, err := h.svc.ResumeScoped(c.Request.Context(), req.TenantID, filter, adminActor, req.Reason)
+	if err != nil {
+		c.Error(mapPanicError(err))
+		return
+	}
+	middleware.AddAuditContext(c, "action", "panic_mode_resumed")
+	middleware.AddAuditContext(c, "scope", state.Scope)
+	middleware.AddAuditContext(c, "reason", req.Reason)
+	c.JSON(http.StatusOK, gin.H{"status": "resumed", "state": state})
+}
+
+func (h *PanicHandler) AdminList(c *gin.Context) {
+	states := h.svc.ListPanicStates()
+	c.JSON(http.StatusOK, gin.H{"states": states, "count": len(states)})
+}
+
+// scopedPanic halts and cancels only the part of the tenant's book matching filter.
+func (h *PanicHandler) scopedPanic(c *gin.Context, tenant *model.Tenant, filter model.OrderFilter, actor, reason string) {
+	middleware.AddAuditContext(c, "action", "scoped_panic_activated")
+	middleware.AddAuditContext(c, "tenant_id", tenant.ID)
+	middleware.AddAuditContext(c, "reason", reason)
+
+	state, resp, err := h.svc.ActivateScopedPanic(c.Request.Context(), tenant, filter, actor, reason)
+	middleware.AddAuditContext(c, "scope", state.Scope)
+	if err != nil {
+		// Halt is in force even if the cancel sweep failed
+		middleware.AddAuditContext(c, "error", err.Error())
+		c.Error(apperrors.New(apperrors.ErrUpstream, "scope suspended but cancel failed", err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "panic_mode_active", "message": "trading suspended and orders cancelled for scope", "state": state, "cancelled": resp.Cancelled})
+}
+
+// bindPanicRequest accepts an empty body (panic without a reason is allowed).
+func bindPanicRequest(c *gin.Context) (model.PanicRequest, bool) {
+	var req model.PanicRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.Error(apperrors.NewInvalidRequest(err.Error()))
+		return req, false
+	}
+	return req, true
+}
+
+func mapPanicError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrPanicReasonNeeded):
+		return apperrors.NewInvalidRequest(err.Error())
+	case errors.Is(err, service.ErrPanicNotActive):
+		return apperrors.New(apperrors.ErrNotFound, err.Error(), err)
+	case errors.Is(err, service.ErrPanicResumeForbidden):
+		return apperrors.New(apperrors.ErrSystemPanic, err.Error(), err)
+	}
+	return apperrors.Wrap(err)
+}
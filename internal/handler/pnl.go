@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/GoPolymarket/polygate/internal/middleware"
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type PnLHandler struct {
+	svc *service.PnLService
+}
+
+func NewPnLHandler(svc *service.PnLService) *PnLHandler {
+	return &PnLHandler{svc: svc}
+}
+
+// Get returns the calling tenant's realized / unrealized PnL and marked positions.
+func (h *PnLHandler) Get(c *gin.Context) {
+	tenant := c.MustGet(middleware.ContextTenantKey).(*model.Tenant)
+	c.JSON(http.StatusOK, h.svc.Snapshot(tenant.ID))
+}
@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/GoPolymarket/polygate/internal/config"
+	"github.com/GoPolymarket/polygate/internal/middleware"
 	"github.com/GoPolymarket/polygate/internal/model"
 	"github.com/GoPolymarket/polygate/internal/pkg/apperrors"
 	"github.com/GoPolymarket/polygate/internal/repository"
@@ -21,6 +23,20 @@ func NewTenantHandler(svc *service.TenantService) *TenantHandler {
 	return &TenantHandler{svc: svc}
 }
 
+// Register mounts the tenant admin API under /v1/tenants: every route needs the
+// admin key, and the routes revealing or replacing credentials the admin secret too.
+func (h *TenantHandler) Register(r gin.IRouter, cfg *config.Config) {
+	admin := r.Group("/v1/tenants")
+	admin.Use(middleware.AdminMiddleware(cfg))
+	admin.GET("", h.List)
+	admin.POST("", h.Create)
+	admin.GET("/:id", h.Get)
+	admin.PUT("/:id", h.Update)
+	admin.DELETE("/:id", h.Delete)
+	admin.GET("/:id/secret", middleware.AdminSecretMiddleware(cfg), h.GetSecret)
+	admin.PUT("/:id/creds", middleware.AdminSecretMiddleware(cfg), h.UpdateCreds)
+}
+
 func (h *TenantHandler) List(c *gin.Context) {
 	limit := 100
 	offset := 0
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/GoPolymarket/polygate/internal/config"
+	"github.com/GoPolymarket/polygate/internal/middleware"
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type recordingTenantListener struct {
+	upserts []string
+	deletes []string
+}
+
+func (l *recordingTenantListener) OnTenantUpsert(t *model.Tenant) {
+	l.upserts = append(l.upserts, t.ID)
+}
+func (l *recordingTenantListener) OnTenantDelete(id string) { l.deletes = append(l.deletes, id) }
+
+func TestTenantRoutesRequireAdminKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{Auth: config.AuthConfig{AdminKey: "admin", AdminSecretKey: "secret"}}
+	manager := service.NewTenantManager(&config.Config{}, nil)
+	manager.RegisterTenant(&model.Tenant{ID: "tenant-1", ApiKey: "sk-tenant-1", Creds: model.PolymarketCreds{L2ApiSecret: "TENANT_SECRET"}})
+	tenantSvc := service.NewTenantService(manager, nil)
+	listener := &recordingTenantListener{}
+	tenantSvc.AddListener(listener)
+
+	router := gin.New()
+	NewTenantHandler(tenantSvc).Register(router, cfg)
+	call := func(method, path, body string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	admin := []string{middleware.HeaderAdminKey, "admin"}
+	adminSecret := []string{middleware.HeaderAdminKey, "admin", middleware.HeaderAdminSecretKey, "secret"}
+
+	if rec := call(http.MethodGet, "/v1/tenants", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without the admin key, got %d", rec.Code)
+	}
+	rec := call(http.MethodGet, "/v1/tenants", "", admin...)
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "TENANT_SECRET") {
+		t.Fatalf("expected a masked tenant list, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := call(http.MethodGet, "/v1/tenants/tenant-1/secret", "", admin...); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for the secret without the admin secret, got %d", rec.Code)
+	}
+	if rec := call(http.MethodGet, "/v1/tenants/tenant-1/secret", "", adminSecret...); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "TENANT_SECRET") {
+		t.Fatalf("expected the full credentials with the admin secret, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Tenant changes reach the listeners (the user streams in the server)
+	created := call(http.MethodPost, "/v1/tenants", `{"id":"tenant-2","api_key":"sk-tenant-2","creds":{"l2_api_key":"k","l2_api_secret":"s","l2_api_passphrase":"p"}}`, admin...)
+	if created.Code != http.StatusCreated {
+		t.Fatalf("expected 201 on create, got %d %s", created.Code, created.Body.String())
+	}
+	if rec := call(http.MethodDelete, "/v1/tenants/tenant-2", "", admin...); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 on delete, got %d", rec.Code)
+	}
+	if len(listener.upserts) != 1 || listener.upserts[0] != "tenant-2" || len(listener.deletes) != 1 || listener.deletes[0] != "tenant-2" {
+		t.Fatalf("expected the listener to see the create and delete, got upserts=%v deletes=%v", listener.upserts, listener.deletes)
+	}
+}
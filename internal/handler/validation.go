@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"reflect"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+)
+
+func init() {
+	// decimal.Decimal is a struct, which `binding:"required"` never rejects on its own.
+	// Validate it by value instead: a missing or zero amount fails `required`.
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+			if d, ok := field.Interface().(decimal.Decimal); ok && !d.IsZero() {
+				return d.String()
+			}
+			return nil
+		}, decimal.Decimal{})
+	}
+}
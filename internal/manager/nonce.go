@@ -5,24 +5,56 @@ import (
 	"fmt"
 	"math/big"
 	"sync"
+	"time"
 
 	"github.com/GoPolymarket/polygate/internal/pkg/logger"
+	"github.com/GoPolymarket/polygate/internal/pkg/metrics"
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
+// Exchange contracts on Polygon mainnet. Orders are validated against the
+// nonce stored in the contract they settle on: nonces(maker) must EQUAL Order.Nonce.
+var (
+	CTFExchangeAddress        = common.HexToAddress("0x4bFb41d5B3570DeFd03C39a9A4D8dE6Bd8B8982E")
+	NegRiskCTFExchangeAddress = common.HexToAddress("0xC5d563A36AE78145C45a50134d48A1215220f80a")
+)
+
+// noncesSelector is keccak256("nonces(address)")[:4]
+var noncesSelector = crypto.Keccak256([]byte("nonces(address)"))[:4]
+
+const DefaultNonceRefreshInterval = 30 * time.Second
+
+// RPCClient is the subset of *ethclient.Client used by NonceManager.
+// Keeping it narrow lets tests point the manager at a local JSON-RPC stub.
+type RPCClient interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+type exchangeNonceKey struct {
+	exchange common.Address
+	maker    common.Address
+}
+
 // NonceManager handles both Ethereum Transaction Nonces (for txs) and Exchange Nonces (for orders)
 type NonceManager struct {
-	client *ethclient.Client
-	
+	client RPCClient
+
 	// Transaction Nonces (Optimistic)
-	txNonces   map[common.Address]uint64
-	txMu       sync.RWMutex
+	txNonces map[common.Address]uint64
+	txMu     sync.RWMutex
 
 	// Exchange Nonces (Cached, Read-mostly)
-	// These are the values stored in the CTF Exchange contract: nonces(user)
-	exchangeNonces map[common.Address]*big.Int
+	// These are the values stored in the exchange contracts: nonces(maker)
+	exchanges      []common.Address
+	exchangeNonces map[exchangeNonceKey]*big.Int
 	exchangeMu     sync.RWMutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 func NewNonceManager(rpcURL string) (*NonceManager, error) {
@@ -30,12 +62,66 @@ func NewNonceManager(rpcURL string) (*NonceManager, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to eth client: %w", err)
 	}
+	return NewNonceManagerWithClient(client), nil
+}
 
+// NewNonceManagerWithClient builds a manager on top of an existing RPC client.
+// Exchange nonces are tracked on both the CTF Exchange and the Neg-Risk Exchange.
+func NewNonceManagerWithClient(client RPCClient) *NonceManager {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &NonceManager{
 		client:         client,
 		txNonces:       make(map[common.Address]uint64),
-		exchangeNonces: make(map[common.Address]*big.Int),
-	}, nil
+		exchanges:      []common.Address{CTFExchangeAddress, NegRiskCTFExchangeAddress},
+		exchangeNonces: make(map[exchangeNonceKey]*big.Int),
+		ctx:            ctx,
+		cancel:         cancel,
+	}
+}
+
+// Start launches the periodic exchange nonce refresh in a background goroutine.
+// An on-chain incrementNonce invalidates every resting and future order signed
+// with the old value, so the cache must not drift from the contract for long.
+func (m *NonceManager) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultNonceRefreshInterval
+	}
+	go m.refreshLoop(interval)
+}
+
+// Stop halts the refresh loop.
+func (m *NonceManager) Stop() {
+	m.cancel()
+}
+
+func (m *NonceManager) refreshLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+			m.RefreshAll(m.ctx)
+		}
+	}
+}
+
+// RefreshAll re-reads every cached exchange nonce from chain.
+// Failures keep the previous value so a flaky RPC does not stall order flow.
+func (m *NonceManager) RefreshAll(ctx context.Context) {
+	m.exchangeMu.RLock()
+	keys := make([]exchangeNonceKey, 0, len(m.exchangeNonces))
+	for key := range m.exchangeNonces {
+		keys = append(keys, key)
+	}
+	m.exchangeMu.RUnlock()
+
+	for _, key := range keys {
+		if _, err := m.SyncExchangeNonceFor(ctx, key.exchange, key.maker); err != nil {
+			logger.Warn("Exchange nonce refresh failed", "exchange", key.exchange.Hex(), "maker", key.maker.Hex(), "error", err)
+		}
+	}
 }
 
 // --- Ethereum Transaction Nonce (Optimistic) ---
@@ -62,7 +148,7 @@ func (m *NonceManager) GetNextTxNonce(ctx context.Context, addr common.Address)
 	return fetched, nil
 }
 
-// IncrementTxNonce manually increments the local nonce. 
+// IncrementTxNonce manually increments the local nonce.
 // Call this AFTER successfully signing/broadcasting a transaction.
 func (m *NonceManager) IncrementTxNonce(addr common.Address) {
 	m.txMu.Lock()
@@ -87,83 +173,100 @@ func (m *NonceManager) ResetTxNonce(ctx context.Context, addr common.Address) er
 	return nil
 }
 
-// --- CTF Exchange Nonce (Cached) ---
+// --- Exchange Nonce (Cached) ---
 
-// GetExchangeNonce returns the current valid nonce for Orders.
-// For standard CTF Exchange, Order.Nonce must EQUAL the contract's nonces(maker).
+// GetExchangeNonce returns the current valid CTF Exchange nonce for orders made by addr.
 func (m *NonceManager) GetExchangeNonce(ctx context.Context, addr common.Address) (*big.Int, error) {
+	return m.GetExchangeNonceFor(ctx, CTFExchangeAddress, addr)
+}
+
+// GetExchangeNonceFor returns the cached nonce of maker on the given exchange,
+// fetching it from chain on first use.
+func (m *NonceManager) GetExchangeNonceFor(ctx context.Context, exchange, maker common.Address) (*big.Int, error) {
 	m.exchangeMu.RLock()
-	cached, ok := m.exchangeNonces[addr]
+	cached, ok := m.exchangeNonces[exchangeNonceKey{exchange: exchange, maker: maker}]
 	m.exchangeMu.RUnlock()
 	if ok {
-		return cached, nil
+		return new(big.Int).Set(cached), nil
 	}
 
-	return m.SyncExchangeNonce(ctx, addr)
+	return m.SyncExchangeNonceFor(ctx, exchange, maker)
 }
 
-// SyncExchangeNonce forces a fetch of the Exchange Nonce from the contract.
+// SyncExchangeNonce forces a fetch of addr's nonce from every known exchange
+// and returns the CTF Exchange value.
 func (m *NonceManager) SyncExchangeNonce(ctx context.Context, addr common.Address) (*big.Int, error) {
-	m.exchangeMu.Lock()
-	defer m.exchangeMu.Unlock()
+	var ctfNonce *big.Int
+	var firstErr error
+	for _, exchange := range m.exchanges {
+		val, err := m.SyncExchangeNonceFor(ctx, exchange, addr)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if exchange == CTFExchangeAddress {
+			ctfNonce = val
+		}
+	}
+	if ctfNonce == nil {
+		if firstErr == nil {
+			firstErr = fmt.Errorf("ctf exchange not configured")
+		}
+		return nil, firstErr
+	}
+	return ctfNonce, nil
+}
 
-	// In a real implementation, we would call the contract: Exchange.nonces(addr)
-	// For now, in this MVP, we will simulate or fetch if we had the contract ABI binding.
-	// Since we don't have the generated bindings in this snippet, we will default to 0 
-	// (which is correct for a fresh account) or rely on a "mock" fetch.
-	// TODO: Replace with actual contract call: exchange.Nonces(&bind.CallOpts{}, addr)
-	
-	// For MVP Phase 1/2 without full contract bindings, we assume 0 or 
-	// use a placeholder that the GatewayService might populate via SDK if needed.
-	// But to be "Robust", let's try to use eth_call if possible or just 0.
-	
-	// Assuming 0 for now as most bots start fresh or we rely on SDK to fetch it once.
-	// But wait, the SDK's `GetNonce` typically calls the API or Chain.
-	// Let's implement a basic ETH Call here if we want to be "The Engine".
-	
-	// Exchange Contract: 0x4bFb41d5B3570DeFd03C39a9A4D8dE6Bd8B8982E
-	// Function: nonces(address) -> uint256
-	// Selector: 0x7ecebe00 (keccak256("nonces(address)")[:4])
-	
+// SyncExchangeNonceFor reads nonces(maker) from the exchange contract and caches it.
+func (m *NonceManager) SyncExchangeNonceFor(ctx context.Context, exchange, maker common.Address) (*big.Int, error) {
 	// Construct calldata: selector + address (padded)
-	/*
-	contractAddr := common.HexToAddress("0x4bFb41d5B3570DeFd03C39a9A4D8dE6Bd8B8982E")
-	selector := []byte{0x7e, 0xce, 0xbe, 0x00}
-	addrBytes := common.LeftPadBytes(addr.Bytes(), 32)
-	data := append(selector, addrBytes...)
-	
-	msg := ethereum.CallMsg{
-		To:   &contractAddr,
-		Data: data,
-	}
-	res, err := m.client.CallContract(ctx, msg, nil)
+	data := make([]byte, 0, 4+32)
+	data = append(data, noncesSelector...)
+	data = append(data, common.LeftPadBytes(maker.Bytes(), 32)...)
+
+	res, err := m.client.CallContract(ctx, ethereum.CallMsg{To: &exchange, Data: data}, nil)
 	if err != nil {
-		// Fallback or error
-		log.Printf("[NonceManager] Failed to fetch exchange nonce: %v", err)
-		val := big.NewInt(0)
-		m.exchangeNonces[addr] = val
-		return val, nil
+		metrics.NonceSyncs.WithLabelValues(exchange.Hex(), "error").Inc()
+		return nil, fmt.Errorf("failed to fetch exchange nonce: %w", err)
+	}
+	if len(res) != 32 {
+		metrics.NonceSyncs.WithLabelValues(exchange.Hex(), "error").Inc()
+		return nil, fmt.Errorf("unexpected nonces() result length %d", len(res))
 	}
-	
 	val := new(big.Int).SetBytes(res)
-	*/
-	
-	// Simplified for this step: Return 0 (Default)
-	// The user can implement the actual contract call in Phase 3 or we use SDK to fetch.
-	val := big.NewInt(0)
-	m.exchangeNonces[addr] = val
-	return val, nil
+
+	key := exchangeNonceKey{exchange: exchange, maker: maker}
+	m.exchangeMu.Lock()
+	if prev, ok := m.exchangeNonces[key]; ok && prev.Cmp(val) != 0 {
+		logger.Warn("Exchange nonce changed on chain", "exchange", exchange.Hex(), "maker", maker.Hex(), "cached", prev.String(), "onchain", val.String())
+	}
+	m.exchangeNonces[key] = val
+	m.exchangeMu.Unlock()
+
+	metrics.NonceSyncs.WithLabelValues(exchange.Hex(), "ok").Inc()
+	setNonceGauge(key, val)
+	return new(big.Int).Set(val), nil
 }
 
-// InvalidateExchangeNonce increments the cached exchange nonce.
-// Call this when you send a "Cancel All" transaction.
+// InvalidateExchangeNonce increments the cached CTF Exchange nonce.
+// Call this when you send a "Cancel All" (incrementNonce) transaction.
 func (m *NonceManager) InvalidateExchangeNonce(addr common.Address) {
 	m.exchangeMu.Lock()
 	defer m.exchangeMu.Unlock()
-	
-	if val, ok := m.exchangeNonces[addr]; ok {
+
+	key := exchangeNonceKey{exchange: CTFExchangeAddress, maker: addr}
+	if val, ok := m.exchangeNonces[key]; ok {
 		// Incrementing locally so new orders use the new nonce immediately
 		// even before the CancelAll tx is mined (Optimistic!)
-		m.exchangeNonces[addr] = new(big.Int).Add(val, big.NewInt(1))
+		m.exchangeNonces[key] = new(big.Int).Add(val, big.NewInt(1))
+		setNonceGauge(key, m.exchangeNonces[key])
 	}
 }
+
+// setNonceGauge exports the nonce a maker signs with on an exchange.
+func setNonceGauge(key exchangeNonceKey, val *big.Int) {
+	nonceFloat, _ := new(big.Float).SetInt(val).Float64()
+	metrics.ExchangeNonce.WithLabelValues(key.exchange.Hex(), key.maker.Hex()).Set(nonceFloat)
+}
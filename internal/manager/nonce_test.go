@@ -0,0 +1,172 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// rpcStub is a minimal JSON-RPC server answering eth_call for nonces(address).
+type rpcStub struct {
+	mu     sync.Mutex
+	nonces map[string]int64 // key: lower(exchange)
+	calls  int
+	fail   bool
+}
+
+func newRPCStub(nonces map[common.Address]int64) (*rpcStub, *httptest.Server) {
+	stub := &rpcStub{nonces: make(map[string]int64)}
+	for addr, n := range nonces {
+		stub.nonces[strings.ToLower(addr.Hex())] = n
+	}
+	return stub, httptest.NewServer(stub)
+}
+
+func (s *rpcStub) set(exchange common.Address, nonce int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nonces[strings.ToLower(exchange.Hex())] = nonce
+}
+
+func (s *rpcStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+
+	w.Header().Set("Content-Type", "application/json")
+	if s.fail || req.Method != "eth_call" {
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"error":{"code":-32000,"message":"stub failure"}}`, req.ID)
+		return
+	}
+
+	var call struct {
+		To    string `json:"to"`
+		Input string `json:"input"`
+		Data  string `json:"data"`
+	}
+	_ = json.Unmarshal(req.Params[0], &call)
+	input := call.Input
+	if input == "" {
+		input = call.Data
+	}
+	if !strings.HasPrefix(input, hexutil.Encode(noncesSelector)) {
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"error":{"code":-32000,"message":"unexpected selector"}}`, req.ID)
+		return
+	}
+
+	nonce := s.nonces[strings.ToLower(call.To)]
+	result := hexutil.Encode(common.LeftPadBytes(big.NewInt(nonce).Bytes(), 32))
+	fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":"%s"}`, req.ID, result)
+}
+
+func TestGetExchangeNonceReadsContract(t *testing.T) {
+	stub, srv := newRPCStub(map[common.Address]int64{
+		CTFExchangeAddress:        7,
+		NegRiskCTFExchangeAddress: 3,
+	})
+	defer srv.Close()
+
+	m, err := NewNonceManager(srv.URL)
+	if err != nil {
+		t.Fatalf("failed to create nonce manager: %v", err)
+	}
+	maker := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	nonce, err := m.GetExchangeNonce(context.Background(), maker)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if nonce.Int64() != 7 {
+		t.Fatalf("expected ctf nonce 7, got %s", nonce)
+	}
+
+	negRisk, err := m.GetExchangeNonceFor(context.Background(), NegRiskCTFExchangeAddress, maker)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if negRisk.Int64() != 3 {
+		t.Fatalf("expected neg risk nonce 3, got %s", negRisk)
+	}
+
+	callsBefore := stub.calls
+	if _, err := m.GetExchangeNonce(context.Background(), maker); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stub.calls != callsBefore {
+		t.Fatalf("expected cached nonce to skip rpc call")
+	}
+}
+
+func TestRefreshAllPicksUpOnChainIncrement(t *testing.T) {
+	stub, srv := newRPCStub(map[common.Address]int64{CTFExchangeAddress: 0})
+	defer srv.Close()
+
+	m, err := NewNonceManager(srv.URL)
+	if err != nil {
+		t.Fatalf("failed to create nonce manager: %v", err)
+	}
+	maker := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+
+	if _, err := m.GetExchangeNonce(context.Background(), maker); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stub.set(CTFExchangeAddress, 1)
+	m.RefreshAll(context.Background())
+
+	nonce, err := m.GetExchangeNonce(context.Background(), maker)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if nonce.Int64() != 1 {
+		t.Fatalf("expected refreshed nonce 1, got %s", nonce)
+	}
+}
+
+func TestSyncExchangeNonceErrorDoesNotCacheZero(t *testing.T) {
+	stub, srv := newRPCStub(nil)
+	defer srv.Close()
+	stub.fail = true
+
+	m, err := NewNonceManager(srv.URL)
+	if err != nil {
+		t.Fatalf("failed to create nonce manager: %v", err)
+	}
+	maker := common.HexToAddress("0x00000000000000000000000000000000000000cc")
+
+	if _, err := m.GetExchangeNonce(context.Background(), maker); err == nil {
+		t.Fatalf("expected rpc failure to surface")
+	}
+
+	stub.mu.Lock()
+	stub.fail = false
+	stub.mu.Unlock()
+	stub.set(CTFExchangeAddress, 5)
+
+	nonce, err := m.GetExchangeNonce(context.Background(), maker)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if nonce.Int64() != 5 {
+		t.Fatalf("expected nonce 5 after recovery, got %s", nonce)
+	}
+}
@@ -0,0 +1,280 @@
+package market
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/pkg/logger"
+)
+
+const (
+	DefaultFillsPerTenant  = 1000
+	DefaultOrdersPerTenant = 1000
+	fillRepoTimeout        = 2 * time.Second
+	// fillWriteQueue bounds the fills / order updates waiting to be persisted
+	fillWriteQueue = 4096
+)
+
+// FillRepo persists fills and order updates (Postgres / Redis).
+type FillRepo interface {
+	SaveFill(ctx context.Context, fill *model.Fill) error
+	ListFills(ctx context.Context, tenantID string, filter model.FillFilter) ([]*model.Fill, error)
+	SaveOrderUpdate(ctx context.Context, update *model.OrderUpdate) error
+	GetOrderUpdates(ctx context.Context, tenantID string, orderIDs []string) (map[string]*model.OrderUpdate, error)
+}
+
+// FillListener is notified of every fill and order update the store records.
+type FillListener interface {
+	OnFill(fill *model.Fill)
+	OnOrderUpdate(update *model.OrderUpdate)
+}
+
+// FillStore keeps the most recent fills and order states per tenant in memory,
+// writing through to an optional FillRepo. Writes are queued and persisted by a
+// background writer, so a slow database never stalls the user channel reader.
+type FillStore struct {
+	mu        sync.RWMutex
+	maxFills  int
+	maxOrders int
+	fills     map[string]*tenantFills // Key: TenantID
+	orders    map[string]*tenantOrders
+	repo      FillRepo
+	listeners []FillListener
+
+	writes  chan fillWrite
+	written chan struct{} // closed once the writer drained the queue
+	closed  bool
+}
+
+// fillWrite is one queued repo write: a fill or an order update.
+type fillWrite struct {
+	fill   *model.Fill
+	update *model.OrderUpdate
+}
+
+type tenantFills struct {
+	byID  map[string]*model.Fill
+	order []string // insertion order, oldest first
+}
+
+type tenantOrders struct {
+	byID  map[string]*model.OrderUpdate
+	order []string
+}
+
+func NewFillStore(maxPerTenant int, repo FillRepo) *FillStore {
+	if maxPerTenant <= 0 {
+		maxPerTenant = DefaultFillsPerTenant
+	}
+	s := &FillStore{
+		maxFills:  maxPerTenant,
+		maxOrders: DefaultOrdersPerTenant,
+		fills:     make(map[string]*tenantFills),
+		orders:    make(map[string]*tenantOrders),
+		repo:      repo,
+	}
+	if repo != nil {
+		s.writes = make(chan fillWrite, fillWriteQueue)
+		s.written = make(chan struct{})
+		go s.processWrites()
+	}
+	return s
+}
+
+// Close stops accepting writes and waits for the queued ones to be persisted.
+func (s *FillStore) Close() {
+	s.mu.Lock()
+	if s.writes == nil || s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	close(s.writes)
+	s.mu.Unlock()
+	<-s.written
+}
+
+// enqueue hands a write to the background writer without blocking; when the
+// queue is full the write is dropped (the record stays in memory, and a later
+// status change of the same fill / order persists it again).
+func (s *FillStore) enqueue(w fillWrite) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.writes == nil || s.closed {
+		return
+	}
+	select {
+	case s.writes <- w:
+	default:
+		if w.fill != nil {
+			logger.Error("Fill write queue full, dropping fill", "tenant_id", w.fill.TenantID, "fill_id", w.fill.ID)
+		} else {
+			logger.Error("Fill write queue full, dropping order update", "tenant_id", w.update.TenantID, "order_id", w.update.OrderID)
+		}
+	}
+}
+
+// processWrites persists queued writes in arrival order, so a fill's later
+// status never lands before an earlier one.
+func (s *FillStore) processWrites() {
+	defer close(s.written)
+	for w := range s.writes {
+		ctx, cancel := context.WithTimeout(context.Background(), fillRepoTimeout)
+		if w.fill != nil {
+			if err := s.repo.SaveFill(ctx, w.fill); err != nil {
+				logger.Error("Failed to persist fill", "tenant_id", w.fill.TenantID, "fill_id", w.fill.ID, "error", err)
+			}
+		} else if err := s.repo.SaveOrderUpdate(ctx, w.update); err != nil {
+			logger.Error("Failed to persist order update", "tenant_id", w.update.TenantID, "order_id", w.update.OrderID, "error", err)
+		}
+		cancel()
+	}
+}
+
+// AddListener registers l for all subsequent fills and order updates.
+func (s *FillStore) AddListener(l FillListener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, l)
+}
+
+// AddFill inserts or updates (by fill ID) a fill for its tenant.
+func (s *FillStore) AddFill(fill *model.Fill) {
+	if fill == nil || fill.ID == "" {
+		return
+	}
+	s.mu.Lock()
+	tf, ok := s.fills[fill.TenantID]
+	if !ok {
+		tf = &tenantFills{byID: make(map[string]*model.Fill)}
+		s.fills[fill.TenantID] = tf
+	}
+	if existing, ok := tf.byID[fill.ID]; ok {
+		// Status progression keeps the original match time
+		fill.Timestamp = existing.Timestamp
+	} else {
+		tf.order = append(tf.order, fill.ID)
+		if len(tf.order) > s.maxFills {
+			evict := tf.order[0]
+			tf.order = tf.order[1:]
+			delete(tf.byID, evict)
+		}
+	}
+	tf.byID[fill.ID] = fill
+	listeners := s.listeners
+	s.mu.Unlock()
+
+	for _, l := range listeners {
+		l.OnFill(fill)
+	}
+	s.enqueue(fillWrite{fill: fill})
+}
+
+// AddOrderUpdate records the latest state of an order.
+func (s *FillStore) AddOrderUpdate(update *model.OrderUpdate) {
+	if update == nil || update.OrderID == "" {
+		return
+	}
+	s.mu.Lock()
+	to, ok := s.orders[update.TenantID]
+	if !ok {
+		to = &tenantOrders{byID: make(map[string]*model.OrderUpdate)}
+		s.orders[update.TenantID] = to
+	}
+	if _, ok := to.byID[update.OrderID]; !ok {
+		to.order = append(to.order, update.OrderID)
+		if len(to.order) > s.maxOrders {
+			evict := to.order[0]
+			to.order = to.order[1:]
+			delete(to.byID, evict)
+		}
+	}
+	to.byID[update.OrderID] = update
+	listeners := s.listeners
+	s.mu.Unlock()
+
+	for _, l := range listeners {
+		l.OnOrderUpdate(update)
+	}
+	s.enqueue(fillWrite{update: update})
+}
+
+// ListFills returns the tenant's fills matching filter, newest first.
+// Persistent storage is preferred; memory is the fallback.
+func (s *FillStore) ListFills(ctx context.Context, tenantID string, filter model.FillFilter) []*model.Fill {
+	if s.repo != nil {
+		records, err := s.repo.ListFills(ctx, tenantID, filter)
+		if err == nil {
+			return records
+		}
+		logger.Warn("Failed to list fills from repo, using memory", "error", err)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tf, ok := s.fills[tenantID]
+	if !ok {
+		return []*model.Fill{}
+	}
+	results := make([]*model.Fill, 0, len(tf.order))
+	for _, id := range tf.order {
+		fill := tf.byID[id]
+		if filter.Match(fill) {
+			copied := *fill
+			results = append(results, &copied)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Timestamp.After(results[j].Timestamp)
+	})
+	if filter.Limit > 0 && len(results) > filter.Limit {
+		results = results[:filter.Limit]
+	}
+	return results
+}
+
+// GetOrderUpdate returns the last known state of an order.
+func (s *FillStore) GetOrderUpdate(tenantID, orderID string) (*model.OrderUpdate, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	to, ok := s.orders[tenantID]
+	if !ok {
+		return nil, false
+	}
+	update, ok := to.byID[orderID]
+	if !ok {
+		return nil, false
+	}
+	copied := *update
+	return &copied, true
+}
+
+// LookupOrderUpdates returns the last known state of each requested order that
+// the store knows about. Memory is checked first; misses fall back to the repo,
+// so state recorded before a restart is still found.
+func (s *FillStore) LookupOrderUpdates(ctx context.Context, tenantID string, orderIDs []string) map[string]*model.OrderUpdate {
+	found := make(map[string]*model.OrderUpdate, len(orderIDs))
+	missing := make([]string, 0)
+	for _, id := range orderIDs {
+		if update, ok := s.GetOrderUpdate(tenantID, id); ok {
+			found[id] = update
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	if s.repo == nil || len(missing) == 0 {
+		return found
+	}
+	stored, err := s.repo.GetOrderUpdates(ctx, tenantID, missing)
+	if err != nil {
+		logger.Warn("Failed to load order updates from repo", "tenant_id", tenantID, "error", err)
+		return found
+	}
+	for id, update := range stored {
+		found[id] = update
+	}
+	return found
+}
@@ -0,0 +1,107 @@
+package market
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+)
+
+type stubFillRepo struct {
+	updates map[string]*model.OrderUpdate
+	asked   []string
+}
+
+func (r *stubFillRepo) SaveFill(ctx context.Context, fill *model.Fill) error { return nil }
+
+func (r *stubFillRepo) ListFills(ctx context.Context, tenantID string, filter model.FillFilter) ([]*model.Fill, error) {
+	return nil, nil
+}
+
+func (r *stubFillRepo) SaveOrderUpdate(ctx context.Context, update *model.OrderUpdate) error {
+	return nil
+}
+
+func (r *stubFillRepo) GetOrderUpdates(ctx context.Context, tenantID string, orderIDs []string) (map[string]*model.OrderUpdate, error) {
+	r.asked = append(r.asked, orderIDs...)
+	out := make(map[string]*model.OrderUpdate)
+	for _, id := range orderIDs {
+		if u, ok := r.updates[id]; ok && u.TenantID == tenantID {
+			out[id] = u
+		}
+	}
+	return out, nil
+}
+
+func TestLookupOrderUpdatesFallsBackToRepo(t *testing.T) {
+	repo := &stubFillRepo{updates: map[string]*model.OrderUpdate{
+		"o-old": {OrderID: "o-old", TenantID: "t1", Side: "SELL", Type: "PLACEMENT"},
+	}}
+	store := NewFillStore(0, repo)
+	store.AddOrderUpdate(&model.OrderUpdate{OrderID: "o-new", TenantID: "t1", Side: "BUY", Type: "UPDATE"})
+
+	found := store.LookupOrderUpdates(context.Background(), "t1", []string{"o-new", "o-old", "o-missing"})
+	if len(found) != 2 {
+		t.Fatalf("expected 2 orders, got %d", len(found))
+	}
+	if found["o-new"].Side != "BUY" || found["o-old"].Side != "SELL" {
+		t.Fatalf("unexpected lookup result: %+v", found)
+	}
+	if len(repo.asked) != 2 || repo.asked[0] != "o-old" || repo.asked[1] != "o-missing" {
+		t.Fatalf("repo should only be asked for memory misses, got %v", repo.asked)
+	}
+
+	if other := store.LookupOrderUpdates(context.Background(), "t2", []string{"o-new"}); len(other) != 0 {
+		t.Fatalf("orders must not leak across tenants, got %+v", other)
+	}
+}
+
+// slowFillRepo holds every save until release is closed.
+type slowFillRepo struct {
+	stubFillRepo
+	release chan struct{}
+	mu      sync.Mutex
+	saved   []string
+}
+
+func (r *slowFillRepo) SaveFill(ctx context.Context, fill *model.Fill) error {
+	<-r.release
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.saved = append(r.saved, fill.ID+"/"+fill.Status)
+	return nil
+}
+
+func (r *slowFillRepo) SaveOrderUpdate(ctx context.Context, update *model.OrderUpdate) error {
+	<-r.release
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.saved = append(r.saved, update.OrderID+"/"+update.Type)
+	return nil
+}
+
+func TestFillStorePersistsInTheBackground(t *testing.T) {
+	repo := &slowFillRepo{release: make(chan struct{})}
+	store := NewFillStore(0, repo)
+
+	start := time.Now()
+	store.AddFill(&model.Fill{ID: "f1", TenantID: "t1", Status: "MATCHED"})
+	store.AddOrderUpdate(&model.OrderUpdate{OrderID: "o1", TenantID: "t1", Type: "UPDATE"})
+	store.AddFill(&model.Fill{ID: "f1", TenantID: "t1", Status: "CONFIRMED"})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("a slow repo must not block the caller, took %s", elapsed)
+	}
+	if update, ok := store.GetOrderUpdate("t1", "o1"); !ok || update.Type != "UPDATE" {
+		t.Fatalf("the update must be in memory right away")
+	}
+
+	close(repo.release)
+	store.Close()
+	if len(repo.saved) != 3 || repo.saved[0] != "f1/MATCHED" || repo.saved[1] != "o1/UPDATE" || repo.saved[2] != "f1/CONFIRMED" {
+		t.Fatalf("expected every write persisted in order on close, got %v", repo.saved)
+	}
+	store.AddFill(&model.Fill{ID: "f2", TenantID: "t1"})
+	store.Close()
+}
@@ -0,0 +1,237 @@
+package market
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/pkg/logger"
+	"github.com/GoPolymarket/polygate/internal/pkg/metrics"
+	"github.com/GoPolymarket/polymarket-go-sdk/pkg/clob/clobtypes"
+)
+
+const (
+	DefaultResyncInterval = 30 * time.Second
+	resyncTimeout         = 5 * time.Second
+
+	// A book whose levels disagree with REST under a *different* hash may just be
+	// a few deltas behind; only call it diverged if it stays that way.
+	maxHashLagChecks = 2
+	// snapshotAttempts bounds the fetches made for a resync when the stream keeps
+	// moving the book while a snapshot is in flight
+	snapshotAttempts = 3
+
+	reasonHashMismatch = "hash_mismatch"
+	reasonLevelDrift   = "level_drift"
+	reasonDisconnected = "stream disconnected"
+)
+
+// BookSource fetches authoritative book snapshots (clob.Client satisfies it).
+type BookSource interface {
+	OrderBook(ctx context.Context, req *clobtypes.BookRequest) (clobtypes.OrderBookResponse, error)
+}
+
+// SetBookSource enables periodic REST verification. Call before Start.
+func (s *MarketService) SetBookSource(src BookSource, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultResyncInterval
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.source = src
+	s.resyncInterval = interval
+}
+
+func (s *MarketService) verifyLoop() {
+	ticker := time.NewTicker(s.resyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.VerifyAll(s.ctx)
+		}
+	}
+}
+
+// VerifyAll checks every subscribed book against a REST snapshot.
+func (s *MarketService) VerifyAll(ctx context.Context) {
+	s.mu.RLock()
+	tokenIDs := make([]string, len(s.subs))
+	copy(tokenIDs, s.subs)
+	s.mu.RUnlock()
+
+	for _, id := range tokenIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.VerifyBook(ctx, id); err != nil {
+			logger.Warn("Orderbook verification failed", "token_id", id, "error", err)
+		}
+	}
+}
+
+// VerifyBook compares the shadow book with upstream and resyncs it when they diverge.
+// Invalid books are always resynced, except while the stream is down: a REST
+// snapshot cannot make a book that receives no deltas trustworthy.
+func (s *MarketService) VerifyBook(ctx context.Context, tokenID string) error {
+	book := s.lookupBook(tokenID)
+	if book == nil {
+		return fmt.Errorf("book %s not subscribed", tokenID)
+	}
+	s.mu.RLock()
+	src := s.source
+	s.mu.RUnlock()
+	if src == nil {
+		return fmt.Errorf("no book source configured")
+	}
+	if book.InvalidReason() == reasonDisconnected {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resyncTimeout)
+	defer cancel()
+	for attempt := 0; attempt < snapshotAttempts; attempt++ {
+		fetched := time.Now()
+		bids, asks, hash, err := fetchSnapshot(ctx, src, tokenID)
+		if err != nil {
+			metrics.BookResyncs.WithLabelValues("error").Inc()
+			return err
+		}
+
+		if attempt == 0 && book.IsValid() {
+			reason := book.compare(bids, asks, hash)
+			if reason == "" {
+				return nil
+			}
+			metrics.BookDivergences.WithLabelValues(reason).Inc()
+			book.Invalidate(reason)
+			logger.Warn("Orderbook diverged from upstream", "token_id", tokenID, "reason", reason, "local_hash", book.Meta().Hash, "upstream_hash", hash)
+		}
+
+		if book.applySnapshot(bids, asks, hash, fetched) {
+			metrics.BookResyncs.WithLabelValues("ok").Inc()
+			logger.Info("Orderbook resynced from REST", "token_id", tokenID, "hash", hash)
+			return nil
+		}
+		if book.InvalidReason() == reasonDisconnected {
+			// The stream dropped while the snapshot was in flight
+			return nil
+		}
+		metrics.BookResyncs.WithLabelValues("stale").Inc()
+		logger.Debug("Discarded a REST snapshot older than the streamed book", "token_id", tokenID, "hash", hash)
+	}
+	return fmt.Errorf("book %s kept moving, no fresh snapshot after %d attempts", tokenID, snapshotAttempts)
+}
+
+func fetchSnapshot(ctx context.Context, src BookSource, tokenID string) (bids, asks []Level, hash string, err error) {
+	resp, err := src.OrderBook(ctx, &clobtypes.BookRequest{TokenID: tokenID})
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if bids, asks, err = LevelsFromBook(resp); err != nil {
+		return nil, nil, "", err
+	}
+	return bids, asks, resp.Hash, nil
+}
+
+// applySnapshot replaces the book with a REST snapshot requested at fetched. A
+// snapshot is stale, and discarded, when the stream moved the book after the
+// request went out under a different hash: installing it could roll back deltas
+// that are already applied. A book whose stream is down is left invalid. It
+// reports whether the snapshot was applied.
+func (ob *Orderbook) applySnapshot(bids, asks []Level, hash string, fetched time.Time) bool {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	if ob.invalidReason == reasonDisconnected {
+		return false
+	}
+	if ob.LastUpdated.After(fetched) && (hash == "" || hash != ob.Hash) {
+		return false
+	}
+	ob.Bids = bids
+	ob.Asks = asks
+	ob.LastUpdated = time.Now()
+	if hash != "" {
+		ob.Hash = hash
+	}
+	ob.invalidReason = ""
+	ob.mismatches = 0
+	return true
+}
+
+// compare returns a divergence reason, or "" if the book can still be trusted.
+func (ob *Orderbook) compare(bids, asks []Level, upstreamHash string) string {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	if levelsEqual(ob.Bids, bids) && levelsEqual(ob.Asks, asks) {
+		ob.mismatches = 0
+		return ""
+	}
+	// Same upstream state, different contents: a delta was lost or misapplied.
+	if upstreamHash != "" && upstreamHash == ob.Hash {
+		return reasonHashMismatch
+	}
+	ob.mismatches++
+	if ob.mismatches >= maxHashLagChecks {
+		return reasonLevelDrift
+	}
+	return ""
+}
+
+func levelsEqual(a, b []Level) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Price.Equal(b[i].Price) || !a[i].Size.Equal(b[i].Size) {
+			return false
+		}
+	}
+	return true
+}
+
+// LevelsFromBook parses a REST book into levels sorted best first.
+func LevelsFromBook(resp clobtypes.OrderBookResponse) (bids, asks []Level, err error) {
+	if bids, err = parseLevels(toRawLevels(resp.Bids), true); err != nil {
+		return nil, nil, err
+	}
+	if asks, err = parseLevels(toRawLevels(resp.Asks), false); err != nil {
+		return nil, nil, err
+	}
+	return bids, asks, nil
+}
+
+func toRawLevels(levels []clobtypes.PriceLevel) []PriceLevelRaw {
+	raw := make([]PriceLevelRaw, len(levels))
+	for i, l := range levels {
+		raw[i] = PriceLevelRaw{Price: l.Price, Size: l.Size}
+	}
+	return raw
+}
+
+// streamResumed marks books cut off by a disconnect as awaiting a snapshot once
+// the stream is resubscribed: deltas flow again, so a snapshot (streamed or REST)
+// can make them trustworthy.
+func (s *MarketService) streamResumed() {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, book := range s.books {
+		book.mu.Lock()
+		if book.invalidReason == reasonDisconnected {
+			book.invalidReason = reasonAwaitingSnapshot
+		}
+		book.mu.Unlock()
+	}
+}
+
+// invalidateAll is used when the stream drops: deltas may have been missed.
+func (s *MarketService) invalidateAll(reason string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, book := range s.books {
+		book.Invalidate(reason)
+	}
+}
@@ -0,0 +1,144 @@
+package market
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/pkg/logger"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	CLOBURL              = "https://clob.polymarket.com"
+	DefaultMarketInfoTTL = 10 * time.Minute
+	marketInfoTimeout    = 3 * time.Second
+)
+
+var ErrMarketInfoUnavailable = errors.New("market info unavailable")
+
+// MarketInfo holds the trading parameters of one token.
+type MarketInfo struct {
+	TokenID   string          `json:"token_id"`
+	Market    string          `json:"market,omitempty"` // condition id
+	TickSize  decimal.Decimal `json:"tick_size"`
+	MinSize   decimal.Decimal `json:"min_order_size"`
+	NegRisk   bool            `json:"neg_risk"` // orders settle on (and are signed for) the Neg-Risk Exchange
+	FetchedAt time.Time       `json:"fetched_at"`
+}
+
+// MarketInfoSource fetches a token's trading parameters from upstream.
+type MarketInfoSource interface {
+	MarketInfo(ctx context.Context, tokenID string) (MarketInfo, error)
+}
+
+// CLOBMarketInfoSource reads tick size and minimum order size from the CLOB
+// book endpoint, which reports both per token.
+type CLOBMarketInfoSource struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewCLOBMarketInfoSource(baseURL string, client *http.Client) *CLOBMarketInfoSource {
+	if baseURL == "" {
+		baseURL = CLOBURL
+	}
+	if client == nil {
+		client = &http.Client{Timeout: marketInfoTimeout}
+	}
+	return &CLOBMarketInfoSource{baseURL: strings.TrimRight(baseURL, "/"), client: client}
+}
+
+func (c *CLOBMarketInfoSource) MarketInfo(ctx context.Context, tokenID string) (MarketInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/book?token_id="+url.QueryEscape(tokenID), nil)
+	if err != nil {
+		return MarketInfo{}, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return MarketInfo{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return MarketInfo{}, fmt.Errorf("clob book %s: status %d", tokenID, resp.StatusCode)
+	}
+
+	var body struct {
+		Market       string `json:"market"`
+		TickSize     string `json:"tick_size"`
+		MinOrderSize string `json:"min_order_size"`
+		NegRisk      bool   `json:"neg_risk"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return MarketInfo{}, fmt.Errorf("decode clob book %s: %w", tokenID, err)
+	}
+	tick, err := decimal.NewFromString(body.TickSize)
+	if err != nil || !tick.IsPositive() {
+		return MarketInfo{}, fmt.Errorf("clob book %s: invalid tick size %q", tokenID, body.TickSize)
+	}
+	minSize, _ := decimal.NewFromString(body.MinOrderSize)
+	return MarketInfo{
+		TokenID:   tokenID,
+		Market:    body.Market,
+		TickSize:  tick,
+		MinSize:   minSize,
+		NegRisk:   body.NegRisk,
+		FetchedAt: time.Now(),
+	}, nil
+}
+
+// SetMarketInfoSource enables tick size / min size lookups, cached for ttl.
+func (s *MarketService) SetMarketInfoSource(src MarketInfoSource, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultMarketInfoTTL
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.infoSource = src
+	s.infoTTL = ttl
+}
+
+// MarketInfo returns the token's trading parameters, refreshing the cache when stale.
+// A stale entry is still served if the refresh fails.
+func (s *MarketService) MarketInfo(ctx context.Context, tokenID string) (MarketInfo, error) {
+	s.mu.RLock()
+	info, ok := s.info[tokenID]
+	src, ttl := s.infoSource, s.infoTTL
+	s.mu.RUnlock()
+
+	if src != nil && (!ok || time.Since(info.FetchedAt) > ttl) {
+		fetchCtx, cancel := context.WithTimeout(ctx, marketInfoTimeout)
+		fetched, err := src.MarketInfo(fetchCtx, tokenID)
+		cancel()
+		switch {
+		case err == nil:
+			info, ok = fetched, true
+			s.mu.Lock()
+			s.info[tokenID] = info
+			s.mu.Unlock()
+		case ok:
+			logger.Warn("Market info refresh failed, using cached value", "token_id", tokenID, "error", err)
+		default:
+			return MarketInfo{}, fmt.Errorf("%w: %v", ErrMarketInfoUnavailable, err)
+		}
+	}
+	if !ok {
+		return MarketInfo{}, ErrMarketInfoUnavailable
+	}
+	return info, nil
+}
+
+// updateTickSize applies a tick_size_change event to the cached market info.
+func (s *MarketService) updateTickSize(tokenID string, tick decimal.Decimal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if info, ok := s.info[tokenID]; ok {
+		info.TickSize = tick
+		s.info[tokenID] = info
+	}
+}
@@ -0,0 +1,344 @@
+package market
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ErrDuplicateClientOrderID is returned when a client_order_id is reused while
+// the order that holds it is still active.
+var ErrDuplicateClientOrderID = errors.New("client_order_id is already used by an active order")
+
+// OrderTrackerRepo persists tracked orders. Lookups return (nil, nil) when the
+// order is unknown.
+type OrderTrackerRepo interface {
+	SaveTrackedOrder(ctx context.Context, order *model.TrackedOrder) error
+	GetTrackedOrderByClientID(ctx context.Context, tenantID, clientOrderID string) (*model.TrackedOrder, error)
+	GetTrackedOrderByOrderID(ctx context.Context, tenantID, orderID string) (*model.TrackedOrder, error)
+}
+
+// OrderTracker runs the local order state machine
+// (NEW → PENDING_SUBMIT → LIVE → PARTIALLY_FILLED → FILLED/CANCELLED/REJECTED/EXPIRED).
+// It is driven by the gateway's submit path and, as a FillListener, by the user
+// channel. Orders are indexed by tracking ID, client order ID and exchange order ID;
+// memory holds the most recent orders per tenant and misses fall back to the repo,
+// so lookups survive a restart.
+type OrderTracker struct {
+	mu        sync.Mutex
+	maxOrders int
+	tenants   map[string]*trackedOrders // Key: TenantID
+	repo      OrderTrackerRepo
+	now       func() time.Time
+}
+
+type trackedOrders struct {
+	byID     map[string]*model.TrackedOrder
+	byClient map[string]string // client order id -> tracking id (latest order using it)
+	byOrder  map[string]string // exchange order id -> tracking id
+	order    []string          // insertion order, oldest first
+}
+
+func NewOrderTracker(repo OrderTrackerRepo) *OrderTracker {
+	return &OrderTracker{
+		maxOrders: DefaultOrdersPerTenant,
+		tenants:   make(map[string]*trackedOrders),
+		repo:      repo,
+		now:       func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Track registers an order about to be submitted, in state NEW.
+func (t *OrderTracker) Track(ctx context.Context, tenantID string, req model.OrderRequest) (*model.TrackedOrder, error) {
+	cid := strings.TrimSpace(req.ClientOrderID)
+	if cid != "" {
+		// Warm memory from the repo so the duplicate check below sees orders from before a restart
+		t.ByClientID(ctx, tenantID, cid)
+	}
+
+	now := t.now()
+	order := &model.TrackedOrder{
+		ID:            uuid.NewString(),
+		TenantID:      tenantID,
+		ClientOrderID: cid,
+		TokenID:       req.TokenID,
+		Side:          strings.ToUpper(req.Side),
+		Price:         req.Price,
+		Size:          req.Size,
+		Expiration:    req.Expiration,
+		State:         model.OrderStateNew,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	t.mu.Lock()
+	to := t.tenant(tenantID)
+	if cid != "" {
+		if prev, ok := to.byID[to.byClient[cid]]; ok && !prev.State.Terminal() {
+			t.mu.Unlock()
+			return nil, ErrDuplicateClientOrderID
+		}
+	}
+	t.store(to, order)
+	copied := *order
+	t.mu.Unlock()
+
+	t.persist(&copied)
+	return &copied, nil
+}
+
+// Submitting marks an order as sent to the exchange.
+func (t *OrderTracker) Submitting(tenantID, id string) {
+	t.transition(tenantID, id, func(o *model.TrackedOrder) bool {
+		return setState(o, model.OrderStatePendingSubmit)
+	})
+}
+
+// Accepted records the exchange order ID and marks the order LIVE.
+func (t *OrderTracker) Accepted(tenantID, id, orderID string) {
+	t.mu.Lock()
+	to := t.tenant(tenantID)
+	if _, ok := to.byID[id]; ok && orderID != "" {
+		to.byOrder[orderID] = id
+	}
+	t.mu.Unlock()
+
+	t.transition(tenantID, id, func(o *model.TrackedOrder) bool {
+		changed := o.OrderID != orderID
+		o.OrderID = orderID
+		return setState(o, model.OrderStateLive) || changed
+	})
+}
+
+// Rejected marks an order that never reached the book.
+func (t *OrderTracker) Rejected(tenantID, id, reason string) {
+	t.transition(tenantID, id, func(o *model.TrackedOrder) bool {
+		if !setState(o, model.OrderStateRejected) {
+			return false
+		}
+		o.Reason = reason
+		return true
+	})
+}
+
+// Cancelled marks the order with exchange ID orderID as cancelled once the
+// exchange acknowledged the cancel (the user channel confirms it later).
+func (t *OrderTracker) Cancelled(ctx context.Context, tenantID, orderID string) {
+	order, ok := t.ByOrderID(ctx, tenantID, orderID)
+	if !ok {
+		return
+	}
+	t.transition(tenantID, order.ID, func(o *model.TrackedOrder) bool {
+		return setState(o, t.cancelState(o))
+	})
+}
+
+// ByClientID returns the latest order submitted with clientOrderID.
+func (t *OrderTracker) ByClientID(ctx context.Context, tenantID, clientOrderID string) (*model.TrackedOrder, bool) {
+	t.mu.Lock()
+	to := t.tenant(tenantID)
+	if order, ok := to.byID[to.byClient[clientOrderID]]; ok {
+		copied := *order
+		t.mu.Unlock()
+		return &copied, true
+	}
+	t.mu.Unlock()
+
+	return t.load(ctx, tenantID, func(ctx context.Context) (*model.TrackedOrder, error) {
+		return t.repo.GetTrackedOrderByClientID(ctx, tenantID, clientOrderID)
+	})
+}
+
+// ByOrderID returns the tracked order with exchange order ID orderID.
+func (t *OrderTracker) ByOrderID(ctx context.Context, tenantID, orderID string) (*model.TrackedOrder, bool) {
+	t.mu.Lock()
+	to := t.tenant(tenantID)
+	if order, ok := to.byID[to.byOrder[orderID]]; ok {
+		copied := *order
+		t.mu.Unlock()
+		return &copied, true
+	}
+	t.mu.Unlock()
+
+	return t.load(ctx, tenantID, func(ctx context.Context) (*model.TrackedOrder, error) {
+		return t.repo.GetTrackedOrderByOrderID(ctx, tenantID, orderID)
+	})
+}
+
+// OnFill is a no-op: order events on the user channel carry the matched size,
+// so the state machine is driven by OnOrderUpdate alone.
+func (t *OrderTracker) OnFill(fill *model.Fill) {}
+
+// OnOrderUpdate advances a tracked order from a user channel order event.
+// Orders not placed through the gateway are ignored.
+func (t *OrderTracker) OnOrderUpdate(update *model.OrderUpdate) {
+	if update == nil || update.OrderID == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), fillRepoTimeout)
+	defer cancel()
+	order, ok := t.ByOrderID(ctx, update.TenantID, update.OrderID)
+	if !ok {
+		return
+	}
+
+	t.transition(update.TenantID, order.ID, func(o *model.TrackedOrder) bool {
+		matched, err := decimal.NewFromString(update.SizeMatched)
+		if err != nil {
+			matched = o.SizeMatched
+		}
+		size, err := decimal.NewFromString(update.OriginalSize)
+		if err != nil || !size.IsPositive() {
+			size = o.Size
+		}
+
+		var next model.OrderState
+		switch {
+		case strings.EqualFold(update.Type, OrderEventCancellation):
+			next = t.cancelState(o)
+		case matched.IsPositive() && matched.GreaterThanOrEqual(size):
+			next = model.OrderStateFilled
+		case matched.IsPositive():
+			next = model.OrderStatePartiallyFilled
+		default:
+			next = model.OrderStateLive
+		}
+
+		changed := false
+		if !o.State.Terminal() && !matched.Equal(o.SizeMatched) {
+			o.SizeMatched = matched
+			changed = true
+		}
+		return setState(o, next) || changed
+	})
+}
+
+// cancelState distinguishes a GTD order cancelled at its expiry from a cancel.
+func (t *OrderTracker) cancelState(o *model.TrackedOrder) model.OrderState {
+	if o.Expiration > 0 && t.now().Unix() >= o.Expiration {
+		return model.OrderStateExpired
+	}
+	return model.OrderStateCancelled
+}
+
+func setState(o *model.TrackedOrder, next model.OrderState) bool {
+	if !o.State.CanTransition(next) {
+		return false
+	}
+	o.State = next
+	return true
+}
+
+// transition applies fn to a copy of the order and stores it when fn reports a change.
+func (t *OrderTracker) transition(tenantID, id string, fn func(o *model.TrackedOrder) bool) {
+	t.mu.Lock()
+	to := t.tenant(tenantID)
+	current, ok := to.byID[id]
+	if !ok {
+		t.mu.Unlock()
+		return
+	}
+	next := *current
+	if !fn(&next) {
+		t.mu.Unlock()
+		return
+	}
+	next.UpdatedAt = t.now()
+	to.byID[id] = &next
+	copied := next
+	t.mu.Unlock()
+
+	t.persist(&copied)
+}
+
+// load fetches an order from the repo and caches it in memory.
+func (t *OrderTracker) load(ctx context.Context, tenantID string, get func(ctx context.Context) (*model.TrackedOrder, error)) (*model.TrackedOrder, bool) {
+	if t.repo == nil {
+		return nil, false
+	}
+	order, err := get(ctx)
+	if err != nil {
+		logger.Warn("Failed to load tracked order from repo", "tenant_id", tenantID, "error", err)
+		return nil, false
+	}
+	if order == nil {
+		return nil, false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	to := t.tenant(tenantID)
+	if cached, ok := to.byID[order.ID]; ok {
+		// Memory is never older than the repo
+		copied := *cached
+		return &copied, true
+	}
+	t.store(to, order)
+	copied := *order
+	return &copied, true
+}
+
+// store indexes order; the caller holds t.mu.
+func (t *OrderTracker) store(to *trackedOrders, order *model.TrackedOrder) {
+	if _, ok := to.byID[order.ID]; !ok {
+		to.order = append(to.order, order.ID)
+		if len(to.order) > t.maxOrders {
+			t.evict(to, to.order[0])
+			to.order = to.order[1:]
+		}
+	}
+	to.byID[order.ID] = order
+	if order.ClientOrderID != "" {
+		if prev, ok := to.byID[to.byClient[order.ClientOrderID]]; !ok || !prev.CreatedAt.After(order.CreatedAt) {
+			to.byClient[order.ClientOrderID] = order.ID
+		}
+	}
+	if order.OrderID != "" {
+		to.byOrder[order.OrderID] = order.ID
+	}
+}
+
+func (t *OrderTracker) evict(to *trackedOrders, id string) {
+	order, ok := to.byID[id]
+	if !ok {
+		return
+	}
+	delete(to.byID, id)
+	if to.byClient[order.ClientOrderID] == id {
+		delete(to.byClient, order.ClientOrderID)
+	}
+	if to.byOrder[order.OrderID] == id {
+		delete(to.byOrder, order.OrderID)
+	}
+}
+
+func (t *OrderTracker) tenant(tenantID string) *trackedOrders {
+	to, ok := t.tenants[tenantID]
+	if !ok {
+		to = &trackedOrders{
+			byID:     make(map[string]*model.TrackedOrder),
+			byClient: make(map[string]string),
+			byOrder:  make(map[string]string),
+		}
+		t.tenants[tenantID] = to
+	}
+	return to
+}
+
+func (t *OrderTracker) persist(order *model.TrackedOrder) {
+	if t.repo == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), fillRepoTimeout)
+	defer cancel()
+	if err := t.repo.SaveTrackedOrder(ctx, order); err != nil {
+		logger.Error("Failed to persist tracked order", "tenant_id", order.TenantID, "id", order.ID, "state", order.State, "error", err)
+	}
+}
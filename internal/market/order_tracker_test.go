@@ -0,0 +1,131 @@
+package market
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+type stubOrderRepo struct {
+	orders map[string]*model.TrackedOrder
+}
+
+func (r *stubOrderRepo) SaveTrackedOrder(ctx context.Context, order *model.TrackedOrder) error {
+	copied := *order
+	r.orders[order.ID] = &copied
+	return nil
+}
+
+func (r *stubOrderRepo) GetTrackedOrderByClientID(ctx context.Context, tenantID, clientOrderID string) (*model.TrackedOrder, error) {
+	var latest *model.TrackedOrder
+	for _, o := range r.orders {
+		if o.TenantID == tenantID && o.ClientOrderID == clientOrderID && (latest == nil || o.CreatedAt.After(latest.CreatedAt)) {
+			latest = o
+		}
+	}
+	return latest, nil
+}
+
+func (r *stubOrderRepo) GetTrackedOrderByOrderID(ctx context.Context, tenantID, orderID string) (*model.TrackedOrder, error) {
+	for _, o := range r.orders {
+		if o.TenantID == tenantID && o.OrderID == orderID {
+			return o, nil
+		}
+	}
+	return nil, nil
+}
+
+func trackedRequest(clientOrderID string) model.OrderRequest {
+	return model.OrderRequest{
+		TokenID:       "tok",
+		Side:          "BUY",
+		Price:         decimal.RequireFromString("0.5"),
+		Size:          decimal.RequireFromString("10"),
+		ClientOrderID: clientOrderID,
+	}
+}
+
+func TestOrderTrackerLifecycle(t *testing.T) {
+	ctx := context.Background()
+	tracker := NewOrderTracker(nil)
+
+	order, err := tracker.Track(ctx, "t1", trackedRequest("strat-1"))
+	if err != nil {
+		t.Fatalf("track: %v", err)
+	}
+	if order.State != model.OrderStateNew {
+		t.Fatalf("expected NEW, got %s", order.State)
+	}
+	if _, err := tracker.Track(ctx, "t1", trackedRequest("strat-1")); !errors.Is(err, ErrDuplicateClientOrderID) {
+		t.Fatalf("expected duplicate client order id error, got %v", err)
+	}
+	if _, err := tracker.Track(ctx, "t2", trackedRequest("strat-1")); err != nil {
+		t.Fatalf("client order ids are per tenant: %v", err)
+	}
+
+	tracker.Submitting("t1", order.ID)
+	// The user channel can report the fill before the POST response returns
+	tracker.Accepted("t1", order.ID, "0xabc")
+	tracker.OnOrderUpdate(&model.OrderUpdate{TenantID: "t1", OrderID: "0xabc", Type: OrderEventUpdate, OriginalSize: "10", SizeMatched: "4"})
+	tracker.Accepted("t1", order.ID, "0xabc")
+
+	got, ok := tracker.ByClientID(ctx, "t1", "strat-1")
+	if !ok || got.State != model.OrderStatePartiallyFilled || !got.SizeMatched.Equal(decimal.NewFromInt(4)) {
+		t.Fatalf("expected PARTIALLY_FILLED with 4 matched, got %+v", got)
+	}
+
+	tracker.OnOrderUpdate(&model.OrderUpdate{TenantID: "t1", OrderID: "0xabc", Type: OrderEventUpdate, OriginalSize: "10", SizeMatched: "10"})
+	tracker.OnOrderUpdate(&model.OrderUpdate{TenantID: "t1", OrderID: "0xabc", Type: OrderEventCancellation, OriginalSize: "10", SizeMatched: "10"})
+	got, _ = tracker.ByOrderID(ctx, "t1", "0xabc")
+	if got.State != model.OrderStateFilled {
+		t.Fatalf("terminal state must stick, got %s", got.State)
+	}
+
+	// Once the first order is done its client id can be reused
+	next, err := tracker.Track(ctx, "t1", trackedRequest("strat-1"))
+	if err != nil {
+		t.Fatalf("reuse after terminal state: %v", err)
+	}
+	tracker.Rejected("t1", next.ID, "risk reject")
+	got, _ = tracker.ByClientID(ctx, "t1", "strat-1")
+	if got.ID != next.ID || got.State != model.OrderStateRejected || got.Reason != "risk reject" {
+		t.Fatalf("expected the newest order to be REJECTED, got %+v", got)
+	}
+}
+
+func TestOrderTrackerExpiryAndRestart(t *testing.T) {
+	ctx := context.Background()
+	repo := &stubOrderRepo{orders: make(map[string]*model.TrackedOrder)}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tracker := NewOrderTracker(repo)
+	tracker.now = func() time.Time { return now }
+	req := trackedRequest("gtd-1")
+	req.Expiration = now.Add(time.Minute).Unix()
+	order, err := tracker.Track(ctx, "t1", req)
+	if err != nil {
+		t.Fatalf("track: %v", err)
+	}
+	tracker.Submitting("t1", order.ID)
+	tracker.Accepted("t1", order.ID, "0xgtd")
+
+	// A fresh tracker (gateway restart) finds the order through the repo
+	restarted := NewOrderTracker(repo)
+	restarted.now = func() time.Time { return now.Add(2 * time.Minute) }
+	got, ok := restarted.ByClientID(ctx, "t1", "gtd-1")
+	if !ok || got.State != model.OrderStateLive || got.OrderID != "0xgtd" {
+		t.Fatalf("expected LIVE order from repo, got %+v", got)
+	}
+	if _, err := restarted.Track(ctx, "t1", trackedRequest("gtd-1")); !errors.Is(err, ErrDuplicateClientOrderID) {
+		t.Fatalf("duplicate check must see persisted orders, got %v", err)
+	}
+
+	restarted.OnOrderUpdate(&model.OrderUpdate{TenantID: "t1", OrderID: "0xgtd", Type: OrderEventCancellation, OriginalSize: "10", SizeMatched: "0"})
+	if repo.orders[order.ID].State != model.OrderStateExpired {
+		t.Fatalf("expected EXPIRED to be persisted, got %s", repo.orders[order.ID].State)
+	}
+}
@@ -2,6 +2,7 @@ package market
 
 import (
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,22 +17,53 @@ type Level struct {
 
 // Orderbook represents the in-memory state of a market
 type Orderbook struct {
-	TokenID     string
-	Bids        []Level // Sorted High to Low
-	Asks        []Level // Sorted Low to High
-	LastUpdated time.Time
-	mu          sync.RWMutex
+	TokenID        string
+	Market         string  // Condition ID
+	Bids           []Level // Sorted High to Low
+	Asks           []Level // Sorted Low to High
+	TickSize       decimal.Decimal
+	LastTradePrice decimal.Decimal
+	LastTradeSize  decimal.Decimal
+	LastTradeSide  string
+	LastTradeAt    time.Time
+	TradedVolume   decimal.Decimal // Cumulative size of the trades seen since subscribing
+	LastUpdated    time.Time
+	Hash           string // Upstream hash of the last applied snapshot/delta
+
+	// Integrity: an invalid book must not be used for pricing decisions
+	invalidReason string
+	mismatches    int // consecutive REST checks that disagreed under a different hash
+	mu            sync.RWMutex
+}
+
+// BookMeta is a consistent copy of the non-level fields of an Orderbook.
+type BookMeta struct {
+	TokenID        string          `json:"token_id"`
+	Market         string          `json:"market,omitempty"`
+	TickSize       decimal.Decimal `json:"tick_size"`
+	LastTradePrice decimal.Decimal `json:"last_trade_price"`
+	LastTradeSize  decimal.Decimal `json:"last_trade_size"`
+	LastTradeSide  string          `json:"last_trade_side,omitempty"`
+	LastTradeAt    time.Time       `json:"last_trade_at"`
+	TradedVolume   decimal.Decimal `json:"traded_volume"`
+	LastUpdated    time.Time       `json:"last_updated"`
+	Hash           string          `json:"hash,omitempty"`
+	Valid          bool            `json:"valid"`
+	InvalidReason  string          `json:"invalid_reason,omitempty"`
 }
 
+const reasonAwaitingSnapshot = "awaiting snapshot"
+
 func NewOrderbook(tokenID string) *Orderbook {
 	return &Orderbook{
-		TokenID: tokenID,
-		Bids:    make([]Level, 0),
-		Asks:    make([]Level, 0),
+		TokenID:       tokenID,
+		Bids:          make([]Level, 0),
+		Asks:          make([]Level, 0),
+		invalidReason: reasonAwaitingSnapshot,
 	}
 }
 
-// Snapshot replaces the entire book state
+// Snapshot replaces the entire book state and marks it trusted again
 func (ob *Orderbook) Snapshot(bids, asks []Level) {
 	ob.mu.Lock()
 	defer ob.mu.Unlock()
@@ -39,6 +71,39 @@ func (ob *Orderbook) Snapshot(bids, asks []Level) {
 	ob.Bids = bids
 	ob.Asks = asks
 	ob.LastUpdated = time.Now()
+	ob.invalidReason = ""
+	ob.mismatches = 0
+}
+
+// SetHash records the upstream hash of the state we just applied
+func (ob *Orderbook) SetHash(hash string) {
+	if hash == "" {
+		return
+	}
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.Hash = hash
+}
+
+// Invalidate flags the book as untrustworthy until the next snapshot
+func (ob *Orderbook) Invalidate(reason string) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.invalidReason = reason
+}
+
+// IsValid reports whether the book is believed to mirror upstream
+func (ob *Orderbook) IsValid() bool {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return ob.invalidReason == ""
+}
+
+// InvalidReason explains why the book is invalid ("" when valid)
+func (ob *Orderbook) InvalidReason() string {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return ob.invalidReason
 }
 
 // Update processes a price/size update
@@ -95,17 +160,72 @@ func (ob *Orderbook) updateLevel(levels *[]Level, price, size decimal.Decimal, d
 		// Insert
 		*levels = append(*levels, Level{Price: price, Size: size})
 		// Re-sort
-		if descending {
-			// Bids: High to Low
-			sort.Slice(*levels, func(i, j int) bool {
-				return (*levels)[i].Price.GreaterThan((*levels)[j].Price)
-			})
-		} else {
-			// Asks: Low to High
-			sort.Slice(*levels, func(i, j int) bool {
-				return (*levels)[i].Price.LessThan((*levels)[j].Price)
-			})
-		}
+		sortLevels(*levels, descending)
+	}
+}
+
+func sortLevels(levels []Level, descending bool) {
+	if descending {
+		// Bids: High to Low
+		sort.Slice(levels, func(i, j int) bool {
+			return levels[i].Price.GreaterThan(levels[j].Price)
+		})
+	} else {
+		// Asks: Low to High
+		sort.Slice(levels, func(i, j int) bool {
+			return levels[i].Price.LessThan(levels[j].Price)
+		})
+	}
+}
+
+// SetMarket records the condition ID the token belongs to
+func (ob *Orderbook) SetMarket(market string) {
+	if market == "" {
+		return
+	}
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.Market = market
+}
+
+// SetTickSize records the current minimum price increment
+func (ob *Orderbook) SetTickSize(tick decimal.Decimal) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.TickSize = tick
+}
+
+// SetLastTrade records the most recent trade printed on this token
+func (ob *Orderbook) SetLastTrade(price, size decimal.Decimal, side string, at time.Time) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.LastTradePrice = price
+	ob.LastTradeSize = size
+	ob.LastTradeSide = side
+	ob.TradedVolume = ob.TradedVolume.Add(size)
+	if at.IsZero() {
+		at = time.Now()
+	}
+	ob.LastTradeAt = at
+}
+
+// Meta returns a safe copy of the book's metadata (Thread-safe read)
+func (ob *Orderbook) Meta() BookMeta {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return BookMeta{
+		TokenID:        ob.TokenID,
+		Market:         ob.Market,
+		TickSize:       ob.TickSize,
+		LastTradePrice: ob.LastTradePrice,
+		LastTradeSize:  ob.LastTradeSize,
+		LastTradeSide:  ob.LastTradeSide,
+		LastTradeAt:    ob.LastTradeAt,
+		TradedVolume:   ob.TradedVolume,
+		LastUpdated:    ob.LastUpdated,
+		Hash:           ob.Hash,
+		Valid:          ob.invalidReason == "",
+		InvalidReason:  ob.invalidReason,
 	}
 }
 
@@ -120,3 +240,29 @@ func (ob *Orderbook) GetCopy() (bids, asks []Level) {
 	copy(asks, ob.Asks)
 	return
 }
+
+// Mid returns the midpoint of the best bid and ask. ok is false when the book
+// is untrusted or one side is empty.
+func (ob *Orderbook) Mid() (mid decimal.Decimal, ok bool) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	if ob.invalidReason != "" || len(ob.Bids) == 0 || len(ob.Asks) == 0 {
+		return decimal.Zero, false
+	}
+	return ob.Bids[0].Price.Add(ob.Asks[0].Price).Div(decimal.NewFromInt(2)), true
+}
+
+// Best returns the top of one side of the book: the best bid for BUY, the best
+// ask for SELL. ok is false when the book is untrusted or that side is empty.
+func (ob *Orderbook) Best(side string) (price decimal.Decimal, ok bool) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	levels := ob.Bids
+	if strings.EqualFold(side, "SELL") {
+		levels = ob.Asks
+	}
+	if ob.invalidReason != "" || len(levels) == 0 {
+		return decimal.Zero, false
+	}
+	return levels[0].Price, true
+}
@@ -0,0 +1,531 @@
+package market
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultHoldTTL bounds how long exposure for an in-flight order is held before
+// the order is confirmed (or the user channel reports it).
+const DefaultHoldTTL = 60 * time.Second
+
+const (
+	// fillSettleAge is how long a fill may stay unconfirmed before it is folded anyway
+	fillSettleAge = 30 * time.Minute
+	// foldedFillTTL is how long folded fill IDs are kept to drop redelivered fills
+	foldedFillTTL = 24 * time.Hour
+)
+
+// PositionStore derives per-tenant positions and open-order exposure from fills
+// and order updates, and holds exposure for orders that are still in flight so
+// concurrent orders cannot all pass the same limit.
+type PositionStore struct {
+	mu      sync.Mutex
+	tenants map[string]*tenantExposure // Key: TenantID
+	markets map[string]string          // token id -> condition id (learned from events)
+	ttl     time.Duration
+}
+
+// Settled fills are folded into base and dropped; only fills that may still
+// fail are kept and replayed on top of it.
+type tenantExposure struct {
+	base         map[string]*model.Position // folded (settled) fills
+	baseRealized map[string]decimal.Decimal // Key: UTC day of the closing fill
+	pending      map[string]*model.Fill     // Key: fill ID, not settled yet (FAILED fills are dropped)
+	folded       map[string]time.Time       // fill ID -> fill time, drops redelivered fills
+	positions    map[string]*model.Position // base + pending
+	realized     map[string]decimal.Decimal
+	dirty        bool
+	orders       map[string]*openOrder // Key: order ID, or hold ID while in flight
+	closed       map[string]time.Time  // order IDs finished before the gateway confirmed them
+}
+
+type openOrder struct {
+	tokenID   string
+	side      string
+	price     decimal.Decimal
+	remaining decimal.Decimal
+	expiresAt time.Time // set only for in-flight holds
+}
+
+// OrderExposure describes an order about to be placed.
+type OrderExposure struct {
+	TokenID string
+	Side    string
+	Price   decimal.Decimal
+	Size    decimal.Decimal
+}
+
+func NewPositionStore() *PositionStore {
+	return &PositionStore{
+		tenants: make(map[string]*tenantExposure),
+		markets: make(map[string]string),
+		ttl:     DefaultHoldTTL,
+	}
+}
+
+// OnFill applies a fill (or its status progression) to the tenant's positions.
+func (s *PositionStore) OnFill(fill *model.Fill) {
+	if fill == nil || fill.ID == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.learnMarket(fill.AssetID, fill.Market)
+	te := s.tenant(fill.TenantID)
+	if _, ok := te.folded[fill.ID]; ok {
+		return
+	}
+	if strings.EqualFold(fill.Status, TradeStatusFailed) {
+		if _, ok := te.pending[fill.ID]; ok {
+			delete(te.pending, fill.ID)
+			te.dirty = true
+		}
+		return
+	}
+	copied := *fill
+	te.pending[fill.ID] = &copied
+	te.dirty = true
+}
+
+// ErrFillHistoryTruncated is returned by Seed when the repo dropped the tenant's
+// oldest fills: positions and PnL rebuilt from the rest would be wrong.
+var ErrFillHistoryTruncated = errors.New("fill history is truncated, positions cannot be rebuilt from it")
+
+// TruncatingFillRepo is a FillRepo that keeps a bounded fill history (Redis).
+type TruncatingFillRepo interface {
+	FillsTruncated(ctx context.Context, tenantID string) (bool, error)
+}
+
+// Seed loads the tenant's persisted fills, so positions survive a restart.
+// Fills the stream already delivered are not counted twice. A history the repo
+// truncated is refused with ErrFillHistoryTruncated.
+func (s *PositionStore) Seed(ctx context.Context, repo FillRepo, tenantID string) error {
+	if bounded, ok := repo.(TruncatingFillRepo); ok {
+		truncated, err := bounded.FillsTruncated(ctx, tenantID)
+		if err != nil {
+			return err
+		}
+		if truncated {
+			return ErrFillHistoryTruncated
+		}
+	}
+	fills, err := repo.ListFills(ctx, tenantID, model.FillFilter{})
+	if err != nil {
+		return err
+	}
+	for _, fill := range fills {
+		if fill.TenantID == "" {
+			fill.TenantID = tenantID
+		}
+		s.OnFill(fill)
+	}
+	return nil
+}
+
+// OnOrderUpdate tracks the remaining size of the tenant's open orders.
+func (s *PositionStore) OnOrderUpdate(update *model.OrderUpdate) {
+	if update == nil || update.OrderID == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.learnMarket(update.AssetID, update.Market)
+	te := s.tenant(update.TenantID)
+
+	original, _ := decimal.NewFromString(update.OriginalSize)
+	matched, _ := decimal.NewFromString(update.SizeMatched)
+	remaining := original.Sub(matched)
+	if strings.EqualFold(update.Type, OrderEventCancellation) || !remaining.IsPositive() {
+		delete(te.orders, update.OrderID)
+		te.closed[update.OrderID] = time.Now()
+		return
+	}
+	price, err := decimal.NewFromString(update.Price)
+	if err != nil {
+		return
+	}
+	te.orders[update.OrderID] = &openOrder{
+		tokenID:   update.AssetID,
+		side:      strings.ToUpper(update.Side),
+		price:     price,
+		remaining: remaining,
+	}
+}
+
+// SetMarket records which event (condition id) a token belongs to.
+func (s *PositionStore) SetMarket(tokenID, market string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.learnMarket(tokenID, market)
+}
+
+// Positions returns the tenant's non-flat positions.
+func (s *PositionStore) Positions(tenantID string) []*model.Position {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	te, ok := s.tenants[tenantID]
+	if !ok {
+		return []*model.Position{}
+	}
+	positions := s.positions(tenantID, te)
+	out := make([]*model.Position, 0, len(positions))
+	for _, pos := range positions {
+		if pos.Size.IsZero() {
+			continue
+		}
+		copied := *pos
+		out = append(out, &copied)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].TokenID < out[j].TokenID })
+	return out
+}
+
+// RealizedPnL returns the tenant's realized PnL overall and for the given UTC day (YYYY-MM-DD).
+func (s *PositionStore) RealizedPnL(tenantID, day string) (total, daily decimal.Decimal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	te, ok := s.tenants[tenantID]
+	if !ok {
+		return decimal.Zero, decimal.Zero
+	}
+	for _, pos := range s.positions(tenantID, te) {
+		total = total.Add(pos.Realized)
+	}
+	return total, te.realized[day]
+}
+
+// Check reports whether the order fits the tenant's exposure limits, without holding anything.
+func (s *PositionStore) Check(tenantID string, order OrderExposure, limits model.ExposureLimits) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.check(tenantID, order, limits, "")
+}
+
+// OpenOrder returns a confirmed open order of the tenant, sized at what is still resting.
+func (s *PositionStore) OpenOrder(tenantID, orderID string) (OrderExposure, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	te, ok := s.tenants[tenantID]
+	if !ok {
+		return OrderExposure{}, false
+	}
+	o, ok := te.orders[orderID]
+	if !ok || !o.expiresAt.IsZero() {
+		return OrderExposure{}, false
+	}
+	return OrderExposure{TokenID: o.tokenID, Side: o.side, Price: o.price, Size: o.remaining}, true
+}
+
+// Reserve checks the order against the limits and, if it fits, holds its
+// exposure until Confirm or Release. The returned hold ID identifies it.
+func (s *PositionStore) Reserve(tenantID string, order OrderExposure, limits model.ExposureLimits) (string, error) {
+	return s.ReserveReplacing(tenantID, "", order, limits)
+}
+
+// ReserveReplacing is Reserve for an order that replaces the open order `replaces`:
+// the limits are checked as if that order were already gone.
+func (s *PositionStore) ReserveReplacing(tenantID, replaces string, order OrderExposure, limits model.ExposureLimits) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(tenantID, order, limits, replaces); err != nil {
+		return "", err
+	}
+	holdID := "hold:" + uuid.New().String()
+	s.tenant(tenantID).orders[holdID] = &openOrder{
+		tokenID:   order.TokenID,
+		side:      strings.ToUpper(order.Side),
+		price:     order.Price,
+		remaining: order.Size,
+		expiresAt: time.Now().Add(s.ttl),
+	}
+	return holdID, nil
+}
+
+// Confirm turns a hold into the open order the exchange accepted. If the user
+// channel already reported the order (open or finished), the hold is just dropped.
+func (s *PositionStore) Confirm(tenantID, holdID, orderID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	te, ok := s.tenants[tenantID]
+	if !ok {
+		return
+	}
+	hold, ok := te.orders[holdID]
+	if !ok || orderID == "" {
+		// Without an order ID the hold stays until it expires or the stream catches up.
+		return
+	}
+	delete(te.orders, holdID)
+	if _, known := te.orders[orderID]; known {
+		return
+	}
+	if _, done := te.closed[orderID]; done {
+		return
+	}
+	hold.expiresAt = time.Time{}
+	te.orders[orderID] = hold
+}
+
+// Release drops a hold for an order that was never placed.
+func (s *PositionStore) Release(tenantID, holdID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if te, ok := s.tenants[tenantID]; ok {
+		delete(te.orders, holdID)
+	}
+}
+
+// RemoveOrder forgets an open order (e.g. after a successful cancel).
+func (s *PositionStore) RemoveOrder(tenantID, orderID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if te, ok := s.tenants[tenantID]; ok {
+		delete(te.orders, orderID)
+		te.closed[orderID] = time.Now()
+	}
+}
+
+// ClearOrders forgets every confirmed open order of the tenant (e.g. after cancel-all).
+// In-flight holds are kept: their orders may still land after the cancel.
+func (s *PositionStore) ClearOrders(tenantID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	te, ok := s.tenants[tenantID]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	for id, o := range te.orders {
+		if o.expiresAt.IsZero() {
+			delete(te.orders, id)
+			te.closed[id] = now
+		}
+	}
+}
+
+// check evaluates the limits; the open order `skip` (if any) is left out.
+func (s *PositionStore) check(tenantID string, order OrderExposure, limits model.ExposureLimits, skip string) error {
+	te := s.tenant(tenantID)
+	s.prune(te)
+	if !limits.Enabled() {
+		return nil
+	}
+	positions := s.positions(tenantID, te)
+	side := strings.ToUpper(order.Side)
+	orderVal := order.Price.Mul(order.Size)
+
+	var openVal, openBuys, openSells, eventVal decimal.Decimal
+	event := s.eventOf(order.TokenID)
+	for id, o := range te.orders {
+		if id == skip {
+			continue
+		}
+		val := o.price.Mul(o.remaining)
+		openVal = openVal.Add(val)
+		if s.eventOf(o.tokenID) == event {
+			eventVal = eventVal.Add(val)
+		}
+		if o.tokenID == order.TokenID {
+			if o.side == "BUY" {
+				openBuys = openBuys.Add(o.remaining)
+			} else {
+				openSells = openSells.Add(o.remaining)
+			}
+		}
+	}
+
+	if limits.MaxOpenOrderValue > 0 && openVal.Add(orderVal).GreaterThan(decimal.NewFromFloat(limits.MaxOpenOrderValue)) {
+		return &model.ExposureLimitError{Reason: "open_order_value_limit", Current: openVal, Order: orderVal, Limit: limits.MaxOpenOrderValue}
+	}
+
+	// Orders that only shrink an existing position never add exposure.
+	net := decimal.Zero
+	if pos, ok := positions[order.TokenID]; ok {
+		net = pos.Size
+	}
+	if (side == "SELL" && net.GreaterThanOrEqual(order.Size)) || (side == "BUY" && net.Neg().GreaterThanOrEqual(order.Size)) {
+		return nil
+	}
+
+	if limits.MaxPositionSize > 0 {
+		// Worst case: every resting order on the same side fills too.
+		current := net.Add(openBuys)
+		projected := current.Add(order.Size)
+		if side != "BUY" {
+			current = net.Sub(openSells).Neg()
+			projected = current.Add(order.Size)
+		}
+		if projected.GreaterThan(decimal.NewFromFloat(limits.MaxPositionSize)) {
+			return &model.ExposureLimitError{Reason: "position_limit", Current: current, Order: order.Size, Limit: limits.MaxPositionSize}
+		}
+	}
+
+	if limits.MaxEventValue > 0 {
+		for tokenID, pos := range positions {
+			if s.eventOf(tokenID) == event {
+				eventVal = eventVal.Add(pos.Notional())
+			}
+		}
+		if eventVal.Add(orderVal).GreaterThan(decimal.NewFromFloat(limits.MaxEventValue)) {
+			return &model.ExposureLimitError{Reason: "event_value_limit", Current: eventVal, Order: orderVal, Limit: limits.MaxEventValue}
+		}
+	}
+	return nil
+}
+
+// positions folds settled fills into the base and replays the pending ones on
+// top of it when the fills changed.
+func (s *PositionStore) positions(tenantID string, te *tenantExposure) map[string]*model.Position {
+	if !te.dirty && te.positions != nil {
+		return te.positions
+	}
+	fills := make([]*model.Fill, 0, len(te.pending))
+	for _, f := range te.pending {
+		fills = append(fills, f)
+	}
+	sort.Slice(fills, func(i, j int) bool {
+		if fills[i].Timestamp.Equal(fills[j].Timestamp) {
+			return fills[i].ID < fills[j].ID
+		}
+		return fills[i].Timestamp.Before(fills[j].Timestamp)
+	})
+
+	// Fold the settled prefix so fills are applied in time order
+	now := time.Now()
+	for len(fills) > 0 && fillSettled(fills[0], now) {
+		f := fills[0]
+		foldFill(tenantID, te.base, te.baseRealized, f)
+		te.folded[f.ID] = f.Timestamp
+		delete(te.pending, f.ID)
+		fills = fills[1:]
+	}
+	for id, at := range te.folded {
+		if now.Sub(at) > foldedFillTTL {
+			delete(te.folded, id)
+		}
+	}
+
+	positions := make(map[string]*model.Position, len(te.base))
+	for tokenID, pos := range te.base {
+		copied := *pos
+		positions[tokenID] = &copied
+	}
+	realized := make(map[string]decimal.Decimal, len(te.baseRealized))
+	for day, pnl := range te.baseRealized {
+		realized[day] = pnl
+	}
+	for _, f := range fills {
+		foldFill(tenantID, positions, realized, f)
+	}
+	te.positions = positions
+	te.realized = realized
+	te.dirty = false
+	return positions
+}
+
+// fillSettled reports whether a fill can no longer fail.
+func fillSettled(f *model.Fill, now time.Time) bool {
+	return strings.EqualFold(f.Status, TradeStatusConfirmed) || now.Sub(f.Timestamp) > fillSettleAge
+}
+
+// foldFill applies one fill to positions, booking any realized PnL on its UTC day.
+func foldFill(tenantID string, positions map[string]*model.Position, realized map[string]decimal.Decimal, f *model.Fill) {
+	price, err1 := decimal.NewFromString(f.Price)
+	size, err2 := decimal.NewFromString(f.Size)
+	if err1 != nil || err2 != nil || f.AssetID == "" {
+		return
+	}
+	pos, ok := positions[f.AssetID]
+	if !ok {
+		pos = &model.Position{TenantID: tenantID, TokenID: f.AssetID, Market: f.Market}
+		positions[f.AssetID] = pos
+	}
+	if strings.EqualFold(f.Side, "SELL") {
+		size = size.Neg()
+	}
+	if pnl := applyFill(pos, price, size); !pnl.IsZero() {
+		day := f.Timestamp.UTC().Format("2006-01-02")
+		realized[day] = realized[day].Add(pnl)
+	}
+}
+
+// applyFill updates an average-cost position with a signed fill quantity and
+// returns the PnL realized by the part of the fill that closed the position.
+func applyFill(pos *model.Position, price, qty decimal.Decimal) decimal.Decimal {
+	newSize := pos.Size.Add(qty)
+	realized := decimal.Zero
+	if !pos.Size.IsZero() && pos.Size.Sign() != qty.Sign() {
+		closed := decimal.Min(pos.Size.Abs(), qty.Abs())
+		realized = price.Sub(pos.AvgPrice).Mul(closed)
+		if pos.Size.IsNegative() {
+			realized = realized.Neg()
+		}
+		pos.Realized = pos.Realized.Add(realized)
+	}
+	switch {
+	case pos.Size.IsZero() || pos.Size.Sign() == qty.Sign():
+		// Opening or adding: blend the entry price
+		cost := pos.Size.Abs().Mul(pos.AvgPrice).Add(qty.Abs().Mul(price))
+		pos.AvgPrice = cost.Div(newSize.Abs())
+	case newSize.IsZero():
+		pos.AvgPrice = decimal.Zero
+	case newSize.Sign() != pos.Size.Sign():
+		// Flipped through flat: the remainder was opened at this price
+		pos.AvgPrice = price
+	}
+	pos.Size = newSize
+	return realized
+}
+
+func (s *PositionStore) prune(te *tenantExposure) {
+	now := time.Now()
+	for id, o := range te.orders {
+		if !o.expiresAt.IsZero() && now.After(o.expiresAt) {
+			delete(te.orders, id)
+		}
+	}
+	for id, at := range te.closed {
+		if now.Sub(at) > s.ttl {
+			delete(te.closed, id)
+		}
+	}
+}
+
+func (s *PositionStore) tenant(tenantID string) *tenantExposure {
+	te, ok := s.tenants[tenantID]
+	if !ok {
+		te = &tenantExposure{
+			base:         make(map[string]*model.Position),
+			baseRealized: make(map[string]decimal.Decimal),
+			pending:      make(map[string]*model.Fill),
+			folded:       make(map[string]time.Time),
+			orders:       make(map[string]*openOrder),
+			closed:       make(map[string]time.Time),
+		}
+		s.tenants[tenantID] = te
+	}
+	return te
+}
+
+func (s *PositionStore) learnMarket(tokenID, market string) {
+	if tokenID != "" && market != "" {
+		s.markets[tokenID] = market
+	}
+}
+
+// eventOf returns the token's condition id, or the token itself when unknown.
+func (s *PositionStore) eventOf(tokenID string) string {
+	if market, ok := s.markets[tokenID]; ok {
+		return market
+	}
+	return tokenID
+}
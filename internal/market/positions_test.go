@@ -0,0 +1,178 @@
+package market
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+func exposure(token, side, price, size string) OrderExposure {
+	return OrderExposure{
+		TokenID: token,
+		Side:    side,
+		Price:   decimal.RequireFromString(price),
+		Size:    decimal.RequireFromString(size),
+	}
+}
+
+func limitReason(err error) string {
+	var limitErr *model.ExposureLimitError
+	if errors.As(err, &limitErr) {
+		return limitErr.Reason
+	}
+	return ""
+}
+
+func TestPositionStoreDerivesPositionsFromFills(t *testing.T) {
+	s := NewPositionStore()
+	t0 := time.Now()
+	s.OnFill(&model.Fill{ID: "f1", TenantID: "t1", Market: "0xcond", AssetID: "yes", Side: "BUY", Price: "0.40", Size: "10", Status: "MATCHED", Timestamp: t0})
+	s.OnFill(&model.Fill{ID: "f2", TenantID: "t1", Market: "0xcond", AssetID: "yes", Side: "BUY", Price: "0.60", Size: "10", Status: "MATCHED", Timestamp: t0.Add(time.Second)})
+	s.OnFill(&model.Fill{ID: "f3", TenantID: "t1", Market: "0xcond", AssetID: "yes", Side: "SELL", Price: "0.70", Size: "5", Status: "MATCHED", Timestamp: t0.Add(2 * time.Second)})
+
+	positions := s.Positions("t1")
+	if len(positions) != 1 {
+		t.Fatalf("expected one position, got %d", len(positions))
+	}
+	pos := positions[0]
+	if !pos.Size.Equal(decimal.NewFromInt(15)) || !pos.AvgPrice.Equal(decimal.RequireFromString("0.5")) {
+		t.Fatalf("unexpected position: size %s avg %s", pos.Size, pos.AvgPrice)
+	}
+
+	// A failed trade is backed out of the position
+	s.OnFill(&model.Fill{ID: "f2", TenantID: "t1", Market: "0xcond", AssetID: "yes", Side: "BUY", Price: "0.60", Size: "10", Status: "FAILED", Timestamp: t0.Add(time.Second)})
+	pos = s.Positions("t1")[0]
+	if !pos.Size.Equal(decimal.NewFromInt(5)) || !pos.AvgPrice.Equal(decimal.RequireFromString("0.4")) {
+		t.Fatalf("failed fill not removed: size %s avg %s", pos.Size, pos.AvgPrice)
+	}
+}
+
+func TestPositionStoreEnforcesExposureLimits(t *testing.T) {
+	s := NewPositionStore()
+	s.OnFill(&model.Fill{ID: "f1", TenantID: "t1", Market: "0xcond", AssetID: "yes", Side: "BUY", Price: "0.50", Size: "100", Status: "MATCHED", Timestamp: time.Now()})
+	s.SetMarket("no", "0xcond")
+
+	// Position: 100 held, 20 more would exceed 110
+	limits := model.ExposureLimits{MaxPositionSize: 110}
+	if err := s.Check("t1", exposure("yes", "BUY", "0.50", "20"), limits); limitReason(err) != "position_limit" {
+		t.Fatalf("expected position_limit, got %v", err)
+	}
+	// Selling down is always allowed
+	if err := s.Check("t1", exposure("yes", "SELL", "0.50", "50"), limits); err != nil {
+		t.Fatalf("reducing order rejected: %v", err)
+	}
+
+	// Event: YES and NO of the same condition share the cap (50 USDC held)
+	limits = model.ExposureLimits{MaxEventValue: 60}
+	if err := s.Check("t1", exposure("no", "BUY", "0.50", "30"), limits); limitReason(err) != "event_value_limit" {
+		t.Fatalf("expected event_value_limit, got %v", err)
+	}
+	if err := s.Check("t1", exposure("other", "BUY", "0.50", "30"), limits); err != nil {
+		t.Fatalf("unrelated event rejected: %v", err)
+	}
+
+	// Open orders: in-flight holds count until released
+	limits = model.ExposureLimits{MaxOpenOrderValue: 10}
+	hold, err := s.Reserve("t1", exposure("other", "BUY", "0.50", "15"), limits)
+	if err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	if _, err := s.Reserve("t1", exposure("other", "BUY", "0.50", "15"), limits); limitReason(err) != "open_order_value_limit" {
+		t.Fatalf("expected open_order_value_limit, got %v", err)
+	}
+	s.Confirm("t1", hold, "o1")
+	s.OnOrderUpdate(&model.OrderUpdate{OrderID: "o1", TenantID: "t1", AssetID: "other", Side: "BUY", Price: "0.50", OriginalSize: "15", SizeMatched: "15", Type: "UPDATE"})
+	if _, err := s.Reserve("t1", exposure("other", "BUY", "0.50", "15"), limits); err != nil {
+		t.Fatalf("fully matched order should free open-order capacity: %v", err)
+	}
+}
+
+func TestPositionStoreConfirmAfterStreamClosedOrder(t *testing.T) {
+	s := NewPositionStore()
+	limits := model.ExposureLimits{MaxOpenOrderValue: 10}
+	hold, err := s.Reserve("t1", exposure("yes", "BUY", "0.50", "15"), limits)
+	if err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	// The stream reports the cancel before the gateway confirms the order
+	s.OnOrderUpdate(&model.OrderUpdate{OrderID: "o1", TenantID: "t1", AssetID: "yes", Side: "BUY", Price: "0.50", OriginalSize: "15", SizeMatched: "0", Type: "CANCELLATION"})
+	s.Confirm("t1", hold, "o1")
+	if err := s.Check("t1", exposure("yes", "BUY", "0.50", "15"), limits); err != nil {
+		t.Fatalf("closed order must not be resurrected: %v", err)
+	}
+}
+
+type memFillRepo struct {
+	fills     []*model.Fill
+	truncated bool
+}
+
+func (r *memFillRepo) FillsTruncated(ctx context.Context, tenantID string) (bool, error) {
+	return r.truncated, nil
+}
+
+func (r *memFillRepo) SaveFill(ctx context.Context, fill *model.Fill) error {
+	r.fills = append(r.fills, fill)
+	return nil
+}
+
+func (r *memFillRepo) ListFills(ctx context.Context, tenantID string, filter model.FillFilter) ([]*model.Fill, error) {
+	return r.fills, nil
+}
+
+func (r *memFillRepo) SaveOrderUpdate(ctx context.Context, update *model.OrderUpdate) error {
+	return nil
+}
+
+func (r *memFillRepo) GetOrderUpdates(ctx context.Context, tenantID string, orderIDs []string) (map[string]*model.OrderUpdate, error) {
+	return map[string]*model.OrderUpdate{}, nil
+}
+
+func TestPositionStoreFoldsSettledFillsAndSeeds(t *testing.T) {
+	old := time.Now().Add(-48 * time.Hour)
+	repo := &memFillRepo{fills: []*model.Fill{
+		{ID: "h2", TenantID: "t1", AssetID: "yes", Side: "SELL", Price: "0.60", Size: "4", Status: "CONFIRMED", Timestamp: old.Add(time.Minute)},
+		{ID: "h1", TenantID: "t1", AssetID: "yes", Side: "BUY", Price: "0.50", Size: "10", Status: "CONFIRMED", Timestamp: old},
+	}}
+
+	s := NewPositionStore()
+	recent := time.Now()
+	// The stream delivered a fresh fill before the seed ran
+	s.OnFill(&model.Fill{ID: "f1", TenantID: "t1", AssetID: "yes", Side: "BUY", Price: "0.40", Size: "6", Status: "MATCHED", Timestamp: recent})
+	if err := s.Seed(context.Background(), repo, "t1"); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	s.OnFill(&model.Fill{ID: "f1", TenantID: "t1", AssetID: "yes", Side: "BUY", Price: "0.40", Size: "6", Status: "MINED", Timestamp: recent})
+
+	pos := s.Positions("t1")[0]
+	// 10 @ 0.50, sell 4 (realized +0.40), buy 6 @ 0.40 -> 12 @ 0.45
+	if !pos.Size.Equal(decimal.NewFromInt(12)) || !pos.AvgPrice.Equal(decimal.RequireFromString("0.45")) {
+		t.Fatalf("unexpected seeded position: size %s avg %s", pos.Size, pos.AvgPrice)
+	}
+	if total, _ := s.RealizedPnL("t1", ""); !total.Equal(decimal.RequireFromString("0.4")) {
+		t.Fatalf("unexpected realized pnl %s", total)
+	}
+
+	// A history that lost its oldest fills is refused rather than half counted
+	repo.truncated = true
+	if err := NewPositionStore().Seed(context.Background(), repo, "t1"); !errors.Is(err, ErrFillHistoryTruncated) {
+		t.Fatalf("expected a truncated history to be refused, got %v", err)
+	}
+
+	te := s.tenants["t1"]
+	if len(te.pending) != 1 {
+		t.Fatalf("settled fills must be folded, %d still pending", len(te.pending))
+	}
+
+	// Confirmation folds the last one; a redelivery is not counted again
+	s.OnFill(&model.Fill{ID: "f1", TenantID: "t1", AssetID: "yes", Side: "BUY", Price: "0.40", Size: "6", Status: "CONFIRMED", Timestamp: recent})
+	s.Positions("t1")
+	s.OnFill(&model.Fill{ID: "f1", TenantID: "t1", AssetID: "yes", Side: "BUY", Price: "0.40", Size: "6", Status: "CONFIRMED", Timestamp: recent})
+	if pos := s.Positions("t1")[0]; len(te.pending) != 0 || !pos.Size.Equal(decimal.NewFromInt(12)) {
+		t.Fatalf("expected every fill folded once, pending %d size %s", len(te.pending), pos.Size)
+	}
+}
@@ -0,0 +1,152 @@
+package market
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Sweep is the outcome of walking one side of a book.
+type Sweep struct {
+	Size       decimal.Decimal // shares taken
+	Notional   decimal.Decimal // USDC paid (BUY) or received (SELL)
+	WorstPrice decimal.Decimal // deepest level reached
+	Levels     int             // levels touched, the last one possibly in part
+	Complete   bool            // the requested size / amount is covered
+}
+
+// AvgPrice is the volume-weighted price of the sweep (zero when nothing was taken).
+func (s Sweep) AvgPrice() decimal.Decimal {
+	if !s.Size.IsPositive() {
+		return decimal.Zero
+	}
+	return s.Notional.Div(s.Size).Round(6)
+}
+
+// Slippage is the adverse move of the sweep's average price against ref, as a
+// fraction (zero when nothing was taken).
+func (s Sweep) Slippage(side string, ref decimal.Decimal) decimal.Decimal {
+	if !s.Size.IsPositive() || !ref.IsPositive() {
+		return decimal.Zero
+	}
+	move := s.AvgPrice().Sub(ref)
+	if side == "SELL" {
+		move = move.Neg()
+	}
+	return move.Div(ref).Round(6)
+}
+
+// SlippageRef is the price slippage is measured against: the mid of the two best
+// levels, or the best price on the side a side order takes from without a mid.
+func SlippageRef(bids, asks []Level, side string) decimal.Decimal {
+	if len(bids) > 0 && len(asks) > 0 {
+		return bids[0].Price.Add(asks[0].Price).Div(decimal.NewFromInt(2))
+	}
+	if side == "SELL" && len(bids) > 0 {
+		return bids[0].Price
+	}
+	if side == "BUY" && len(asks) > 0 {
+		return asks[0].Price
+	}
+	return decimal.Zero
+}
+
+// CrossingSweep is what a limit order of size at limit takes on arrival: a BUY
+// walks the asks, a SELL the bids, stopping at levels worse than limit.
+func CrossingSweep(bids, asks []Level, side string, size, limit decimal.Decimal) Sweep {
+	levels := asks
+	if side == "SELL" {
+		levels = bids
+	}
+	return SweepLevels(levels, side, size, decimal.Zero, limit)
+}
+
+// SweepLevels walks levels (best first) taking size shares, or amount USDC worth
+// when size is zero, converted to whole cents of shares per level. A positive
+// limit stops the walk at levels worse than it (above for a BUY, below for a SELL).
+func SweepLevels(levels []Level, side string, size, amount, limit decimal.Decimal) Sweep {
+	var sweep Sweep
+	for _, l := range levels {
+		if limit.IsPositive() && ((side == "BUY" && l.Price.GreaterThan(limit)) || (side == "SELL" && l.Price.LessThan(limit))) {
+			break
+		}
+		take := l.Size
+		if size.IsPositive() {
+			take = decimal.Min(take, size.Sub(sweep.Size))
+		} else {
+			affordable := amount.Sub(sweep.Notional).Div(l.Price).RoundDown(2)
+			if affordable.LessThan(take) {
+				// The amount runs out inside this level
+				take = affordable
+				sweep.Complete = true
+			}
+		}
+		if take.IsPositive() {
+			sweep.Size = sweep.Size.Add(take)
+			sweep.Notional = sweep.Notional.Add(take.Mul(l.Price))
+			sweep.WorstPrice = l.Price
+			sweep.Levels++
+		}
+		if sweep.Complete || (size.IsPositive() && sweep.Size.GreaterThanOrEqual(size)) {
+			sweep.Complete = true
+			break
+		}
+	}
+	return sweep
+}
+
+// BookQuote is the pre-trade cost of taking size from one side of a book.
+type BookQuote struct {
+	TokenID            string          `json:"token_id"`
+	Side               string          `json:"side"`
+	Size               decimal.Decimal `json:"size"`
+	Fillable           decimal.Decimal `json:"fillable"` // < size when the book runs out
+	Notional           decimal.Decimal `json:"notional"`
+	AvgPrice           decimal.Decimal `json:"avg_price"`
+	WorstPrice         decimal.Decimal `json:"worst_price"`
+	BestPrice          decimal.Decimal `json:"best_price"`
+	Mid                decimal.Decimal `json:"mid,omitempty"`
+	Slippage           decimal.Decimal `json:"slippage"` // adverse move of avg_price against mid (or best_price without a mid), as a fraction
+	LevelsConsumed     int             `json:"levels_consumed"`
+	RemainingLiquidity decimal.Decimal `json:"remaining_liquidity"` // shares left on that side afterwards
+	Complete           bool            `json:"complete"`
+	LastUpdated        time.Time       `json:"last_updated"`
+}
+
+// Quote prices taking size shares from the book: a BUY walks the asks, a SELL the bids.
+// The caller decides whether an untrusted book (see IsValid) may be quoted.
+func (ob *Orderbook) Quote(side string, size decimal.Decimal) BookQuote {
+	bids, asks := ob.GetCopy()
+	levels := asks
+	if side == "SELL" {
+		levels = bids
+	}
+	sweep := SweepLevels(levels, side, size, decimal.Zero, decimal.Zero)
+
+	q := BookQuote{
+		TokenID:        ob.TokenID,
+		Side:           side,
+		Size:           size,
+		Fillable:       sweep.Size,
+		Notional:       sweep.Notional,
+		AvgPrice:       sweep.AvgPrice(),
+		WorstPrice:     sweep.WorstPrice,
+		LevelsConsumed: sweep.Levels,
+		Complete:       sweep.Complete,
+		LastUpdated:    ob.Meta().LastUpdated,
+	}
+	total := decimal.Zero
+	for _, l := range levels {
+		total = total.Add(l.Size)
+	}
+	q.RemainingLiquidity = total.Sub(sweep.Size)
+	if len(levels) == 0 {
+		return q
+	}
+	q.BestPrice = levels[0].Price
+	if mid, ok := ob.Mid(); ok {
+		q.Mid = mid
+	}
+	q.Slippage = sweep.Slippage(side, SlippageRef(bids, asks, side))
+	return q
+}
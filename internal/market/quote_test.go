@@ -0,0 +1,68 @@
+package market
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestSweepLevels(t *testing.T) {
+	d := decimal.RequireFromString
+	asks := []Level{
+		{Price: d("0.50"), Size: d("100")},
+		{Price: d("0.52"), Size: d("50")},
+		{Price: d("0.60"), Size: d("1000")},
+	}
+	bids := []Level{
+		{Price: d("0.48"), Size: d("20")},
+		{Price: d("0.45"), Size: d("200")},
+	}
+
+	cases := []struct {
+		name         string
+		levels       []Level
+		side         string
+		size, amount string
+		worst        string
+		wantSize     string
+		wantNotional string
+		wantLimit    string
+		wantComplete bool
+	}{
+		{"size within top level", asks, "BUY", "40", "0", "0.55", "40", "20", "0.50", true},
+		{"size across levels", asks, "BUY", "120", "0", "0.55", "120", "60.4", "0.52", true},
+		{"size stops at worst price", asks, "BUY", "200", "0", "0.55", "150", "76", "0.52", false},
+		{"amount ends inside a level", asks, "BUY", "0", "56", "0.55", "111.53", "55.9956", "0.52", true},
+		{"sell amount across bids", bids, "SELL", "0", "30", "0.40", "65.33", "29.9985", "0.45", true},
+		{"nothing within worst price", bids, "SELL", "10", "0", "0.50", "0", "0", "0", false},
+	}
+	for _, tc := range cases {
+		got := SweepLevels(tc.levels, tc.side, d(tc.size), d(tc.amount), d(tc.worst))
+		if !got.Size.Equal(d(tc.wantSize)) || !got.Notional.Equal(d(tc.wantNotional)) || !got.WorstPrice.Equal(d(tc.wantLimit)) || got.Complete != tc.wantComplete {
+			t.Fatalf("%s: got size=%s notional=%s worst=%s complete=%v", tc.name, got.Size, got.Notional, got.WorstPrice, got.Complete)
+		}
+	}
+}
+
+func TestOrderbookQuote(t *testing.T) {
+	d := decimal.RequireFromString
+	ob := NewOrderbook("tok")
+	ob.Snapshot(
+		[]Level{{Price: d("0.48"), Size: d("100")}},
+		[]Level{{Price: d("0.52"), Size: d("100")}, {Price: d("0.55"), Size: d("100")}, {Price: d("0.60"), Size: d("100")}},
+	)
+
+	q := ob.Quote("BUY", d("150"))
+	if !q.Complete || !q.Fillable.Equal(d("150")) || !q.AvgPrice.Equal(d("0.53")) || !q.WorstPrice.Equal(d("0.55")) {
+		t.Fatalf("unexpected quote: %+v", q)
+	}
+	// avg 0.53 against mid 0.50
+	if q.LevelsConsumed != 2 || !q.RemainingLiquidity.Equal(d("150")) || !q.Mid.Equal(d("0.5")) || !q.Slippage.Equal(d("0.06")) {
+		t.Fatalf("unexpected quote: %+v", q)
+	}
+
+	short := ob.Quote("SELL", d("150"))
+	if short.Complete || !short.Fillable.Equal(d("100")) || !short.RemainingLiquidity.IsZero() || !short.Slippage.Equal(d("0.04")) {
+		t.Fatalf("expected a partial sell quote, got %+v", short)
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/GoPolymarket/polygate/internal/pkg/logger"
 	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
 )
 
 const (
@@ -26,6 +28,15 @@ type MarketService struct {
 	ctx         context.Context
 	cancel      context.CancelFunc
 	isConnected bool
+
+	// Integrity checks against REST snapshots (optional)
+	source         BookSource
+	resyncInterval time.Duration
+
+	// Tick size / min size cache (optional source)
+	info       map[string]MarketInfo
+	infoSource MarketInfoSource
+	infoTTL    time.Duration
 }
 
 func NewMarketService() *MarketService {
@@ -33,6 +44,7 @@ func NewMarketService() *MarketService {
 	return &MarketService{
 		books: make(map[string]*Orderbook),
 		subs:  make([]string, 0),
+		info:  make(map[string]MarketInfo),
 		ctx:   ctx,
 		cancel: cancel,
 	}
@@ -41,6 +53,12 @@ func NewMarketService() *MarketService {
 // Start launches the connection loop in a background goroutine
 func (s *MarketService) Start() {
 	go s.runLoop()
+	s.mu.RLock()
+	verify := s.source != nil
+	s.mu.RUnlock()
+	if verify {
+		go s.verifyLoop()
+	}
 }
 
 // Stop closes the service
@@ -54,29 +72,31 @@ func (s *MarketService) Stop() {
 // Subscribe adds tokenIDs to the subscription list and updates the connection if active
 func (s *MarketService) Subscribe(tokenIDs []string) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 
 	// Add unique IDs
-	updates := false
+	added := make([]string, 0, len(tokenIDs))
 	for _, id := range tokenIDs {
-		found := false
-		for _, existing := range s.subs {
-			if existing == id {
-				found = true
-				break
-			}
+		if _, exists := s.books[id]; exists {
+			continue
 		}
-		if !found {
-			s.subs = append(s.subs, id)
-			// Initialize empty book
-			s.books[id] = NewOrderbook(id)
-			updates = true
+		s.subs = append(s.subs, id)
+		// Initialize empty book
+		book := NewOrderbook(id)
+		if !s.isConnected {
+			// Nothing streams until the connection is up and resubscribed
+			book.Invalidate(reasonDisconnected)
 		}
+		s.books[id] = book
+		added = append(added, id)
 	}
+	connected := s.isConnected
+	s.mu.Unlock()
 
-	if updates && s.isConnected {
+	if len(added) > 0 && connected {
 		// Send subscription message
-		s.sendSubscribe(tokenIDs)
+		if err := s.sendSubscribe(added); err != nil {
+			logger.Error("Failed to subscribe", "error", err)
+		}
 	}
 }
 
@@ -123,6 +143,7 @@ func (s *MarketService) runLoop() {
 				continue
 			}
 		}
+		s.streamResumed()
 
 		// Read Loop
 		s.readLoop()
@@ -130,6 +151,8 @@ func (s *MarketService) runLoop() {
 		s.mu.Lock()
 		s.isConnected = false
 		s.mu.Unlock()
+		// Deltas may have been missed; books stay untrusted until the next snapshot
+		s.invalidateAll(reasonDisconnected)
 	}
 }
 
@@ -177,11 +200,28 @@ func (s *MarketService) connect() error {
 }
 
 type WSMessage struct {
-	EventType string          `json:"event_type"` // "book" or "price_change"
-	Market    string          `json:"market"`     // TokenID (asset_id)
+	EventType string          `json:"event_type"` // book / price_change / tick_size_change / last_trade_price
+	AssetID   string          `json:"asset_id"`   // TokenID
+	Market    string          `json:"market"`     // Condition ID
 	Bids      []PriceLevelRaw `json:"bids"`
 	Asks      []PriceLevelRaw `json:"asks"`
-	Hash      string          `json:"hash"` // If present, it's a snapshot
+	Buys      []PriceLevelRaw `json:"buys"`  // legacy book format
+	Sells     []PriceLevelRaw `json:"sells"` // legacy book format
+	Hash      string          `json:"hash"`
+	Timestamp string          `json:"timestamp"`
+
+	// price_change
+	PriceChanges []PriceChangeRaw `json:"price_changes"`
+	Changes      []PriceChangeRaw `json:"changes"` // legacy: single asset per message
+
+	// tick_size_change
+	OldTickSize string `json:"old_tick_size"`
+	NewTickSize string `json:"new_tick_size"`
+
+	// last_trade_price
+	Price string `json:"price"`
+	Side  string `json:"side"`
+	Size  string `json:"size"`
 }
 
 type PriceLevelRaw struct {
@@ -189,6 +229,24 @@ type PriceLevelRaw struct {
 	Size  string `json:"size"`
 }
 
+type PriceChangeRaw struct {
+	AssetID string `json:"asset_id"`
+	Price   string `json:"price"`
+	Size    string `json:"size"`
+	Side    string `json:"side"`
+	Hash    string `json:"hash"`
+	BestBid string `json:"best_bid"`
+	BestAsk string `json:"best_ask"`
+}
+
+// TokenID returns the asset the message refers to.
+func (m WSMessage) TokenID() string {
+	if m.AssetID != "" {
+		return m.AssetID
+	}
+	return m.Market
+}
+
 func (s *MarketService) readLoop() {
 	defer s.conn.Close()
 	
@@ -216,50 +274,149 @@ func (s *MarketService) readLoop() {
 		}
 
 		for _, m := range msg {
-			if m.EventType == "book" && m.Market != "" {
-				s.processBookMessage(m)
-			}
+			s.processMessage(m)
 		}
 	}
 }
 
-func (s *MarketService) processBookMessage(msg WSMessage) {
+func (s *MarketService) processMessage(m WSMessage) {
+	switch m.EventType {
+	case "book":
+		s.processBookMessage(m)
+	case "price_change":
+		s.processPriceChange(m)
+	case "tick_size_change":
+		s.processTickSizeChange(m)
+	case "last_trade_price":
+		s.processLastTradePrice(m)
+	}
+}
+
+func (s *MarketService) lookupBook(tokenID string) *Orderbook {
+	if tokenID == "" {
+		return nil
+	}
 	s.mu.RLock()
-	book, exists := s.books[msg.Market]
-	s.mu.RUnlock()
+	defer s.mu.RUnlock()
+	return s.books[tokenID]
+}
 
-	if !exists {
+// processBookMessage handles a full snapshot: the book is replaced, not merged.
+func (s *MarketService) processBookMessage(msg WSMessage) {
+	book := s.lookupBook(msg.TokenID())
+	if book == nil {
 		return
 	}
 
-	for _, b := range msg.Bids {
-		if b.Size == "0" {
-			// Fast path for deletion
-			book.Update("BUY", b.Price, "0")
-		} else {
-			book.Update("BUY", b.Price, b.Size)
+	rawBids, rawAsks := msg.Bids, msg.Asks
+	if len(rawBids) == 0 && len(msg.Buys) > 0 {
+		rawBids = msg.Buys
+	}
+	if len(rawAsks) == 0 && len(msg.Sells) > 0 {
+		rawAsks = msg.Sells
+	}
+	bids, err := parseLevels(rawBids, true)
+	if err != nil {
+		logger.Warn("Invalid book snapshot", "token_id", book.TokenID, "error", err)
+		return
+	}
+	asks, err := parseLevels(rawAsks, false)
+	if err != nil {
+		logger.Warn("Invalid book snapshot", "token_id", book.TokenID, "error", err)
+		return
+	}
+	book.SetMarket(msg.Market)
+	book.Snapshot(bids, asks)
+	book.SetHash(msg.Hash)
+}
+
+// processPriceChange applies level deltas (size is the new absolute size, 0 removes).
+func (s *MarketService) processPriceChange(msg WSMessage) {
+	changes := msg.PriceChanges
+	if len(changes) == 0 {
+		changes = msg.Changes
+	}
+	for _, ch := range changes {
+		tokenID := ch.AssetID
+		if tokenID == "" {
+			tokenID = msg.TokenID()
+		}
+		book := s.lookupBook(tokenID)
+		if book == nil {
+			continue
+		}
+		side := strings.ToUpper(ch.Side)
+		if side != "BUY" && side != "SELL" {
+			continue
+		}
+		if err := book.Update(side, ch.Price, ch.Size); err != nil {
+			logger.Warn("Invalid price change", "token_id", tokenID, "error", err)
+			book.Invalidate("unparseable delta")
+			continue
 		}
+		book.SetHash(firstNonEmpty(ch.Hash, msg.Hash))
+	}
+}
+
+func (s *MarketService) processTickSizeChange(msg WSMessage) {
+	tick, err := decimal.NewFromString(msg.NewTickSize)
+	if err != nil || !tick.IsPositive() {
+		return
+	}
+	s.updateTickSize(msg.TokenID(), tick)
+	book := s.lookupBook(msg.TokenID())
+	if book == nil {
+		return
 	}
-	for _, a := range msg.Asks {
-		if a.Size == "0" {
-			book.Update("SELL", a.Price, "0")
-		} else {
-			book.Update("SELL", a.Price, a.Size)
+	logger.Info("Tick size changed", "token_id", book.TokenID, "old", msg.OldTickSize, "new", msg.NewTickSize)
+	book.SetTickSize(tick)
+}
+
+func (s *MarketService) processLastTradePrice(msg WSMessage) {
+	book := s.lookupBook(msg.TokenID())
+	if book == nil {
+		return
+	}
+	price, err := decimal.NewFromString(msg.Price)
+	if err != nil {
+		return
+	}
+	size, _ := decimal.NewFromString(msg.Size)
+	book.SetLastTrade(price, size, strings.ToUpper(msg.Side), parseEventTime(msg.Timestamp))
+}
+
+func parseLevels(raw []PriceLevelRaw, descending bool) ([]Level, error) {
+	levels := make([]Level, 0, len(raw))
+	for _, l := range raw {
+		price, err := decimal.NewFromString(l.Price)
+		if err != nil {
+			return nil, err
+		}
+		size, err := decimal.NewFromString(l.Size)
+		if err != nil {
+			return nil, err
+		}
+		if size.IsZero() {
+			continue
 		}
+		levels = append(levels, Level{Price: price, Size: size})
 	}
+	sortLevels(levels, descending)
+	return levels, nil
 }
 
 func (s *MarketService) sendSubscribe(tokenIDs []string) error {
 	msg := map[string]interface{}{
-		"type":       "subscribe",
-		"assets_ids": tokenIDs,
-		"channel_name": "book",
+		"type":         "market",
+		"operation":    "subscribe",
+		"assets_ids":   tokenIDs,
+		"initial_dump": true,
 	}
-	
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if s.conn == nil {
 		return fmt.Errorf("no connection")
 	}
 	return s.conn.WriteJSON(msg)
-}
\ No newline at end of file
+}
@@ -0,0 +1,213 @@
+package market
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/GoPolymarket/polymarket-go-sdk/pkg/clob/clobtypes"
+	"github.com/shopspring/decimal"
+)
+
+func feed(t *testing.T, s *MarketService, frame string) {
+	t.Helper()
+	var msgs []WSMessage
+	if err := json.Unmarshal([]byte(frame), &msgs); err != nil {
+		t.Fatalf("bad frame: %v", err)
+	}
+	for _, m := range msgs {
+		s.processMessage(m)
+	}
+}
+
+func TestMarketServiceAppliesBookEvents(t *testing.T) {
+	s := NewMarketService()
+	s.Subscribe([]string{"111"})
+
+	// Snapshot replaces whatever was there before
+	book := s.GetBook("111")
+	book.Update("BUY", "0.10", "99")
+	feed(t, s, `[{"event_type":"book","asset_id":"111","market":"0xcond",
+		"bids":[{"price":"0.48","size":"30"},{"price":"0.50","size":"10"}],
+		"asks":[{"price":"0.55","size":"5"},{"price":"0.52","size":"20"}]}]`)
+
+	bids, asks := book.GetCopy()
+	if len(bids) != 2 || !bids[0].Price.Equal(decimal.RequireFromString("0.50")) {
+		t.Fatalf("unexpected bids after snapshot: %+v", bids)
+	}
+	if len(asks) != 2 || !asks[0].Price.Equal(decimal.RequireFromString("0.52")) {
+		t.Fatalf("unexpected asks after snapshot: %+v", asks)
+	}
+
+	feed(t, s, `[{"event_type":"price_change","market":"0xcond","price_changes":[
+		{"asset_id":"111","price":"0.50","size":"0","side":"BUY"},
+		{"asset_id":"111","price":"0.51","size":"7","side":"SELL"},
+		{"asset_id":"999","price":"0.40","size":"1","side":"BUY"}]}]`)
+
+	bids, asks = book.GetCopy()
+	if len(bids) != 1 || !bids[0].Price.Equal(decimal.RequireFromString("0.48")) {
+		t.Fatalf("expected 0.50 bid removed, got %+v", bids)
+	}
+	if len(asks) != 3 || !asks[0].Price.Equal(decimal.RequireFromString("0.51")) {
+		t.Fatalf("expected new best ask 0.51, got %+v", asks)
+	}
+
+	feed(t, s, `[{"event_type":"tick_size_change","asset_id":"111","old_tick_size":"0.01","new_tick_size":"0.001"},
+		{"event_type":"last_trade_price","asset_id":"111","price":"0.51","size":"3","side":"buy","timestamp":"1700000000000"}]`)
+
+	meta := book.Meta()
+	if meta.Market != "0xcond" || !meta.TickSize.Equal(decimal.RequireFromString("0.001")) {
+		t.Fatalf("unexpected meta: %+v", meta)
+	}
+	if !meta.LastTradePrice.Equal(decimal.RequireFromString("0.51")) || meta.LastTradeSide != "BUY" || meta.LastTradeAt.Unix() != 1700000000 {
+		t.Fatalf("unexpected last trade: %+v", meta)
+	}
+}
+
+type stubBookSource struct {
+	book   clobtypes.OrderBookResponse
+	during func() // runs while the request is in flight
+}
+
+func (s *stubBookSource) OrderBook(ctx context.Context, req *clobtypes.BookRequest) (clobtypes.OrderBookResponse, error) {
+	if s.during != nil {
+		s.during()
+	}
+	return s.book, nil
+}
+
+func TestVerifyBookDetectsDivergenceAndResyncs(t *testing.T) {
+	s := NewMarketService()
+	s.Subscribe([]string{"111"})
+	book := s.GetBook("111")
+	if book.IsValid() {
+		t.Fatalf("book without a snapshot must not be valid")
+	}
+
+	feed(t, s, `[{"event_type":"book","asset_id":"111","hash":"h1",
+		"bids":[{"price":"0.50","size":"10"}],"asks":[{"price":"0.52","size":"20"}]}]`)
+
+	src := &stubBookSource{book: clobtypes.OrderBookResponse{
+		Hash: "h1",
+		Bids: []clobtypes.PriceLevel{{Price: "0.50", Size: "10"}},
+		Asks: []clobtypes.PriceLevel{{Price: "0.52", Size: "20"}},
+	}}
+	s.SetBookSource(src, time.Minute)
+
+	if err := s.VerifyBook(context.Background(), "111"); err != nil || !book.IsValid() {
+		t.Fatalf("matching book should stay valid (err=%v)", err)
+	}
+
+	// Same upstream hash but different levels: a delta was lost
+	src.book.Bids = []clobtypes.PriceLevel{{Price: "0.50", Size: "4"}, {Price: "0.49", Size: "1"}}
+	if err := s.VerifyBook(context.Background(), "111"); err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+	bids, _ := book.GetCopy()
+	if !book.IsValid() || len(bids) != 2 || !bids[0].Size.Equal(decimal.NewFromInt(4)) {
+		t.Fatalf("expected book resynced from REST, got %+v", bids)
+	}
+
+	// Different hash: tolerate one lagging check, resync on the second
+	src.book.Hash = "h2"
+	src.book.Asks = []clobtypes.PriceLevel{{Price: "0.53", Size: "1"}}
+	s.VerifyBook(context.Background(), "111")
+	if _, asks := book.GetCopy(); !asks[0].Price.Equal(decimal.RequireFromString("0.52")) {
+		t.Fatalf("should not resync on first lagging check")
+	}
+	s.VerifyBook(context.Background(), "111")
+	if _, asks := book.GetCopy(); !asks[0].Price.Equal(decimal.RequireFromString("0.53")) || book.Meta().Hash != "h2" {
+		t.Fatalf("expected resync after persistent drift")
+	}
+
+	// Disconnect invalidates until the stream is back: REST alone cannot keep the book current
+	s.invalidateAll(reasonDisconnected)
+	if book.IsValid() {
+		t.Fatalf("expected book invalid after disconnect")
+	}
+	s.VerifyBook(context.Background(), "111")
+	if book.IsValid() || book.InvalidReason() != reasonDisconnected {
+		t.Fatalf("a REST snapshot must not validate a book whose stream is down")
+	}
+	s.streamResumed()
+	s.VerifyBook(context.Background(), "111")
+	if !book.IsValid() {
+		t.Fatalf("expected invalid book to be resynced once the stream resumed")
+	}
+}
+
+func TestVerifyBookDiscardsStaleSnapshots(t *testing.T) {
+	s := NewMarketService()
+	s.Subscribe([]string{"111"})
+	book := s.GetBook("111")
+	feed(t, s, `[{"event_type":"book","asset_id":"111","hash":"h1",
+		"bids":[{"price":"0.50","size":"10"}],"asks":[{"price":"0.52","size":"20"}]}]`)
+	book.Invalidate(reasonHashMismatch)
+
+	// Every snapshot is overtaken by a delta streamed while it was in flight
+	src := &stubBookSource{book: clobtypes.OrderBookResponse{
+		Hash: "h0",
+		Bids: []clobtypes.PriceLevel{{Price: "0.49", Size: "1"}},
+		Asks: []clobtypes.PriceLevel{{Price: "0.52", Size: "20"}},
+	}}
+	deltas := 0
+	src.during = func() {
+		time.Sleep(time.Millisecond)
+		deltas++
+		feed(t, s, `[{"event_type":"price_change","market":"0xcond","price_changes":[
+			{"asset_id":"111","price":"0.50","size":"11","side":"BUY","hash":"h2"}]}]`)
+	}
+	s.SetBookSource(src, time.Minute)
+
+	if err := s.VerifyBook(context.Background(), "111"); err == nil {
+		t.Fatalf("expected an error when no snapshot is fresh")
+	}
+	bids, _ := book.GetCopy()
+	if deltas != snapshotAttempts || book.IsValid() || len(bids) != 1 || !bids[0].Size.Equal(decimal.NewFromInt(11)) {
+		t.Fatalf("stale snapshots must not roll the streamed book back, got %+v valid=%v after %d fetches", bids, book.IsValid(), deltas)
+	}
+
+	// The book goes quiet: the next snapshot is fresh and applied
+	src.during = nil
+	if err := s.VerifyBook(context.Background(), "111"); err != nil || !book.IsValid() {
+		t.Fatalf("expected the fresh snapshot to be applied (err=%v)", err)
+	}
+	if bids, _ := book.GetCopy(); !bids[0].Price.Equal(decimal.RequireFromString("0.49")) || book.Meta().Hash != "h0" {
+		t.Fatalf("unexpected book after resync: %+v", bids)
+	}
+}
+
+type stubInfoSource struct {
+	calls int
+	info  MarketInfo
+}
+
+func (s *stubInfoSource) MarketInfo(ctx context.Context, tokenID string) (MarketInfo, error) {
+	s.calls++
+	info := s.info
+	info.TokenID = tokenID
+	info.FetchedAt = time.Now()
+	return info, nil
+}
+
+func TestMarketInfoCachedAndUpdatedByTickSizeChange(t *testing.T) {
+	s := NewMarketService()
+	src := &stubInfoSource{info: MarketInfo{TickSize: decimal.RequireFromString("0.01"), MinSize: decimal.NewFromInt(5)}}
+	s.SetMarketInfoSource(src, time.Minute)
+
+	info, err := s.MarketInfo(context.Background(), "111")
+	if err != nil || !info.TickSize.Equal(decimal.RequireFromString("0.01")) {
+		t.Fatalf("unexpected info %+v (err=%v)", info, err)
+	}
+	s.MarketInfo(context.Background(), "111")
+	if src.calls != 1 {
+		t.Fatalf("expected cached lookup, got %d upstream calls", src.calls)
+	}
+
+	feed(t, s, `[{"event_type":"tick_size_change","asset_id":"111","old_tick_size":"0.01","new_tick_size":"0.001"}]`)
+	info, _ = s.MarketInfo(context.Background(), "111")
+	if !info.TickSize.Equal(decimal.RequireFromString("0.001")) || !info.MinSize.Equal(decimal.NewFromInt(5)) {
+		t.Fatalf("tick_size_change not applied: %+v", info)
+	}
+}
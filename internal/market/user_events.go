@@ -0,0 +1,239 @@
+package market
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+)
+
+// User channel event types
+const (
+	UserEventTrade = "trade"
+	UserEventOrder = "order"
+)
+
+// Trade statuses reported on the user channel
+const (
+	TradeStatusMatched   = "MATCHED"
+	TradeStatusMined     = "MINED"
+	TradeStatusConfirmed = "CONFIRMED"
+	TradeStatusRetrying  = "RETRYING"
+	TradeStatusFailed    = "FAILED"
+)
+
+// Order update types reported on the user channel
+const (
+	OrderEventPlacement    = "PLACEMENT"
+	OrderEventUpdate       = "UPDATE"
+	OrderEventCancellation = "CANCELLATION"
+)
+
+// TradeEvent is a "trade" message on the user channel.
+type TradeEvent struct {
+	EventType    string       `json:"event_type"`
+	Type         string       `json:"type"`
+	ID           string       `json:"id"`
+	Market       string       `json:"market"`
+	AssetID      string       `json:"asset_id"`
+	Side         string       `json:"side"`
+	Size         string       `json:"size"`
+	Price        string       `json:"price"`
+	Outcome      string       `json:"outcome"`
+	Status       string       `json:"status"`
+	Owner        string       `json:"owner"`
+	TradeOwner   string       `json:"trade_owner"`
+	TakerOrderID string       `json:"taker_order_id"`
+	MakerOrders  []MakerOrder `json:"maker_orders"`
+	MatchTime    string       `json:"matchtime"`
+	LastUpdate   string       `json:"last_update"`
+	Timestamp    string       `json:"timestamp"`
+}
+
+// MakerOrder is a resting order matched as part of a trade.
+type MakerOrder struct {
+	OrderID       string `json:"order_id"`
+	Owner         string `json:"owner"`
+	AssetID       string `json:"asset_id"`
+	MatchedAmount string `json:"matched_amount"`
+	Price         string `json:"price"`
+	Outcome       string `json:"outcome"`
+	Side          string `json:"side"`
+}
+
+// OrderEvent is an "order" message on the user channel.
+type OrderEvent struct {
+	EventType       string   `json:"event_type"`
+	Type            string   `json:"type"`
+	ID              string   `json:"id"`
+	Market          string   `json:"market"`
+	AssetID         string   `json:"asset_id"`
+	Owner           string   `json:"owner"`
+	Outcome         string   `json:"outcome"`
+	Side            string   `json:"side"`
+	Price           string   `json:"price"`
+	OriginalSize    string   `json:"original_size"`
+	SizeMatched     string   `json:"size_matched"`
+	AssociateTrades []string `json:"associate_trades"`
+	Timestamp       string   `json:"timestamp"`
+}
+
+// ParseUserMessage decodes a raw user channel frame (single object or array)
+// into typed trade and order events. Unknown event types are skipped.
+func ParseUserMessage(raw []byte) ([]TradeEvent, []OrderEvent) {
+	var frames []json.RawMessage
+	if err := json.Unmarshal(raw, &frames); err != nil {
+		frames = []json.RawMessage{raw}
+	}
+
+	var trades []TradeEvent
+	var orders []OrderEvent
+	for _, frame := range frames {
+		var head struct {
+			EventType string `json:"event_type"`
+		}
+		if err := json.Unmarshal(frame, &head); err != nil {
+			continue
+		}
+		switch strings.ToLower(head.EventType) {
+		case UserEventTrade:
+			var ev TradeEvent
+			if err := json.Unmarshal(frame, &ev); err == nil {
+				trades = append(trades, ev)
+			}
+		case UserEventOrder:
+			var ev OrderEvent
+			if err := json.Unmarshal(frame, &ev); err == nil {
+				orders = append(orders, ev)
+			}
+		}
+	}
+	return trades, orders
+}
+
+// Fills converts a trade event into the fills belonging to apiKey's orders.
+// A single trade can fill our taker order and/or several of our maker orders.
+func (ev TradeEvent) Fills(tenantID, apiKey string) []*model.Fill {
+	ts := parseEventTime(ev.MatchTime)
+	if ts.IsZero() {
+		ts = parseEventTime(ev.Timestamp)
+	}
+	if ts.IsZero() {
+		ts = time.Now().UTC()
+	}
+	now := time.Now().UTC()
+	status := strings.ToUpper(ev.Status)
+
+	var fills []*model.Fill
+	for _, mo := range ev.MakerOrders {
+		if apiKey == "" || mo.Owner != apiKey {
+			continue
+		}
+		fills = append(fills, &model.Fill{
+			ID:        ev.ID + ":" + mo.OrderID,
+			TenantID:  tenantID,
+			TradeID:   ev.ID,
+			OrderID:   mo.OrderID,
+			Market:    ev.Market,
+			AssetID:   firstNonEmpty(mo.AssetID, ev.AssetID),
+			Outcome:   firstNonEmpty(mo.Outcome, ev.Outcome),
+			Side:      makerSide(ev, mo),
+			Price:     mo.Price,
+			Size:      mo.MatchedAmount,
+			Role:      "MAKER",
+			Status:    status,
+			Timestamp: ts,
+			UpdatedAt: now,
+		})
+	}
+
+	isTaker := ev.TradeOwner != "" && ev.TradeOwner == apiKey
+	if ev.TradeOwner == "" && len(fills) == 0 {
+		// Older payloads omit trade_owner; a trade with none of our maker
+		// orders on it can only be our taker order.
+		isTaker = true
+	}
+	if isTaker && ev.TakerOrderID != "" {
+		fills = append(fills, &model.Fill{
+			ID:        ev.ID + ":" + ev.TakerOrderID,
+			TenantID:  tenantID,
+			TradeID:   ev.ID,
+			OrderID:   ev.TakerOrderID,
+			Market:    ev.Market,
+			AssetID:   ev.AssetID,
+			Outcome:   ev.Outcome,
+			Side:      strings.ToUpper(ev.Side),
+			Price:     ev.Price,
+			Size:      ev.Size,
+			Role:      "TAKER",
+			Status:    status,
+			Timestamp: ts,
+			UpdatedAt: now,
+		})
+	}
+	return fills
+}
+
+// OrderUpdate converts an order event into the persisted order state.
+func (ev OrderEvent) OrderUpdate(tenantID string) *model.OrderUpdate {
+	ts := parseEventTime(ev.Timestamp)
+	if ts.IsZero() {
+		ts = time.Now().UTC()
+	}
+	return &model.OrderUpdate{
+		OrderID:      ev.ID,
+		TenantID:     tenantID,
+		Market:       ev.Market,
+		AssetID:      ev.AssetID,
+		Outcome:      ev.Outcome,
+		Side:         strings.ToUpper(ev.Side),
+		Price:        ev.Price,
+		OriginalSize: ev.OriginalSize,
+		SizeMatched:  ev.SizeMatched,
+		Type:         strings.ToUpper(ev.Type),
+		Timestamp:    ts,
+		UpdatedAt:    time.Now().UTC(),
+	}
+}
+
+func makerSide(ev TradeEvent, mo MakerOrder) string {
+	if mo.Side != "" {
+		return strings.ToUpper(mo.Side)
+	}
+	takerSide := strings.ToUpper(ev.Side)
+	// Maker on the complementary token trades the same direction as the taker.
+	if mo.AssetID != "" && ev.AssetID != "" && mo.AssetID != ev.AssetID {
+		return takerSide
+	}
+	if takerSide == "BUY" {
+		return "SELL"
+	}
+	return "BUY"
+}
+
+// parseEventTime accepts unix seconds or milliseconds encoded as a string.
+func parseEventTime(raw string) time.Time {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}
+	}
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	if n > 1e12 {
+		return time.UnixMilli(n).UTC()
+	}
+	return time.Unix(n, 0).UTC()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
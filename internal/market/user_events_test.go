@@ -0,0 +1,115 @@
+package market
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+)
+
+const sampleUserFrame = `[
+  {
+    "event_type": "trade",
+    "type": "TRADE",
+    "id": "trade-1",
+    "market": "0xcond",
+    "asset_id": "111",
+    "side": "BUY",
+    "size": "10",
+    "price": "0.57",
+    "outcome": "YES",
+    "status": "MATCHED",
+    "owner": "key-a",
+    "trade_owner": "key-b",
+    "taker_order_id": "0xtaker",
+    "matchtime": "1672290701",
+    "maker_orders": [
+      {"order_id": "0xmaker1", "owner": "key-a", "asset_id": "111", "matched_amount": "4", "price": "0.57", "outcome": "YES"},
+      {"order_id": "0xmaker2", "owner": "key-c", "asset_id": "111", "matched_amount": "6", "price": "0.57", "outcome": "YES"}
+    ]
+  },
+  {
+    "event_type": "order",
+    "type": "UPDATE",
+    "id": "0xmaker1",
+    "market": "0xcond",
+    "asset_id": "111",
+    "side": "SELL",
+    "price": "0.57",
+    "original_size": "10",
+    "size_matched": "4",
+    "timestamp": "1672290702"
+  },
+  {"event_type": "unknown"}
+]`
+
+func TestParseUserMessageTypesEvents(t *testing.T) {
+	trades, orders := ParseUserMessage([]byte(sampleUserFrame))
+	if len(trades) != 1 || len(orders) != 1 {
+		t.Fatalf("expected 1 trade and 1 order event, got %d/%d", len(trades), len(orders))
+	}
+
+	fills := trades[0].Fills("tenant-a", "key-a")
+	if len(fills) != 1 {
+		t.Fatalf("expected only our maker fill, got %d", len(fills))
+	}
+	fill := fills[0]
+	if fill.OrderID != "0xmaker1" || fill.Role != "MAKER" || fill.Side != "SELL" || fill.Size != "4" {
+		t.Fatalf("unexpected maker fill: %+v", fill)
+	}
+	if fill.Status != TradeStatusMatched {
+		t.Fatalf("expected MATCHED status, got %s", fill.Status)
+	}
+	if !fill.Timestamp.Equal(time.Unix(1672290701, 0)) {
+		t.Fatalf("unexpected timestamp %s", fill.Timestamp)
+	}
+
+	takerFills := trades[0].Fills("tenant-b", "key-b")
+	if len(takerFills) != 1 || takerFills[0].Role != "TAKER" || takerFills[0].OrderID != "0xtaker" {
+		t.Fatalf("expected taker fill for trade owner, got %+v", takerFills)
+	}
+
+	update := orders[0].OrderUpdate("tenant-a")
+	if update.Type != OrderEventUpdate || update.SizeMatched != "4" {
+		t.Fatalf("unexpected order update: %+v", update)
+	}
+}
+
+func TestFillStoreUpsertsAndFilters(t *testing.T) {
+	store := NewFillStore(2, nil)
+	base := time.Unix(1700000000, 0).UTC()
+
+	store.AddFill(&model.Fill{ID: "t1:o1", TenantID: "a", OrderID: "o1", Market: "m1", Status: TradeStatusMatched, Timestamp: base})
+	store.AddFill(&model.Fill{ID: "t1:o1", TenantID: "a", OrderID: "o1", Market: "m1", Status: TradeStatusConfirmed, Timestamp: base.Add(time.Minute)})
+	store.AddFill(&model.Fill{ID: "t2:o2", TenantID: "a", OrderID: "o2", Market: "m2", Timestamp: base.Add(2 * time.Minute)})
+	store.AddFill(&model.Fill{ID: "t3:o3", TenantID: "b", OrderID: "o3", Market: "m1", Timestamp: base})
+
+	all := store.ListFills(context.Background(), "a", model.FillFilter{})
+	if len(all) != 2 {
+		t.Fatalf("expected 2 fills for tenant a, got %d", len(all))
+	}
+	if all[0].ID != "t2:o2" {
+		t.Fatalf("expected newest first, got %s", all[0].ID)
+	}
+	if all[1].Status != TradeStatusConfirmed || !all[1].Timestamp.Equal(base) {
+		t.Fatalf("expected status upsert keeping match time, got %+v", all[1])
+	}
+
+	byMarket := store.ListFills(context.Background(), "a", model.FillFilter{Market: "m1"})
+	if len(byMarket) != 1 || byMarket[0].OrderID != "o1" {
+		t.Fatalf("market filter failed: %+v", byMarket)
+	}
+
+	from := base.Add(time.Minute)
+	ranged := store.ListFills(context.Background(), "a", model.FillFilter{From: &from})
+	if len(ranged) != 1 || ranged[0].OrderID != "o2" {
+		t.Fatalf("time filter failed: %+v", ranged)
+	}
+
+	// Bounded per tenant: a third fill evicts the oldest
+	store.AddFill(&model.Fill{ID: "t4:o4", TenantID: "a", OrderID: "o4", Timestamp: base.Add(3 * time.Minute)})
+	if got := store.ListFills(context.Background(), "a", model.FillFilter{OrderID: "o1"}); len(got) != 0 {
+		t.Fatalf("expected oldest fill to be evicted")
+	}
+}
@@ -1,11 +1,9 @@
 package market
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/base64"
-	"encoding/json"
+	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,125 +11,209 @@ import (
 	"github.com/gorilla/websocket"
 )
 
+const UserWSURL = "wss://ws-subscriptions-clob.polymarket.com/ws/user"
+
+// UserStream is one authenticated user-channel connection (one per tenant).
 type UserStream struct {
-	conn      *websocket.Conn
-	apiKey    string
-	apiSecret string
-	passphrase string
-	fills     []Fill
-	mu        sync.RWMutex
+	conn        *websocket.Conn
+	mu          sync.Mutex
+	tenantID    string
+	apiKey      string
+	apiSecret   string
+	passphrase  string
+	store       *FillStore
+	ctx         context.Context
+	cancel      context.CancelFunc
+	isConnected bool
+}
+
+type userAuthPayload struct {
+	APIKey     string `json:"apiKey"`
+	Secret     string `json:"secret"`
+	Passphrase string `json:"passphrase"`
 }
 
-type Fill struct {
-	Market    string    `json:"market"`
-	Price     string    `json:"price"`
-	Size      string    `json:"size"`
-	Side      string    `json:"side"`
-	Timestamp time.Time `json:"timestamp"`
-	ID        string    `json:"fill_id"`
+type userSubscribeMessage struct {
+	Type    string          `json:"type"`
+	Markets []string        `json:"markets"`
+	Auth    userAuthPayload `json:"auth"`
 }
 
-func NewUserStream(key, secret, passphrase string) *UserStream {
+func NewUserStream(tenantID, key, secret, passphrase string, store *FillStore) *UserStream {
+	if store == nil {
+		store = NewFillStore(DefaultFillsPerTenant, nil)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
 	return &UserStream{
+		tenantID:   tenantID,
 		apiKey:     key,
 		apiSecret:  secret,
 		passphrase: passphrase,
-		fills:      make([]Fill, 0),
+		store:      store,
+		ctx:        ctx,
+		cancel:     cancel,
 	}
 }
 
+// Start launches the connection loop in a background goroutine
 func (s *UserStream) Start() {
-	go s.connectAndRead()
+	go s.runLoop()
+}
+
+// Stop closes the stream for good
+func (s *UserStream) Stop() {
+	s.cancel()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		s.conn.Close()
+	}
 }
 
-func (s *UserStream) GetFills() []Fill {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	// Return copy
-	res := make([]Fill, len(s.fills))
-	copy(res, s.fills)
-	return res
+func (s *UserStream) IsConnected() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.isConnected
 }
 
-func (s *UserStream) connectAndRead() {
-	// 1. Dial
-	conn, _, err := websocket.DefaultDialer.Dial(WSURL, nil)
-	if err != nil {
-		logger.Error("Dial failed", "error", err)
-		return
+func (s *UserStream) runLoop() {
+	delay := ReconnBaseDelay
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		default:
+		}
+
+		if err := s.connect(); err != nil {
+			logger.Error("User stream connection failed", "tenant_id", s.tenantID, "error", err, "retry_in", delay)
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			delay *= 2
+			if delay > ReconnMaxDelay {
+				delay = ReconnMaxDelay
+			}
+			continue
+		}
+
+		// Connected and authenticated
+		delay = ReconnBaseDelay
+		logger.Info("User stream connected", "tenant_id", s.tenantID)
+
+		s.readLoop()
+
+		s.mu.Lock()
+		s.isConnected = false
+		s.mu.Unlock()
 	}
-	s.conn = conn
-	defer conn.Close()
+}
 
-	// 2. Auth
-	if err := s.authenticate(); err != nil {
-		logger.Error("Auth failed", "error", err)
-		return
+func (s *UserStream) connect() error {
+	conn, _, err := websocket.DefaultDialer.DialContext(s.ctx, UserWSURL, nil)
+	if err != nil {
+		return err
 	}
 
-	// 3. Subscribe
-	subMsg := map[string]interface{}{
-		"type":         "subscribe",
-		"channel_name": "user",
+	// Zombie Check: any frame (including PONG) extends the deadline
+	readTimeout := PingPeriod + 10*time.Second
+	conn.SetReadDeadline(time.Now().Add(readTimeout))
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+		return nil
+	})
+
+	// Auth travels with the subscription on the user channel
+	sub := userSubscribeMessage{
+		Type:    "user",
+		Markets: []string{},
+		Auth: userAuthPayload{
+			APIKey:     s.apiKey,
+			Secret:     s.apiSecret,
+			Passphrase: s.passphrase,
+		},
 	}
-	if err := conn.WriteJSON(subMsg); err != nil {
-		logger.Error("Subscribe failed", "error", err)
-		return
+	if err := conn.WriteJSON(sub); err != nil {
+		conn.Close()
+		return fmt.Errorf("subscribe failed: %w", err)
 	}
 
-	// 4. Read Loop
+	s.mu.Lock()
+	s.conn = conn
+	s.isConnected = true
+	s.mu.Unlock()
+
+	// Start Pinger
+	go func() {
+		ticker := time.NewTicker(PingPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+				s.mu.Lock()
+				if !s.isConnected || s.conn != conn {
+					s.mu.Unlock()
+					return
+				}
+				// CLOB WS expects a text "PING" keep-alive
+				err := conn.WriteMessage(websocket.TextMessage, []byte("PING"))
+				s.mu.Unlock()
+				if err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (s *UserStream) readLoop() {
+	s.mu.Lock()
+	conn := s.conn
+	s.mu.Unlock()
+	defer conn.Close()
+
+	readTimeout := PingPeriod + 10*time.Second
+
 	for {
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
 		_, msg, err := conn.ReadMessage()
 		if err != nil {
-			logger.Error("Read failed", "error", err)
+			select {
+			case <-s.ctx.Done():
+			default:
+				logger.Error("User stream read error", "tenant_id", s.tenantID, "error", err)
+			}
 			return
 		}
+		if isKeepAlive(msg) {
+			continue
+		}
 		s.handleMessage(msg)
 	}
 }
 
-func (s *UserStream) authenticate() error {
-	// Timestamp
-	ts := fmt.Sprintf("%d", time.Now().Unix())
-	signStr := ts + "GET" + "/ws/market"
-	
-	// HMAC-SHA256
-	mac := hmac.New(sha256.New, []byte(s.apiSecret))
-	mac.Write([]byte(signStr))
-	sig := base64.StdEncoding.EncodeToString(mac.Sum(nil))
-
-	authMsg := map[string]string{
-		"type":        "auth",
-		"key":         s.apiKey,
-		"signature":   sig,
-		"timestamp":   ts,
-		"passphrase":  s.passphrase,
-	}
-	
-	return s.conn.WriteJSON(authMsg)
-}
-
 func (s *UserStream) handleMessage(raw []byte) {
-	// Parse generic to check event type
-	var msgs []WSMessage
-	if err := json.Unmarshal(raw, &msgs); err != nil {
-		var single WSMessage
-		if err2 := json.Unmarshal(raw, &single); err2 == nil {
-			msgs = []WSMessage{single}
-		}
-	}
+	trades, orders := ParseUserMessage(raw)
 
-	for _, m := range msgs {
-		if m.EventType == "fills" {
-			// Parse Fills
-			// Note: The structure of 'fills' event might differ from 'book'
-			// For MVP, we just log it
-			logger.Info("Fill received", "market", m.Market)
-			
-			// In real impl, parse m.Data or m.Fills list and append to s.fills
-			// s.mu.Lock()
-			// s.fills = append(s.fills, ...)
-			// s.mu.Unlock()
+	for _, ev := range trades {
+		for _, fill := range ev.Fills(s.tenantID, s.apiKey) {
+			logger.Info("Fill received", "tenant_id", s.tenantID, "market", fill.Market, "order_id", fill.OrderID, "status", fill.Status)
+			s.store.AddFill(fill)
 		}
 	}
+	for _, ev := range orders {
+		s.store.AddOrderUpdate(ev.OrderUpdate(s.tenantID))
+	}
+}
+
+func isKeepAlive(msg []byte) bool {
+	trimmed := strings.TrimSpace(string(msg))
+	return trimmed == "PONG" || trimmed == "PING" || trimmed == ""
 }
@@ -0,0 +1,105 @@
+package market
+
+import (
+	"sync"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/pkg/logger"
+)
+
+// UserStreamManager owns one user-channel stream per tenant with L2 credentials.
+type UserStreamManager struct {
+	mu      sync.Mutex
+	streams map[string]*managedUserStream // Key: TenantID
+	store   *FillStore
+}
+
+type managedUserStream struct {
+	stream *UserStream
+	creds  model.PolymarketCreds
+}
+
+func NewUserStreamManager(store *FillStore) *UserStreamManager {
+	if store == nil {
+		store = NewFillStore(DefaultFillsPerTenant, nil)
+	}
+	return &UserStreamManager{
+		streams: make(map[string]*managedUserStream),
+		store:   store,
+	}
+}
+
+// Store returns the fill store shared by all tenant streams.
+func (m *UserStreamManager) Store() *FillStore {
+	return m.store
+}
+
+// OnTenantUpsert starts, restarts or stops the tenant's stream to match its credentials.
+func (m *UserStreamManager) OnTenantUpsert(t *model.Tenant) {
+	if t == nil || t.ID == "" {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, exists := m.streams[t.ID]
+	if !hasL2Creds(t.Creds) {
+		if exists {
+			current.stream.Stop()
+			delete(m.streams, t.ID)
+			logger.Info("User stream stopped (no L2 credentials)", "tenant_id", t.ID)
+		}
+		return
+	}
+	if exists && sameL2Creds(current.creds, t.Creds) {
+		return
+	}
+	if exists {
+		current.stream.Stop()
+	}
+
+	stream := NewUserStream(t.ID, t.Creds.L2ApiKey, t.Creds.L2ApiSecret, t.Creds.L2ApiPassphrase, m.store)
+	stream.Start()
+	m.streams[t.ID] = &managedUserStream{stream: stream, creds: t.Creds}
+	logger.Info("User stream started", "tenant_id", t.ID, "restart", exists)
+}
+
+// OnTenantDelete stops the tenant's stream, if any.
+func (m *UserStreamManager) OnTenantDelete(tenantID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if current, ok := m.streams[tenantID]; ok {
+		current.stream.Stop()
+		delete(m.streams, tenantID)
+		logger.Info("User stream stopped (tenant deleted)", "tenant_id", tenantID)
+	}
+}
+
+// Stream returns the running stream for a tenant.
+func (m *UserStreamManager) Stream(tenantID string) (*UserStream, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current, ok := m.streams[tenantID]
+	if !ok {
+		return nil, false
+	}
+	return current.stream, true
+}
+
+// StopAll closes every stream
+func (m *UserStreamManager) StopAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, current := range m.streams {
+		current.stream.Stop()
+		delete(m.streams, id)
+	}
+}
+
+func hasL2Creds(c model.PolymarketCreds) bool {
+	return c.L2ApiKey != "" && c.L2ApiSecret != "" && c.L2ApiPassphrase != ""
+}
+
+func sameL2Creds(a, b model.PolymarketCreds) bool {
+	return a.L2ApiKey == b.L2ApiKey && a.L2ApiSecret == b.L2ApiSecret && a.L2ApiPassphrase == b.L2ApiPassphrase
+}
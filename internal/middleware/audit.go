@@ -13,7 +13,10 @@ import (
 	"github.com/google/uuid"
 )
 
-const ContextAuditLog = "audit_log"
+const (
+	ContextAuditLog  = "audit_log"
+	ContextAuditSkip = "audit_skip"
+)
 
 // bodyLogWriter 包装 ResponseWriter 以捕获响应体
 type bodyLogWriter struct {
@@ -71,6 +74,9 @@ func AuditMiddleware(auditSvc *service.AuditService) gin.HandlerFunc {
 		auditEntry.LatencyMs = time.Since(start).Milliseconds()
 
 		// 5. 异步发送日志
+		if c.GetBool(ContextAuditSkip) {
+			return
+		}
 		auditSvc.Log(auditEntry)
 	}
 }
@@ -84,6 +90,11 @@ func AddAuditContext(c *gin.Context, key string, value interface{}) {
 	}
 }
 
+// SkipAudit 标记当前请求不写审计日志（用于高频且不改变状态的请求，如心跳续约）
+func SkipAudit(c *gin.Context) {
+	c.Set(ContextAuditSkip, true)
+}
+
 func redactAuditBody(path string, body []byte) string {
 	if len(body) == 0 {
 		return ""
@@ -112,8 +123,11 @@ func isSensitivePath(path string) bool {
 }
 
 func redactJSON(body []byte) ([]byte, bool) {
+	// UseNumber keeps numeric literals (prices, sizes) byte-for-byte instead of round-tripping through float64
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
 	var data interface{}
-	if err := json.Unmarshal(body, &data); err != nil {
+	if err := dec.Decode(&data); err != nil {
 		return nil, false
 	}
 	redactValue(&data)
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -41,3 +42,14 @@ func TestRedactAuditBodyInvalidJSON(t *testing.T) {
 		t.Fatalf("expected redacted placeholder for invalid json")
 	}
 }
+
+func TestRedactAuditBodyKeepsNumericPrecision(t *testing.T) {
+	body := []byte(`{"token_id":"1","price":0.123456789012345678,"size":"10.5","signature":"0xdead"}`)
+	out := redactAuditBody("/v1/orders", body)
+	if !strings.Contains(out, `"price":0.123456789012345678`) {
+		t.Fatalf("price precision lost: %s", out)
+	}
+	if !strings.Contains(out, `"size":"10.5"`) {
+		t.Fatalf("size changed: %s", out)
+	}
+}
@@ -18,6 +18,11 @@ func ReadOnlyMiddleware(enabled bool) gin.HandlerFunc {
 			c.Next()
 			return
 		}
+		// A bot that cannot heartbeat would trip its dead man's switch
+		if c.Request.Method == http.MethodPost && c.FullPath() == "/v1/heartbeat" {
+			c.Next()
+			return
+		}
 
 		method := c.Request.Method
 		switch method {
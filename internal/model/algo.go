@@ -0,0 +1,123 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Algo limits
+const (
+	MaxAlgosPerTenant      = 50
+	MaxAlgoDurationSeconds = 86400
+	MaxParticipationRate   = 0.5
+	MaxMinRequoteMs        = 60000
+	DefaultMinRequoteMs    = 1000
+)
+
+// AlgoType 是母单执行算法类型
+type AlgoType string
+
+const (
+	AlgoTWAP    AlgoType = "TWAP"    // equal slices over duration_seconds
+	AlgoVWAP    AlgoType = "VWAP"    // participation_rate of the volume traded on the token (POV)
+	AlgoIceberg AlgoType = "ICEBERG" // one clip_size clip resting at a time, refilled as each one fills
+	AlgoPegged  AlgoType = "PEGGED"  // one order for the remainder, requoted as its peg reference moves
+)
+
+// Peg references of a PEGGED algo
+const (
+	PegBestBid = "best_bid"
+	PegBestAsk = "best_ask"
+	PegMid     = "mid"
+)
+
+// AlgoStatus is the state of a parent order.
+type AlgoStatus string
+
+const (
+	AlgoRunning   AlgoStatus = "RUNNING"
+	AlgoPaused    AlgoStatus = "PAUSED"
+	AlgoCompleted AlgoStatus = "COMPLETED" // fully filled (or the remainder is below the market's minimum size)
+	AlgoExpired   AlgoStatus = "EXPIRED"   // ran out of time before filling
+	AlgoCancelled AlgoStatus = "CANCELLED"
+	AlgoFailed    AlgoStatus = "FAILED" // child orders kept being rejected
+)
+
+// Terminal reports whether the algo will place no more child orders.
+func (s AlgoStatus) Terminal() bool {
+	switch s {
+	case AlgoCompleted, AlgoExpired, AlgoCancelled, AlgoFailed:
+		return true
+	}
+	return false
+}
+
+// AlgoRequest is the body of POST /v1/algos. Child orders are GTC limit orders at
+// limit_price placed through the regular order path; each one rests until the next
+// slice, when it is cancelled and the schedule is re-evaluated. Iceberg clips rest
+// until filled instead, or until the book moves away from a pegged clip. For PEGGED,
+// limit_price is the cap: a BUY is never quoted above it, a SELL never below.
+type AlgoRequest struct {
+	Type              AlgoType        `json:"type" binding:"required,oneof=TWAP VWAP ICEBERG PEGGED"`
+	TokenID           string          `json:"token_id" binding:"required"`
+	Side              string          `json:"side" binding:"required,oneof=BUY SELL"`
+	Size              decimal.Decimal `json:"size" binding:"required"`
+	LimitPrice        decimal.Decimal `json:"limit_price" binding:"required"`
+	DurationSeconds   int             `json:"duration_seconds" binding:"min=0,max=86400"`             // TWAP: required; others: optional deadline
+	IntervalSeconds   int             `json:"interval_seconds" binding:"min=0,max=3600"`              // TWAP / VWAP: slice / decision interval
+	ParticipationRate decimal.Decimal `json:"participation_rate"`                                     // VWAP only, (0, 0.5]
+	ClipSize          decimal.Decimal `json:"clip_size"`                                              // ICEBERG only: visible size
+	Peg               bool            `json:"peg"`                                                    // ICEBERG only: rest at the best bid (BUY) / ask (SELL), never beyond limit_price
+	PegTo             string          `json:"peg_to" binding:"omitempty,oneof=best_bid best_ask mid"` // PEGGED (required) / pegged ICEBERG (default: same-side best): reference price
+	PegOffset         decimal.Decimal `json:"peg_offset"`                                             // PEGGED / pegged ICEBERG: added to the reference price
+	MinRequoteMs      int             `json:"min_requote_ms" binding:"min=0,max=60000"`               // PEGGED / pegged ICEBERG: minimum time an order rests before a requote (default 1000)
+	RequoteThreshold  decimal.Decimal `json:"requote_threshold"`                                      // PEGGED / pegged ICEBERG: requote once the target moves this far (default: any tick)
+}
+
+// AlgoChild is the child order currently working for an algo.
+type AlgoChild struct {
+	OrderID           string          `json:"order_id"`
+	Price             decimal.Decimal `json:"price"`
+	Size              decimal.Decimal `json:"size"`
+	Filled            decimal.Decimal `json:"filled"`
+	PlacedAt          time.Time       `json:"placed_at"`
+	CancelRequestedAt *time.Time      `json:"cancel_requested_at,omitempty"`
+}
+
+// Algo 是一笔拆单执行中的母单及其进度
+type Algo struct {
+	ID                string          `json:"id" gorm:"primaryKey"`
+	TenantID          string          `json:"-" gorm:"index"`
+	Type              AlgoType        `json:"type"`
+	Status            AlgoStatus      `json:"status" gorm:"index"`
+	TokenID           string          `json:"token_id"`
+	Side              string          `json:"side"`
+	Size              decimal.Decimal `json:"size" gorm:"type:numeric(30,6)"`
+	LimitPrice        decimal.Decimal `json:"limit_price" gorm:"type:numeric(30,6)"`
+	DurationSeconds   int             `json:"duration_seconds,omitempty"`
+	IntervalSeconds   int             `json:"interval_seconds,omitempty"`
+	ParticipationRate decimal.Decimal `json:"participation_rate,omitempty" gorm:"type:numeric(30,6)"`
+	ClipSize          decimal.Decimal `json:"clip_size,omitempty" gorm:"type:numeric(30,6)"`
+	Peg               bool            `json:"peg,omitempty"`
+	PegTo             string          `json:"peg_to,omitempty"`
+	PegOffset         decimal.Decimal `json:"peg_offset,omitempty" gorm:"type:numeric(30,6)"`
+	MinRequoteMs      int             `json:"min_requote_ms,omitempty"`
+	RequoteThreshold  decimal.Decimal `json:"requote_threshold,omitempty" gorm:"type:numeric(30,6)"`
+	TickSize          decimal.Decimal `json:"tick_size,omitempty" gorm:"type:numeric(30,6)"`
+	MinChildSize      decimal.Decimal `json:"min_child_size,omitempty" gorm:"type:numeric(30,6)"`
+	Filled            decimal.Decimal `json:"filled" gorm:"type:numeric(30,6)"`
+	Notional          decimal.Decimal `json:"-" gorm:"type:numeric(30,12)"` // price * size summed over settled children
+	AvgPrice          decimal.Decimal `json:"avg_price" gorm:"type:numeric(30,6)"`
+	Progress          float64         `json:"progress"` // filled / size
+	ChildOrders       int             `json:"child_orders"`
+	Working           *AlgoChild      `json:"working,omitempty" gorm:"serializer:json"`
+	MarketVolume      decimal.Decimal `json:"market_volume,omitempty" gorm:"type:numeric(30,6)"` // VWAP: volume traded since start
+	NextSliceAt       time.Time       `json:"next_slice_at"`
+	StartedAt         time.Time       `json:"started_at"`
+	EndsAt            *time.Time      `json:"ends_at,omitempty"`
+	Reason            string          `json:"reason,omitempty"`
+	LastError         string          `json:"last_error,omitempty"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
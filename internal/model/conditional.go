@@ -0,0 +1,129 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// MaxConditionalOrdersPerTenant caps the active triggers one tenant may hold
+const MaxConditionalOrdersPerTenant = 500
+
+// ConditionalType 是网关侧条件单类型（Polymarket 本身不支持止损 / 止盈单）
+type ConditionalType string
+
+const (
+	ConditionalStopLoss     ConditionalType = "STOP_LOSS"
+	ConditionalTakeProfit   ConditionalType = "TAKE_PROFIT"
+	ConditionalTrailingStop ConditionalType = "TRAILING_STOP"
+)
+
+// Trigger price sources
+const (
+	TriggerSourceLastTrade = "last_trade"
+	TriggerSourceMid       = "mid"
+)
+
+// ConditionalStatus is the state of a conditional order.
+type ConditionalStatus string
+
+const (
+	ConditionalActive    ConditionalStatus = "ACTIVE"    // watching the market
+	ConditionalTriggered ConditionalStatus = "TRIGGERED" // order submitted (OrderID set once accepted)
+	ConditionalFailed    ConditionalStatus = "FAILED"    // triggered, but the order was rejected
+	ConditionalCancelled ConditionalStatus = "CANCELLED"
+)
+
+// ConditionalOrderRequest is the body of POST /v1/conditional-orders.
+// The order is placed with the gateway's signer when the trigger fires, so it must be custodial.
+//
+// Triggers are defined for the side of the order that will be placed: a SELL stop-loss fires
+// when the price falls to trigger_price, a SELL take-profit when it rises to it (BUY mirrors both).
+// A trailing stop follows the best price seen by trail_amount instead of using a fixed trigger.
+type ConditionalOrderRequest struct {
+	Type          ConditionalType `json:"type" binding:"required,oneof=STOP_LOSS TAKE_PROFIT TRAILING_STOP"`
+	TriggerPrice  decimal.Decimal `json:"trigger_price"`
+	TrailAmount   decimal.Decimal `json:"trail_amount"`
+	TriggerSource string          `json:"trigger_source,omitempty" binding:"omitempty,oneof=last_trade mid"`
+	Order         OrderRequest    `json:"order" binding:"required"`
+}
+
+// ConditionalOrder 是一张由网关保管的条件单，触发后通过正常下单流程（含风控）提交
+type ConditionalOrder struct {
+	ID            string            `json:"id" gorm:"primaryKey"`
+	TenantID      string            `json:"-" gorm:"index"`
+	Type          ConditionalType   `json:"type"`
+	TriggerSource string            `json:"trigger_source"`
+	TriggerPrice  decimal.Decimal   `json:"trigger_price" gorm:"type:numeric(30,6)"`
+	TrailAmount   decimal.Decimal   `json:"trail_amount,omitempty" gorm:"type:numeric(30,6)"`
+	Extreme       decimal.Decimal   `json:"extreme,omitempty" gorm:"type:numeric(30,6)"` // best price seen by a trailing stop
+	TokenID       string            `json:"token_id"`
+	Side          string            `json:"side"`
+	Price         decimal.Decimal   `json:"price" gorm:"type:numeric(30,6)"` // limit price of the placed order
+	Size          decimal.Decimal   `json:"size" gorm:"type:numeric(30,6)"`
+	OrderType     string            `json:"order_type,omitempty"`
+	Expiration    int64             `json:"expiration,omitempty"`
+	ClientOrderID string            `json:"client_order_id,omitempty"`
+	SessionID     string            `json:"session_id,omitempty"`
+	Status        ConditionalStatus `json:"status" gorm:"index"`
+	FiredPrice    decimal.Decimal   `json:"fired_price,omitempty" gorm:"type:numeric(30,6)"` // market price that fired the trigger
+	OrderID       string            `json:"order_id,omitempty"`
+	Reason        string            `json:"reason,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	TriggeredAt   *time.Time        `json:"triggered_at,omitempty"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// Observe feeds one market price to an active trigger. fire reports that the order
+// should be placed now; moved reports that a trailing stop moved its stop level.
+func (o *ConditionalOrder) Observe(price decimal.Decimal) (fire, moved bool) {
+	if o.Status != ConditionalActive || !price.IsPositive() {
+		return false, false
+	}
+	sell := o.Side == "SELL"
+	switch o.Type {
+	case ConditionalStopLoss:
+		if sell {
+			return price.LessThanOrEqual(o.TriggerPrice), false
+		}
+		return price.GreaterThanOrEqual(o.TriggerPrice), false
+	case ConditionalTakeProfit:
+		if sell {
+			return price.GreaterThanOrEqual(o.TriggerPrice), false
+		}
+		return price.LessThanOrEqual(o.TriggerPrice), false
+	case ConditionalTrailingStop:
+		// A SELL trails the high, a BUY trails the low
+		if o.Extreme.IsZero() || (sell && price.GreaterThan(o.Extreme)) || (!sell && price.LessThan(o.Extreme)) {
+			o.Extreme = price
+			o.TriggerPrice = o.stopLevel()
+			moved = true
+		}
+		if sell {
+			return price.LessThanOrEqual(o.TriggerPrice), moved
+		}
+		return price.GreaterThanOrEqual(o.TriggerPrice), moved
+	}
+	return false, false
+}
+
+func (o *ConditionalOrder) stopLevel() decimal.Decimal {
+	if o.Side == "SELL" {
+		return o.Extreme.Sub(o.TrailAmount)
+	}
+	return o.Extreme.Add(o.TrailAmount)
+}
+
+// OrderRequest returns the order placed when the trigger fires.
+func (o *ConditionalOrder) OrderRequest() OrderRequest {
+	return OrderRequest{
+		TokenID:       o.TokenID,
+		Price:         o.Price,
+		Size:          o.Size,
+		Side:          o.Side,
+		OrderType:     o.OrderType,
+		Expiration:    o.Expiration,
+		ClientOrderID: o.ClientOrderID,
+		SessionID:     o.SessionID,
+	}
+}
@@ -1,12 +1,16 @@
 package model
 
-import "github.com/GoPolymarket/polymarket-go-sdk/pkg/clob/clobtypes"
+import (
+	"github.com/GoPolymarket/polymarket-go-sdk/pkg/clob/clobtypes"
+	"github.com/shopspring/decimal"
+)
 
-// OrderRequest represents the incoming JSON body
+// OrderRequest represents the incoming JSON body.
+// Price and Size accept decimal strings ("0.55") as well as JSON numbers; both are parsed exactly.
 type OrderRequest struct {
 	TokenID       string                   `json:"token_id" binding:"required"`
-	Price         float64                  `json:"price" binding:"required"`
-	Size          float64                  `json:"size" binding:"required"`
+	Price         decimal.Decimal          `json:"price" binding:"required"`
+	Size          decimal.Decimal          `json:"size" binding:"required"`
 	Side          string                   `json:"side" binding:"required,oneof=BUY SELL"` // BUY or SELL
 	OrderType     string                   `json:"order_type,omitempty"`                   // GTC/GTD/FAK/FOK
 	PostOnly      *bool                    `json:"post_only,omitempty"`
@@ -16,6 +20,8 @@ type OrderRequest struct {
 	Signer        string                   `json:"signer,omitempty"`
 	SignatureType *int                     `json:"signature_type,omitempty"` // 0=EOA,1=Proxy,2=Safe
 	L2            *L2Creds                 `json:"l2,omitempty"`
+	ClientOrderID string                   `json:"client_order_id,omitempty" binding:"omitempty,max=64"` // tenant-chosen ID, unique among active orders
+	SessionID     string                   `json:"session_id,omitempty" binding:"omitempty,max=64"`      // heartbeat session the order is tied to
 }
 
 type L2Creds struct {
@@ -33,3 +39,97 @@ type TypedOrderResponse struct {
 type CancelOrderInput struct {
 	ID string `json:"id" binding:"required"`
 }
+
+// PanicRequest carries the reason for a panic / resume (tenant_id is admin-only).
+// market / token_id / side narrow the halt to part of the tenant's book.
+type PanicRequest struct {
+	Reason   string `json:"reason"`
+	TenantID string `json:"tenant_id,omitempty"`
+	Market   string `json:"market,omitempty"`
+	TokenID  string `json:"token_id,omitempty"`
+	Side     string `json:"side,omitempty" binding:"omitempty,oneof=BUY SELL"`
+}
+
+// Filter returns the requested scope (empty for a full tenant / global halt).
+func (r PanicRequest) Filter() OrderFilter {
+	return OrderFilter{Market: r.Market, AssetID: r.TokenID, Side: r.Side}
+}
+
+// CancelOrdersResponse is returned by a filtered mass cancel
+type CancelOrdersResponse struct {
+	Status    string   `json:"status,omitempty"`
+	Cancelled []string `json:"cancelled"` // open orders known to match the filter
+	Count     int      `json:"count"`
+}
+
+// MaxBatchOrders caps one POST /v1/orders/batch request
+const MaxBatchOrders = 100
+
+// BatchOrderResult is the outcome of one order in a batch, at the same index as in the request
+type BatchOrderResult struct {
+	Index         int    `json:"index"`
+	ClientOrderID string `json:"client_order_id,omitempty"`
+	OrderID       string `json:"order_id,omitempty"`
+	Status        string `json:"status,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// BatchOrderResponse is returned by POST /v1/orders/batch
+type BatchOrderResponse struct {
+	Results []BatchOrderResult `json:"results"`
+	Placed  int                `json:"placed"`
+	Failed  int                `json:"failed"`
+}
+
+// AmendOrderRequest replaces an open order's price and/or size (zero keeps the original value)
+type AmendOrderRequest struct {
+	Price      decimal.Decimal `json:"price"`
+	Size       decimal.Decimal `json:"size"`
+	OrderType  string          `json:"order_type,omitempty"`
+	PostOnly   *bool           `json:"post_only,omitempty"`
+	Expiration int64           `json:"expiration,omitempty"`
+}
+
+// MarketOrderRequest is the body of POST /v1/orders/market: exactly one of amount
+// (USDC to spend on a BUY / receive on a SELL) or size (shares), swept through the
+// book no further than worst_price.
+type MarketOrderRequest struct {
+	TokenID       string          `json:"token_id" binding:"required"`
+	Side          string          `json:"side" binding:"required,oneof=BUY SELL"`
+	Amount        decimal.Decimal `json:"amount"`
+	Size          decimal.Decimal `json:"size"`
+	WorstPrice    decimal.Decimal `json:"worst_price" binding:"required"`
+	OrderType     string          `json:"order_type,omitempty" binding:"omitempty,oneof=FOK FAK"` // default FOK
+	ClientOrderID string          `json:"client_order_id,omitempty" binding:"omitempty,max=64"`
+	SessionID     string          `json:"session_id,omitempty" binding:"omitempty,max=64"`
+}
+
+// MarketOrderFill is a filled (or expected) quantity of a market order
+type MarketOrderFill struct {
+	Size     decimal.Decimal `json:"size"`
+	Notional decimal.Decimal `json:"notional"`
+	AvgPrice decimal.Decimal `json:"avg_price"`
+}
+
+// MarketOrderResponse compares the fill expected from the book with the fills reported
+type MarketOrderResponse struct {
+	OrderID       string          `json:"order_id,omitempty"`
+	Status        string          `json:"status,omitempty"`
+	OrderType     string          `json:"order_type"`
+	BookSource    string          `json:"book_source"` // stream / rest
+	LimitPrice    decimal.Decimal `json:"limit_price"` // deepest level the sweep reaches
+	Expected      MarketOrderFill `json:"expected"`
+	Actual        MarketOrderFill `json:"actual"`
+	ActualPending bool            `json:"actual_pending,omitempty"` // fill reports still outstanding when the response was built
+}
+
+// AmendOrderResponse reports both legs of a cancel/replace
+type AmendOrderResponse struct {
+	OriginalID    string `json:"original_id"`
+	ClientOrderID string `json:"client_order_id,omitempty"` // carried over to the replacement
+	Cancelled     bool   `json:"cancelled"`
+	Placed        bool   `json:"placed"`
+	OrderID       string `json:"order_id,omitempty"`
+	Status        string `json:"status,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Fill 代表一笔用户成交 (来自 user channel 的 trade 事件)
+// 同一笔成交会随状态推进 (MATCHED -> MINED -> CONFIRMED / FAILED) 多次更新
+type Fill struct {
+	ID        string    `json:"fill_id" gorm:"primaryKey"` // trade_id:order_id
+	TenantID  string    `json:"tenant_id" gorm:"index"`
+	TradeID   string    `json:"trade_id" gorm:"index"`
+	OrderID   string    `json:"order_id" gorm:"index"`
+	Market    string    `json:"market" gorm:"index"` // condition id
+	AssetID   string    `json:"asset_id"`            // token id
+	Outcome   string    `json:"outcome"`
+	Side      string    `json:"side"`
+	Price     string    `json:"price"`
+	Size      string    `json:"size"`
+	Role      string    `json:"role"`   // MAKER / TAKER
+	Status    string    `json:"status"` // MATCHED / MINED / CONFIRMED / RETRYING / FAILED
+	Timestamp time.Time `json:"timestamp" gorm:"index"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OrderUpdate 代表订单的最新状态 (来自 user channel 的 order 事件)
+type OrderUpdate struct {
+	OrderID      string    `json:"order_id" gorm:"primaryKey"`
+	TenantID     string    `json:"tenant_id" gorm:"index"`
+	Market       string    `json:"market"`
+	AssetID      string    `json:"asset_id"`
+	Outcome      string    `json:"outcome"`
+	Side         string    `json:"side"`
+	Price        string    `json:"price"`
+	OriginalSize string    `json:"original_size"`
+	SizeMatched  string    `json:"size_matched"`
+	Type         string    `json:"type"` // PLACEMENT / UPDATE / CANCELLATION
+	Timestamp    time.Time `json:"timestamp"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// FillFilter 定义成交查询条件
+type FillFilter struct {
+	Market  string
+	AssetID string
+	OrderID string
+	From    *time.Time
+	To      *time.Time
+	Limit   int
+}
+
+// Match reports whether the fill satisfies every non-empty filter field.
+func (f FillFilter) Match(fill *Fill) bool {
+	if fill == nil {
+		return false
+	}
+	if f.Market != "" && fill.Market != f.Market {
+		return false
+	}
+	if f.AssetID != "" && fill.AssetID != f.AssetID {
+		return false
+	}
+	if f.OrderID != "" && fill.OrderID != f.OrderID {
+		return false
+	}
+	if f.From != nil && fill.Timestamp.Before(*f.From) {
+		return false
+	}
+	if f.To != nil && fill.Timestamp.After(*f.To) {
+		return false
+	}
+	return true
+}
+
+// Order 是订单查询接口的返回：CLOB 上的状态 + user channel 上的明细
+type Order struct {
+	ID           string     `json:"id"`
+	Status       string     `json:"status"`
+	Market       string     `json:"market,omitempty"`
+	AssetID      string     `json:"asset_id,omitempty"`
+	Outcome      string     `json:"outcome,omitempty"`
+	Side         string     `json:"side,omitempty"`
+	Price        string     `json:"price,omitempty"`
+	OriginalSize string     `json:"original_size,omitempty"`
+	SizeMatched  string     `json:"size_matched,omitempty"`
+	LastEvent    string     `json:"last_event,omitempty"` // PLACEMENT / UPDATE / CANCELLATION
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+	Source       string     `json:"source"` // clob / stream / clob+stream
+	// Set when the order was placed through the gateway
+	ClientOrderID string     `json:"client_order_id,omitempty"`
+	State         OrderState `json:"state,omitempty"`
+}
+
+// OrderFilter 定义订单查询 / 批量撤单条件
+type OrderFilter struct {
+	Market  string
+	AssetID string
+	Side    string
+}
+
+// IsEmpty reports whether the filter selects every order.
+func (f OrderFilter) IsEmpty() bool {
+	return f.Market == "" && f.AssetID == "" && f.Side == ""
+}
+
+// Matches reports whether an order with the given attributes is selected.
+// An unknown (empty) attribute never matches a filter set on it.
+func (f OrderFilter) Matches(market, assetID, side string) bool {
+	if f.Market != "" && f.Market != market {
+		return false
+	}
+	if f.AssetID != "" && f.AssetID != assetID {
+		return false
+	}
+	if f.Side != "" && !strings.EqualFold(f.Side, side) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// MaxHeartbeatTTLSeconds caps how long a dead man's switch may wait for the next beat
+const MaxHeartbeatTTLSeconds = 3600
+
+// HeartbeatRequest arms (or re-arms) a tenant's dead man's switch; ttl_seconds 0 disarms it.
+// With a session_id only the orders tagged with that session are cancelled when it lapses.
+type HeartbeatRequest struct {
+	TTLSeconds int    `json:"ttl_seconds" binding:"min=0,max=3600"`
+	SessionID  string `json:"session_id,omitempty" binding:"omitempty,max=64"`
+}
+
+// Heartbeat 是一个已启用的死手开关：ExpiresAt 前未收到下一次心跳则撤单
+type Heartbeat struct {
+	TenantID   string    `json:"tenant_id"`
+	SessionID  string    `json:"session_id,omitempty"` // empty: all of the tenant's orders
+	TTLSeconds int       `json:"ttl_seconds"`
+	LastBeat   time.Time `json:"last_beat"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// MaxClientOrderIDLength bounds the tenant-supplied client_order_id
+const MaxClientOrderIDLength = 64
+
+// OrderState 是网关本地的订单生命周期状态
+type OrderState string
+
+const (
+	OrderStateNew             OrderState = "NEW"
+	OrderStatePendingSubmit   OrderState = "PENDING_SUBMIT"
+	OrderStateLive            OrderState = "LIVE"
+	OrderStatePartiallyFilled OrderState = "PARTIALLY_FILLED"
+	OrderStateFilled          OrderState = "FILLED"
+	OrderStateCancelled       OrderState = "CANCELLED"
+	OrderStateRejected        OrderState = "REJECTED"
+	OrderStateExpired         OrderState = "EXPIRED"
+)
+
+// Terminal reports whether no further transition is possible.
+func (s OrderState) Terminal() bool {
+	switch s {
+	case OrderStateFilled, OrderStateCancelled, OrderStateRejected, OrderStateExpired:
+		return true
+	}
+	return false
+}
+
+// rank orders the non-terminal states; terminal states share the highest rank.
+func (s OrderState) rank() int {
+	switch s {
+	case OrderStateNew:
+		return 0
+	case OrderStatePendingSubmit:
+		return 1
+	case OrderStateLive:
+		return 2
+	case OrderStatePartiallyFilled:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// CanTransition reports whether an order may move from s to next.
+// States only move forward: terminal states are final, and a late event (e.g. the
+// POST response arriving after the user channel already reported a fill) never
+// moves an order back.
+func (s OrderState) CanTransition(next OrderState) bool {
+	if s.Terminal() {
+		return false
+	}
+	return next.rank() > s.rank()
+}
+
+// TrackedOrder 是一笔经网关提交的订单的本地状态，
+// 可按租户自定义的 client_order_id 或交易所订单号查询
+type TrackedOrder struct {
+	ID            string          `json:"id" gorm:"primaryKey"` // gateway-assigned, exists before the exchange ID
+	TenantID      string          `json:"-" gorm:"index:idx_tracked_client;index:idx_tracked_order"`
+	ClientOrderID string          `json:"client_order_id,omitempty" gorm:"index:idx_tracked_client"`
+	OrderID       string          `json:"order_id,omitempty" gorm:"index:idx_tracked_order"`
+	TokenID       string          `json:"token_id"`
+	Side          string          `json:"side"`
+	Price         decimal.Decimal `json:"price" gorm:"type:numeric(30,6)"`
+	Size          decimal.Decimal `json:"size" gorm:"type:numeric(30,6)"`
+	SizeMatched   decimal.Decimal `json:"size_matched" gorm:"type:numeric(30,6)"`
+	Expiration    int64           `json:"expiration,omitempty"`
+	State         OrderState      `json:"state" gorm:"index"`
+	Reason        string          `json:"reason,omitempty"` // why an order was rejected
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// MaxOrderGroupLegs caps the legs of one OCO / bracket group
+const MaxOrderGroupLegs = 10
+
+// OrderGroupType 是订单组类型
+type OrderGroupType string
+
+const (
+	OrderGroupOCO     OrderGroupType = "OCO"     // one leg executing cancels the others; partial fills shrink them
+	OrderGroupBracket OrderGroupType = "BRACKET" // entry first; its exits form an OCO once it fills
+)
+
+// OrderGroupStatus is the state of an order group.
+type OrderGroupStatus string
+
+const (
+	OrderGroupPendingEntry OrderGroupStatus = "PENDING_ENTRY" // bracket entry resting, exits not armed yet
+	OrderGroupActive       OrderGroupStatus = "ACTIVE"        // legs working
+	OrderGroupDone         OrderGroupStatus = "DONE"          // a leg executed, the others were cancelled
+	OrderGroupCancelled    OrderGroupStatus = "CANCELLED"
+	OrderGroupFailed       OrderGroupStatus = "FAILED" // no exit leg could be armed
+)
+
+// Terminal reports whether the group no longer reacts to events.
+func (s OrderGroupStatus) Terminal() bool {
+	return s == OrderGroupDone || s == OrderGroupCancelled || s == OrderGroupFailed
+}
+
+// GroupLegStatus is the state of one leg.
+type GroupLegStatus string
+
+const (
+	GroupLegPending   GroupLegStatus = "PENDING" // bracket exit waiting for the entry
+	GroupLegOpen      GroupLegStatus = "OPEN"
+	GroupLegExecuted  GroupLegStatus = "EXECUTED" // limit leg fully filled or trigger fired
+	GroupLegCancelled GroupLegStatus = "CANCELLED"
+	GroupLegFailed    GroupLegStatus = "FAILED"
+	// GroupLegCancelPending: the exchange rejected the leg's cancel; it is retried until confirmed
+	GroupLegCancelPending GroupLegStatus = "CANCEL_PENDING"
+)
+
+// GroupLegRequest is one leg of a group: a resting limit order, or, with type set,
+// a gateway conditional order (see ConditionalOrderRequest).
+type GroupLegRequest struct {
+	Type          ConditionalType `json:"type,omitempty" binding:"omitempty,oneof=STOP_LOSS TAKE_PROFIT TRAILING_STOP"`
+	TriggerPrice  decimal.Decimal `json:"trigger_price"`
+	TrailAmount   decimal.Decimal `json:"trail_amount"`
+	TriggerSource string          `json:"trigger_source,omitempty" binding:"omitempty,oneof=last_trade mid"`
+	Order         OrderRequest    `json:"order" binding:"required"`
+}
+
+// IsConditional reports whether the leg is held by the trigger engine.
+func (l GroupLegRequest) IsConditional() bool {
+	return l.Type != ""
+}
+
+// Conditional returns the trigger definition of a conditional leg.
+func (l GroupLegRequest) Conditional() ConditionalOrderRequest {
+	return ConditionalOrderRequest{
+		Type:          l.Type,
+		TriggerPrice:  l.TriggerPrice,
+		TrailAmount:   l.TrailAmount,
+		TriggerSource: l.TriggerSource,
+		Order:         l.Order,
+	}
+}
+
+// OrderGroupRequest is the body of POST /v1/order-groups.
+// OCO takes two or more legs; BRACKET takes an entry plus exit legs on the same token
+// and the opposite side, armed once the entry fills.
+type OrderGroupRequest struct {
+	Type  OrderGroupType    `json:"type" binding:"required,oneof=OCO BRACKET"`
+	Entry *OrderRequest     `json:"entry,omitempty"`
+	Legs  []GroupLegRequest `json:"legs" binding:"required,min=1,max=10,dive"`
+}
+
+// GroupLeg is the live state of one leg.
+type GroupLeg struct {
+	Index         int                        `json:"index"`
+	Request       GroupLegRequest            `json:"request"`
+	Status        GroupLegStatus             `json:"status"`
+	OrderID       string                     `json:"order_id,omitempty"`       // limit legs
+	ConditionalID string                     `json:"conditional_id,omitempty"` // conditional legs
+	Filled        decimal.Decimal            `json:"filled"`                   // matched on the leg's own orders
+	Reduced       decimal.Decimal            `json:"reduced"`                  // taken off the leg by fills on the other legs
+	Orders        map[string]decimal.Decimal `json:"orders,omitempty"`         // limit legs: every order placed for the leg -> size matched
+	Reason        string                     `json:"reason,omitempty"`
+}
+
+// Remaining is what the leg still has to cover: its size less its own fills and
+// the fills on the other legs.
+func (l GroupLeg) Remaining() decimal.Decimal {
+	return l.Request.Order.Size.Sub(l.Filled).Sub(l.Reduced)
+}
+
+// OrderGroup 是一组联动订单（OCO / 括号单），由 user channel 成交事件驱动
+type OrderGroup struct {
+	ID           string           `json:"id" gorm:"primaryKey"`
+	TenantID     string           `json:"-" gorm:"index"`
+	Type         OrderGroupType   `json:"type"`
+	Status       OrderGroupStatus `json:"status" gorm:"index"`
+	Entry        *OrderRequest    `json:"entry,omitempty" gorm:"serializer:json"`
+	EntryOrderID string           `json:"entry_order_id,omitempty"`
+	EntryFilled  decimal.Decimal  `json:"entry_filled" gorm:"type:numeric(30,6)"`
+	Legs         []GroupLeg       `json:"legs" gorm:"serializer:json"`
+	Reason       string           `json:"reason,omitempty"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+}
+
+// CancelPending reports whether a leg's order may still be live upstream because
+// its cancel has not gone through; such a group stays loaded even once finished.
+func (g *OrderGroup) CancelPending() bool {
+	for _, leg := range g.Legs {
+		if leg.Status == GroupLegCancelPending {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// PanicScopeGlobal halts trading for every tenant.
+const PanicScopeGlobal = "global"
+
+// PanicState is the kill-switch state for one scope (global, a single tenant, or
+// part of a tenant's book narrowed by market / token / side).
+type PanicState struct {
+	Scope        string    `json:"scope" gorm:"primaryKey"` // "global", "tenant:<id>" or "tenant:<id>/market:<m>/..."
+	TenantID     string    `json:"tenant_id,omitempty"`     // empty for global
+	Market       string    `json:"market,omitempty"`
+	AssetID      string    `json:"token_id,omitempty"`
+	Side         string    `json:"side,omitempty"`
+	Active       bool      `json:"active"`
+	Reason       string    `json:"reason,omitempty"`
+	ActivatedBy  string    `json:"activated_by,omitempty"`
+	ActivatedAt  time.Time `json:"activated_at,omitempty"`
+	ResumedBy    string    `json:"resumed_by,omitempty"`
+	ResumeReason string    `json:"resume_reason,omitempty"`
+	ResumedAt    time.Time `json:"resumed_at,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// PanicScope returns the storage key for a tenant ("" means global).
+func PanicScope(tenantID string) string {
+	if tenantID == "" {
+		return PanicScopeGlobal
+	}
+	return "tenant:" + tenantID
+}
+
+// PanicScopeFor returns the storage key for a tenant halt narrowed by filter.
+func PanicScopeFor(tenantID string, filter OrderFilter) string {
+	scope := PanicScope(tenantID)
+	if filter.Market != "" {
+		scope += "/market:" + filter.Market
+	}
+	if filter.AssetID != "" {
+		scope += "/token:" + filter.AssetID
+	}
+	if filter.Side != "" {
+		scope += "/side:" + strings.ToUpper(filter.Side)
+	}
+	return scope
+}
+
+// Filter returns the part of the book a scoped halt covers (empty for a full halt).
+func (p PanicState) Filter() OrderFilter {
+	return OrderFilter{Market: p.Market, AssetID: p.AssetID, Side: p.Side}
+}
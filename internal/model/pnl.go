@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// LossLimits trigger the loss kill switch (0 = disabled)
+type LossLimits struct {
+	MaxDailyLoss float64
+	MaxDrawdown  float64
+}
+
+// PnL 是租户的盈亏快照（已实现 + 按中间价计算的未实现）
+type PnL struct {
+	TenantID      string          `json:"tenant_id"`
+	Realized      decimal.Decimal `json:"realized"`       // all-time, from fills
+	DailyRealized decimal.Decimal `json:"daily_realized"` // closing fills of the current UTC day
+	Unrealized    decimal.Decimal `json:"unrealized"`     // open positions marked at mid
+	Daily         decimal.Decimal `json:"daily"`          // DailyRealized + Unrealized
+	Total         decimal.Decimal `json:"total"`          // Realized + Unrealized
+	Peak          decimal.Decimal `json:"peak"`           // high-water mark of Total, rebased when an operator lifts a loss halt
+	Drawdown      decimal.Decimal `json:"drawdown"`       // Peak - Total
+	Positions     []*PositionMark `json:"positions"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// PositionMark is a position with the price it was marked at.
+type PositionMark struct {
+	Position
+	MarkPrice  decimal.Decimal `json:"mark_price"`
+	MarkSource string          `json:"mark_source"` // mid | last_trade | entry
+	Unrealized decimal.Decimal `json:"unrealized_pnl"`
+}
+
+// PnLBaseline is the loss guard's persisted reference for one tenant: the
+// drawdown high-water mark and, after an operator lifts a loss halt, the daily
+// loss already forgiven for that day.
+type PnLBaseline struct {
+	TenantID  string          `json:"tenant_id" gorm:"primaryKey"`
+	Peak      decimal.Decimal `json:"peak" gorm:"type:numeric(30,6)"`
+	Day       string          `json:"day"`                                  // UTC date DailyBase applies to
+	DailyBase decimal.Decimal `json:"daily_base" gorm:"type:numeric(30,6)"` // daily PnL at the last resume (<= 0)
+	RebasedAt time.Time       `json:"rebased_at,omitempty"`                 // ResumedAt of the halt last rebased on
+	UpdatedAt time.Time       `json:"updated_at"`
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Position 是租户在单个 token 上的净持仓（由成交推导，平均成本法）
+type Position struct {
+	TenantID string          `json:"tenant_id"`
+	TokenID  string          `json:"token_id"`
+	Market   string          `json:"market,omitempty"` // condition id, groups the outcomes of one event
+	Size     decimal.Decimal `json:"size"`             // > 0 long, < 0 short
+	AvgPrice decimal.Decimal `json:"avg_price"`
+	Realized decimal.Decimal `json:"realized_pnl"` // PnL locked in by reducing fills
+}
+
+// Notional is the position's value at its average entry price.
+func (p *Position) Notional() decimal.Decimal {
+	return p.Size.Abs().Mul(p.AvgPrice)
+}
+
+// ExposureLimits are the position / open-order caps enforced before an order is sent (0 = unlimited)
+type ExposureLimits struct {
+	MaxPositionSize   float64
+	MaxEventValue     float64
+	MaxOpenOrderValue float64
+}
+
+// Enabled reports whether any exposure limit is set.
+func (l ExposureLimits) Enabled() bool {
+	return l.MaxPositionSize > 0 || l.MaxEventValue > 0 || l.MaxOpenOrderValue > 0
+}
+
+// ExposureLimitError is returned when an order would push exposure past a limit.
+// Current includes filled positions, open orders and in-flight orders, but not the new order.
+type ExposureLimitError struct {
+	Reason  string // position_limit | event_value_limit | open_order_value_limit
+	Current decimal.Decimal
+	Order   decimal.Decimal
+	Limit   float64
+}
+
+func (e *ExposureLimitError) Error() string {
+	switch e.Reason {
+	case "position_limit":
+		return fmt.Sprintf("risk reject: position limit exceeded (curr: %s, new: %s, max: %.2f)", e.Current.StringFixed(2), e.Order.StringFixed(2), e.Limit)
+	case "event_value_limit":
+		return fmt.Sprintf("risk reject: event exposure limit exceeded (curr: %s, new: %s, max: %.2f)", e.Current.StringFixed(2), e.Order.StringFixed(2), e.Limit)
+	default:
+		return fmt.Sprintf("risk reject: open order value limit exceeded (curr: %s, new: %s, max: %.2f)", e.Current.StringFixed(2), e.Order.StringFixed(2), e.Limit)
+	}
+}
@@ -0,0 +1,93 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultReservationTTL bounds how long an uncommitted reservation holds capacity
+// (e.g. if the process dies between reserve and commit).
+const DefaultReservationTTL = 60 * time.Second
+
+// USDCDecimals is the on-chain precision of USDC; order values are kept at this scale.
+const USDCDecimals = 6
+
+// RoundUSDC rounds an amount up to whole micro-USDC, so usage is never under-counted.
+func RoundUSDC(amount decimal.Decimal) decimal.Decimal {
+	return amount.RoundCeil(USDCDecimals)
+}
+
+// ToMicroUSDC converts an amount to integer micro-USDC (rounding up).
+func ToMicroUSDC(amount decimal.Decimal) int64 {
+	return RoundUSDC(amount).Shift(USDCDecimals).IntPart()
+}
+
+// FromMicroUSDC converts integer micro-USDC back to an amount.
+func FromMicroUSDC(micro int64) decimal.Decimal {
+	return decimal.New(micro, -USDCDecimals)
+}
+
+// UsageLimits are the daily caps enforced atomically at reservation time (0 = unlimited)
+type UsageLimits struct {
+	MaxDailyValue  decimal.Decimal
+	MaxDailyOrders int
+}
+
+// Check returns a *UsageLimitError if adding orders/amount to the current usage would exceed a limit
+func (l UsageLimits) Check(curOrders int, curVolume decimal.Decimal, orders int, amount decimal.Decimal) error {
+	if l.MaxDailyValue.IsPositive() && curVolume.Add(amount).GreaterThan(l.MaxDailyValue) {
+		return &UsageLimitError{Reason: "daily_volume_limit", Orders: curOrders, Volume: curVolume, Amount: amount, Limits: l}
+	}
+	if l.MaxDailyOrders > 0 && curOrders+orders > l.MaxDailyOrders {
+		return &UsageLimitError{Reason: "daily_order_limit", Orders: curOrders, Volume: curVolume, Amount: amount, Limits: l}
+	}
+	return nil
+}
+
+// UsageReservation holds daily capacity for one in-flight order
+type UsageReservation struct {
+	ID       string          `json:"id"`
+	TenantID string          `json:"tenant_id"`
+	Day      string          `json:"day"` // Usage bucket the reservation was taken from
+	Orders   int             `json:"orders"`
+	Amount   decimal.Decimal `json:"amount"`
+}
+
+// UsageLimitError is returned by ReserveUsage when a limit would be exceeded.
+// Orders/Volume include both committed usage and pending reservations.
+type UsageLimitError struct {
+	Reason string // daily_volume_limit | daily_order_limit
+	Orders int
+	Volume decimal.Decimal
+	Amount decimal.Decimal
+	Limits UsageLimits
+}
+
+func (e *UsageLimitError) Error() string {
+	if e.Reason == "daily_order_limit" {
+		return fmt.Sprintf("risk reject: daily order limit exceeded (curr: %d, max: %d)", e.Orders, e.Limits.MaxDailyOrders)
+	}
+	return fmt.Sprintf("risk reject: daily volume limit exceeded (curr: %s, new: %s, max: %s)", e.Volume, e.Amount, e.Limits.MaxDailyValue)
+}
+
+// RiskUsage is the committed daily usage row (Postgres)
+type RiskUsage struct {
+	TenantID  string          `gorm:"primaryKey"`
+	Day       string          `gorm:"primaryKey"`
+	Orders    int             `gorm:"not null;default:0"`
+	Volume    decimal.Decimal `gorm:"type:numeric(30,6);not null;default:0"`
+	UpdatedAt time.Time
+}
+
+// RiskReservation is a pending reservation row (Postgres)
+type RiskReservation struct {
+	ID        string `gorm:"primaryKey"`
+	TenantID  string `gorm:"index:idx_risk_reservation_day"`
+	Day       string `gorm:"index:idx_risk_reservation_day"`
+	Orders    int
+	Amount    decimal.Decimal `gorm:"type:numeric(30,6)"`
+	ExpiresAt time.Time       `gorm:"index"`
+	CreatedAt time.Time
+}
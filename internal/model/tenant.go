@@ -6,7 +6,13 @@ type RiskConfig struct {
 	MaxDailyValue             float64  `json:"max_daily_value"`             // 单日最大交易额
 	MaxDailyOrders            int      `json:"max_daily_orders"`            // 单日最大订单数
 	MaxSlippage               float64  `json:"max_slippage"`                // 允许的最大偏离 (0.05 = 5%)
+	MaxPositionSize           float64  `json:"max_position_size"`           // 单个 token 最大净持仓 (shares)
+	MaxEventValue             float64  `json:"max_event_value"`             // 单个事件所有 outcome 的最大名义金额 (USDC)
+	MaxOpenOrderValue         float64  `json:"max_open_order_value"`        // 挂单总名义金额上限 (USDC)
+	MaxDailyLoss              float64  `json:"max_daily_loss"`              // 单日最大亏损 (USDC)，触发后仅允许撤单
+	MaxDrawdown               float64  `json:"max_drawdown"`                // 最大回撤 (USDC)，触发后仅允许撤单
 	RestrictedMkts            []string `json:"restricted_mkts"`             // 禁止交易的市场 ID
+	RoundToTick               bool     `json:"round_to_tick"`               // 价格不在 tick 上时自动取整而非拒绝
 	AllowUnverifiedSignatures bool     `json:"allow_unverified_signatures"` // 允许未验证签名
 }
 
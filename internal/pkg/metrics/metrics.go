@@ -21,4 +21,24 @@ var (
 		Name: "polygate_risk_rejects_total",
 		Help: "Total risk engine rejections",
 	}, []string{"reason"})
+
+	NonceSyncs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "polygate_nonce_syncs_total",
+		Help: "Exchange nonce reads against the chain",
+	}, []string{"exchange", "status"})
+
+	ExchangeNonce = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "polygate_exchange_nonce",
+		Help: "Last known exchange nonce per maker",
+	}, []string{"exchange", "maker"})
+
+	BookDivergences = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "polygate_orderbook_divergences_total",
+		Help: "Shadow orderbooks found to disagree with upstream",
+	}, []string{"reason"})
+
+	BookResyncs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "polygate_orderbook_resyncs_total",
+		Help: "Orderbook resyncs from REST snapshots",
+	}, []string{"status"})
 )
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm/clause"
+)
+
+type PostgresAlgoRepo struct {
+	db *DB
+}
+
+func NewPostgresAlgoRepo(db *DB) (*PostgresAlgoRepo, error) {
+	if err := db.Client.AutoMigrate(&model.Algo{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate algo table: %w", err)
+	}
+	return &PostgresAlgoRepo{db: db}, nil
+}
+
+func (r *PostgresAlgoRepo) SaveAlgo(ctx context.Context, algo *model.Algo) error {
+	return r.db.Client.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		UpdateAll: true,
+	}).Create(algo).Error
+}
+
+func (r *PostgresAlgoRepo) LoadActiveAlgos(ctx context.Context) ([]*model.Algo, error) {
+	var algos []*model.Algo
+	err := r.db.Client.WithContext(ctx).
+		Where("status IN ? OR working IS NOT NULL", []model.AlgoStatus{model.AlgoRunning, model.AlgoPaused}).
+		Find(&algos).Error
+	return algos, err
+}
+
+// --- Redis ---
+// Live algos (and finished ones whose last child is still settling) live in one
+// hash so a restart can load them all; finished ones move to a short per-tenant
+// history list.
+
+const (
+	redisAlgoKey     = "algos"
+	redisAlgoHistory = 100
+)
+
+// redisAlgo carries the fields the model keeps out of its JSON.
+type redisAlgo struct {
+	TenantID string          `json:"tenant_id"`
+	Notional decimal.Decimal `json:"notional"`
+	*model.Algo
+}
+
+func (r *RedisClient) SaveAlgo(ctx context.Context, algo *model.Algo) error {
+	payload, err := json.Marshal(redisAlgo{TenantID: algo.TenantID, Notional: algo.Notional, Algo: algo})
+	if err != nil {
+		return err
+	}
+	if !algo.Status.Terminal() || algo.Working != nil {
+		return r.Client.HSet(ctx, redisAlgoKey, algo.ID, payload).Err()
+	}
+	historyKey := fmt.Sprintf("algos:%s:done", algo.TenantID)
+	pipe := r.Client.TxPipeline()
+	pipe.HDel(ctx, redisAlgoKey, algo.ID)
+	pipe.LPush(ctx, historyKey, payload)
+	pipe.LTrim(ctx, historyKey, 0, redisAlgoHistory-1)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisClient) LoadActiveAlgos(ctx context.Context) ([]*model.Algo, error) {
+	raws, err := r.Client.HGetAll(ctx, redisAlgoKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	algos := make([]*model.Algo, 0, len(raws))
+	for id, raw := range raws {
+		rec := redisAlgo{Algo: &model.Algo{}}
+		if err := json.Unmarshal([]byte(raw), &rec); err != nil {
+			return nil, fmt.Errorf("corrupt algo %s: %w", id, err)
+		}
+		rec.Algo.TenantID = rec.TenantID
+		rec.Algo.Notional = rec.Notional
+		algos = append(algos, rec.Algo)
+	}
+	return algos, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+	"gorm.io/gorm/clause"
+)
+
+type PostgresConditionalRepo struct {
+	db *DB
+}
+
+func NewPostgresConditionalRepo(db *DB) (*PostgresConditionalRepo, error) {
+	if err := db.Client.AutoMigrate(&model.ConditionalOrder{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate conditional order table: %w", err)
+	}
+	return &PostgresConditionalRepo{db: db}, nil
+}
+
+func (r *PostgresConditionalRepo) SaveConditionalOrder(ctx context.Context, order *model.ConditionalOrder) error {
+	return r.db.Client.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		UpdateAll: true,
+	}).Create(order).Error
+}
+
+func (r *PostgresConditionalRepo) LoadActiveConditionalOrders(ctx context.Context) ([]*model.ConditionalOrder, error) {
+	var orders []*model.ConditionalOrder
+	err := r.db.Client.WithContext(ctx).Where("status = ?", model.ConditionalActive).Find(&orders).Error
+	return orders, err
+}
+
+// --- Redis ---
+// Active triggers live in one hash so a restart can load them all; finished ones
+// move to a short per-tenant history list.
+
+const (
+	redisConditionalKey     = "conditional_orders"
+	redisConditionalHistory = 100
+)
+
+// redisConditional carries the tenant id, which the model keeps out of its JSON.
+type redisConditional struct {
+	TenantID string `json:"tenant_id"`
+	*model.ConditionalOrder
+}
+
+func (r *RedisClient) SaveConditionalOrder(ctx context.Context, order *model.ConditionalOrder) error {
+	payload, err := json.Marshal(redisConditional{TenantID: order.TenantID, ConditionalOrder: order})
+	if err != nil {
+		return err
+	}
+	if order.Status == model.ConditionalActive {
+		return r.Client.HSet(ctx, redisConditionalKey, order.ID, payload).Err()
+	}
+	historyKey := fmt.Sprintf("conditional_orders:%s:done", order.TenantID)
+	pipe := r.Client.TxPipeline()
+	pipe.HDel(ctx, redisConditionalKey, order.ID)
+	pipe.LPush(ctx, historyKey, payload)
+	pipe.LTrim(ctx, historyKey, 0, redisConditionalHistory-1)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisClient) LoadActiveConditionalOrders(ctx context.Context) ([]*model.ConditionalOrder, error) {
+	raws, err := r.Client.HGetAll(ctx, redisConditionalKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	orders := make([]*model.ConditionalOrder, 0, len(raws))
+	for id, raw := range raws {
+		rec := redisConditional{ConditionalOrder: &model.ConditionalOrder{}}
+		if err := json.Unmarshal([]byte(raw), &rec); err != nil {
+			return nil, fmt.Errorf("corrupt conditional order %s: %w", id, err)
+		}
+		rec.ConditionalOrder.TenantID = rec.TenantID
+		orders = append(orders, rec.ConditionalOrder)
+	}
+	return orders, nil
+}
@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm/clause"
+)
+
+type PostgresFillRepo struct {
+	db *DB
+}
+
+func NewPostgresFillRepo(db *DB) (*PostgresFillRepo, error) {
+	if err := db.Client.AutoMigrate(&model.Fill{}, &model.OrderUpdate{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate fill tables: %w", err)
+	}
+	return &PostgresFillRepo{db: db}, nil
+}
+
+func (r *PostgresFillRepo) SaveFill(ctx context.Context, fill *model.Fill) error {
+	return r.db.Client.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"status", "updated_at"}),
+	}).Create(fill).Error
+}
+
+func (r *PostgresFillRepo) ListFills(ctx context.Context, tenantID string, filter model.FillFilter) ([]*model.Fill, error) {
+	var fills []*model.Fill
+	tx := r.db.Client.WithContext(ctx).Where("tenant_id = ?", tenantID)
+
+	if filter.Market != "" {
+		tx = tx.Where("market = ?", filter.Market)
+	}
+	if filter.AssetID != "" {
+		tx = tx.Where("asset_id = ?", filter.AssetID)
+	}
+	if filter.OrderID != "" {
+		tx = tx.Where("order_id = ?", filter.OrderID)
+	}
+	if filter.From != nil {
+		tx = tx.Where("timestamp >= ?", filter.From)
+	}
+	if filter.To != nil {
+		tx = tx.Where("timestamp <= ?", filter.To)
+	}
+	if filter.Limit > 0 {
+		tx = tx.Limit(filter.Limit)
+	}
+
+	err := tx.Order("timestamp desc").Find(&fills).Error
+	return fills, err
+}
+
+func (r *PostgresFillRepo) SaveOrderUpdate(ctx context.Context, update *model.OrderUpdate) error {
+	return r.db.Client.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "order_id"}},
+		UpdateAll: true,
+	}).Create(update).Error
+}
+
+func (r *PostgresFillRepo) GetOrderUpdates(ctx context.Context, tenantID string, orderIDs []string) (map[string]*model.OrderUpdate, error) {
+	var updates []*model.OrderUpdate
+	err := r.db.Client.WithContext(ctx).
+		Where("tenant_id = ? AND order_id IN ?", tenantID, orderIDs).
+		Find(&updates).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]*model.OrderUpdate, len(updates))
+	for _, update := range updates {
+		out[update.OrderID] = update
+	}
+	return out, nil
+}
+
+// --- Redis ---
+// Fills are stored as a hash (fill id -> json) plus a sorted set indexed by
+// match time, so status updates overwrite in place and range queries stay cheap.
+// Past redisFillsMax the oldest are dropped and the tenant is flagged truncated,
+// so positions are not rebuilt from a partial history.
+
+const redisFillsMax = 10000
+
+func (r *RedisClient) SaveFill(ctx context.Context, fill *model.Fill) error {
+	payload, err := json.Marshal(fill)
+	if err != nil {
+		return err
+	}
+	keyData := fmt.Sprintf("fills:%s", fill.TenantID)
+	keyIdx := fmt.Sprintf("fills:%s:idx", fill.TenantID)
+
+	pipe := r.Client.TxPipeline()
+	pipe.HSet(ctx, keyData, fill.ID, payload)
+	pipe.ZAdd(ctx, keyIdx, redis.Z{Score: float64(fill.Timestamp.UnixMilli()), Member: fill.ID})
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return err
+	}
+	trimmed, err := r.trimIndexed(ctx, keyData, keyIdx, redisFillsMax)
+	if err != nil || !trimmed {
+		return err
+	}
+	return r.Client.Set(ctx, fmt.Sprintf("fills:%s:truncated", fill.TenantID), time.Now().UTC().Format(time.RFC3339), 0).Err()
+}
+
+// FillsTruncated reports whether fills of the tenant were dropped past redisFillsMax.
+func (r *RedisClient) FillsTruncated(ctx context.Context, tenantID string) (bool, error) {
+	n, err := r.Client.Exists(ctx, fmt.Sprintf("fills:%s:truncated", tenantID)).Result()
+	return n > 0, err
+}
+
+// trimIndexed drops the oldest entries of a hash + time index pair beyond max,
+// reporting whether any were dropped.
+func (r *RedisClient) trimIndexed(ctx context.Context, keyData, keyIdx string, max int64) (bool, error) {
+	overflow, err := r.Client.ZCard(ctx, keyIdx).Result()
+	if err != nil || overflow <= max {
+		return false, err
+	}
+	stale, err := r.Client.ZRange(ctx, keyIdx, 0, overflow-max-1).Result()
+	if err != nil || len(stale) == 0 {
+		return false, err
+	}
+	pipe := r.Client.TxPipeline()
+	pipe.HDel(ctx, keyData, stale...)
+	pipe.ZRem(ctx, keyIdx, toInterfaces(stale)...)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *RedisClient) ListFills(ctx context.Context, tenantID string, filter model.FillFilter) ([]*model.Fill, error) {
+	keyData := fmt.Sprintf("fills:%s", tenantID)
+	keyIdx := fmt.Sprintf("fills:%s:idx", tenantID)
+
+	rng := &redis.ZRangeBy{Min: "-inf", Max: "+inf"}
+	if filter.From != nil {
+		rng.Min = strconv.FormatInt(filter.From.UnixMilli(), 10)
+	}
+	if filter.To != nil {
+		rng.Max = strconv.FormatInt(filter.To.UnixMilli(), 10)
+	}
+	ids, err := r.Client.ZRevRangeByScore(ctx, keyIdx, rng).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return []*model.Fill{}, nil
+	}
+	raws, err := r.Client.HMGet(ctx, keyData, ids...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	fills := make([]*model.Fill, 0, len(raws))
+	for _, raw := range raws {
+		str, ok := raw.(string)
+		if !ok {
+			continue
+		}
+		var fill model.Fill
+		if err := json.Unmarshal([]byte(str), &fill); err != nil {
+			continue
+		}
+		if !filter.Match(&fill) {
+			continue
+		}
+		fills = append(fills, &fill)
+		if filter.Limit > 0 && len(fills) >= filter.Limit {
+			break
+		}
+	}
+	return fills, nil
+}
+
+// Order updates keep the last state of every order in a hash. Orders that ended
+// (cancelled or fully matched) are also indexed by time and trimmed past
+// redisOrdersMax, the same way fills are; live orders are never dropped.
+
+const redisOrdersMax = 10000
+
+func (r *RedisClient) SaveOrderUpdate(ctx context.Context, update *model.OrderUpdate) error {
+	payload, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+	keyData := fmt.Sprintf("orders:%s", update.TenantID)
+	if !orderEnded(update) {
+		return r.Client.HSet(ctx, keyData, update.OrderID, payload).Err()
+	}
+	keyIdx := fmt.Sprintf("orders:%s:done", update.TenantID)
+
+	pipe := r.Client.TxPipeline()
+	pipe.HSet(ctx, keyData, update.OrderID, payload)
+	pipe.ZAdd(ctx, keyIdx, redis.Z{Score: float64(update.Timestamp.UnixMilli()), Member: update.OrderID})
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = r.trimIndexed(ctx, keyData, keyIdx, redisOrdersMax)
+	return err
+}
+
+// orderEnded reports whether no further update can change the order.
+func orderEnded(update *model.OrderUpdate) bool {
+	if strings.EqualFold(update.Type, "CANCELLATION") {
+		return true
+	}
+	original, err := decimal.NewFromString(update.OriginalSize)
+	if err != nil || !original.IsPositive() {
+		return false
+	}
+	matched, err := decimal.NewFromString(update.SizeMatched)
+	return err == nil && matched.GreaterThanOrEqual(original)
+}
+
+func (r *RedisClient) GetOrderUpdates(ctx context.Context, tenantID string, orderIDs []string) (map[string]*model.OrderUpdate, error) {
+	out := make(map[string]*model.OrderUpdate, len(orderIDs))
+	if len(orderIDs) == 0 {
+		return out, nil
+	}
+	raws, err := r.Client.HMGet(ctx, fmt.Sprintf("orders:%s", tenantID), orderIDs...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, raw := range raws {
+		str, ok := raw.(string)
+		if !ok {
+			continue
+		}
+		var update model.OrderUpdate
+		if err := json.Unmarshal([]byte(str), &update); err != nil {
+			continue
+		}
+		out[update.OrderID] = &update
+	}
+	return out, nil
+}
+
+func toInterfaces(values []string) []interface{} {
+	out := make([]interface{}, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+	"gorm.io/gorm/clause"
+)
+
+type PostgresOrderGroupRepo struct {
+	db *DB
+}
+
+func NewPostgresOrderGroupRepo(db *DB) (*PostgresOrderGroupRepo, error) {
+	if err := db.Client.AutoMigrate(&model.OrderGroup{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate order group table: %w", err)
+	}
+	return &PostgresOrderGroupRepo{db: db}, nil
+}
+
+func (r *PostgresOrderGroupRepo) SaveOrderGroup(ctx context.Context, group *model.OrderGroup) error {
+	return r.db.Client.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		UpdateAll: true,
+	}).Create(group).Error
+}
+
+func (r *PostgresOrderGroupRepo) LoadActiveOrderGroups(ctx context.Context) ([]*model.OrderGroup, error) {
+	var groups []*model.OrderGroup
+	err := r.db.Client.WithContext(ctx).
+		Where("status IN ?", []model.OrderGroupStatus{model.OrderGroupPendingEntry, model.OrderGroupActive}).
+		Or("legs LIKE ?", `%"status":"`+string(model.GroupLegCancelPending)+`"%`).
+		Find(&groups).Error
+	return groups, err
+}
+
+// --- Redis ---
+// Working groups live in one hash so a restart can load them all; finished ones
+// move to a short per-tenant history list once no leg cancel is pending.
+
+const (
+	redisOrderGroupKey     = "order_groups"
+	redisOrderGroupHistory = 100
+)
+
+// redisOrderGroup carries the tenant id, which the model keeps out of its JSON.
+type redisOrderGroup struct {
+	TenantID string `json:"tenant_id"`
+	*model.OrderGroup
+}
+
+func (r *RedisClient) SaveOrderGroup(ctx context.Context, group *model.OrderGroup) error {
+	payload, err := json.Marshal(redisOrderGroup{TenantID: group.TenantID, OrderGroup: group})
+	if err != nil {
+		return err
+	}
+	if !group.Status.Terminal() || group.CancelPending() {
+		return r.Client.HSet(ctx, redisOrderGroupKey, group.ID, payload).Err()
+	}
+	historyKey := fmt.Sprintf("order_groups:%s:done", group.TenantID)
+	pipe := r.Client.TxPipeline()
+	pipe.HDel(ctx, redisOrderGroupKey, group.ID)
+	pipe.LPush(ctx, historyKey, payload)
+	pipe.LTrim(ctx, historyKey, 0, redisOrderGroupHistory-1)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisClient) LoadActiveOrderGroups(ctx context.Context) ([]*model.OrderGroup, error) {
+	raws, err := r.Client.HGetAll(ctx, redisOrderGroupKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]*model.OrderGroup, 0, len(raws))
+	for id, raw := range raws {
+		rec := redisOrderGroup{OrderGroup: &model.OrderGroup{}}
+		if err := json.Unmarshal([]byte(raw), &rec); err != nil {
+			return nil, fmt.Errorf("corrupt order group %s: %w", id, err)
+		}
+		rec.OrderGroup.TenantID = rec.TenantID
+		groups = append(groups, rec.OrderGroup)
+	}
+	return groups, nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PostgresOrderRepo struct {
+	db *DB
+}
+
+func NewPostgresOrderRepo(db *DB) (*PostgresOrderRepo, error) {
+	if err := db.Client.AutoMigrate(&model.TrackedOrder{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate tracked order table: %w", err)
+	}
+	return &PostgresOrderRepo{db: db}, nil
+}
+
+func (r *PostgresOrderRepo) SaveTrackedOrder(ctx context.Context, order *model.TrackedOrder) error {
+	return r.db.Client.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		UpdateAll: true,
+	}).Create(order).Error
+}
+
+func (r *PostgresOrderRepo) GetTrackedOrderByClientID(ctx context.Context, tenantID, clientOrderID string) (*model.TrackedOrder, error) {
+	return r.first(ctx, "tenant_id = ? AND client_order_id = ?", tenantID, clientOrderID)
+}
+
+func (r *PostgresOrderRepo) GetTrackedOrderByOrderID(ctx context.Context, tenantID, orderID string) (*model.TrackedOrder, error) {
+	return r.first(ctx, "tenant_id = ? AND order_id = ?", tenantID, orderID)
+}
+
+// first returns the newest order matching the condition, or nil.
+func (r *PostgresOrderRepo) first(ctx context.Context, query string, args ...interface{}) (*model.TrackedOrder, error) {
+	var order model.TrackedOrder
+	err := r.db.Client.WithContext(ctx).Where(query, args...).Order("created_at desc").First(&order).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// --- Redis ---
+// Tracked orders live in a hash (tracking id -> json) with two index hashes for
+// client order ids and exchange order ids.
+
+func (r *RedisClient) SaveTrackedOrder(ctx context.Context, order *model.TrackedOrder) error {
+	payload, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+	pipe := r.Client.TxPipeline()
+	pipe.HSet(ctx, fmt.Sprintf("tracked:%s", order.TenantID), order.ID, payload)
+	// Only a new order claims its client id, so late events for an older order
+	// that used the same id never steal the index back.
+	if order.ClientOrderID != "" && order.State == model.OrderStateNew {
+		pipe.HSet(ctx, fmt.Sprintf("tracked:%s:client", order.TenantID), order.ClientOrderID, order.ID)
+	}
+	if order.OrderID != "" {
+		pipe.HSet(ctx, fmt.Sprintf("tracked:%s:order", order.TenantID), order.OrderID, order.ID)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisClient) GetTrackedOrderByClientID(ctx context.Context, tenantID, clientOrderID string) (*model.TrackedOrder, error) {
+	return r.trackedOrderByIndex(ctx, tenantID, fmt.Sprintf("tracked:%s:client", tenantID), clientOrderID)
+}
+
+func (r *RedisClient) GetTrackedOrderByOrderID(ctx context.Context, tenantID, orderID string) (*model.TrackedOrder, error) {
+	return r.trackedOrderByIndex(ctx, tenantID, fmt.Sprintf("tracked:%s:order", tenantID), orderID)
+}
+
+func (r *RedisClient) trackedOrderByIndex(ctx context.Context, tenantID, indexKey, field string) (*model.TrackedOrder, error) {
+	id, err := r.Client.HGet(ctx, indexKey, field).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	raw, err := r.Client.HGet(ctx, fmt.Sprintf("tracked:%s", tenantID), id).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var order model.TrackedOrder
+	if err := json.Unmarshal([]byte(raw), &order); err != nil {
+		return nil, fmt.Errorf("corrupt tracked order %s: %w", id, err)
+	}
+	return &order, nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+	"gorm.io/gorm/clause"
+)
+
+type PostgresPanicRepo struct {
+	db *DB
+}
+
+func NewPostgresPanicRepo(db *DB) (*PostgresPanicRepo, error) {
+	if err := db.Client.AutoMigrate(&model.PanicState{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate panic table: %w", err)
+	}
+	return &PostgresPanicRepo{db: db}, nil
+}
+
+func (r *PostgresPanicRepo) SavePanicState(ctx context.Context, state *model.PanicState) error {
+	return r.db.Client.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "scope"}},
+		UpdateAll: true,
+	}).Create(state).Error
+}
+
+func (r *PostgresPanicRepo) LoadPanicStates(ctx context.Context) ([]*model.PanicState, error) {
+	var states []*model.PanicState
+	err := r.db.Client.WithContext(ctx).Find(&states).Error
+	return states, err
+}
+
+// --- Redis ---
+
+const redisPanicKey = "panic_states"
+
+func (r *RedisClient) SavePanicState(ctx context.Context, state *model.PanicState) error {
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return r.Client.HSet(ctx, redisPanicKey, state.Scope, payload).Err()
+}
+
+func (r *RedisClient) LoadPanicStates(ctx context.Context) ([]*model.PanicState, error) {
+	raws, err := r.Client.HGetAll(ctx, redisPanicKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	states := make([]*model.PanicState, 0, len(raws))
+	for scope, raw := range raws {
+		var st model.PanicState
+		if err := json.Unmarshal([]byte(raw), &st); err != nil {
+			return nil, fmt.Errorf("corrupt panic state %s: %w", scope, err)
+		}
+		states = append(states, &st)
+	}
+	return states, nil
+}
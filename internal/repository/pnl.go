@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+	"gorm.io/gorm/clause"
+)
+
+type PostgresPnLRepo struct {
+	db *DB
+}
+
+func NewPostgresPnLRepo(db *DB) (*PostgresPnLRepo, error) {
+	if err := db.Client.AutoMigrate(&model.PnLBaseline{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate pnl baseline table: %w", err)
+	}
+	return &PostgresPnLRepo{db: db}, nil
+}
+
+func (r *PostgresPnLRepo) SavePnLBaseline(ctx context.Context, baseline *model.PnLBaseline) error {
+	return r.db.Client.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}},
+		UpdateAll: true,
+	}).Create(baseline).Error
+}
+
+func (r *PostgresPnLRepo) LoadPnLBaselines(ctx context.Context) ([]*model.PnLBaseline, error) {
+	var baselines []*model.PnLBaseline
+	err := r.db.Client.WithContext(ctx).Find(&baselines).Error
+	return baselines, err
+}
+
+// --- Redis ---
+
+const redisPnLBaselineKey = "pnl_baselines"
+
+func (r *RedisClient) SavePnLBaseline(ctx context.Context, baseline *model.PnLBaseline) error {
+	payload, err := json.Marshal(baseline)
+	if err != nil {
+		return err
+	}
+	return r.Client.HSet(ctx, redisPnLBaselineKey, baseline.TenantID, payload).Err()
+}
+
+func (r *RedisClient) LoadPnLBaselines(ctx context.Context) ([]*model.PnLBaseline, error) {
+	raws, err := r.Client.HGetAll(ctx, redisPnLBaselineKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	baselines := make([]*model.PnLBaseline, 0, len(raws))
+	for tenantID, raw := range raws {
+		var b model.PnLBaseline
+		if err := json.Unmarshal([]byte(raw), &b); err != nil {
+			return nil, fmt.Errorf("corrupt pnl baseline %s: %w", tenantID, err)
+		}
+		baselines = append(baselines, &b)
+	}
+	return baselines, nil
+}
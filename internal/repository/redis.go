@@ -6,7 +6,9 @@ import (
 	"time"
 
 	"github.com/GoPolymarket/polygate/internal/config"
+	"github.com/GoPolymarket/polygate/internal/model"
 	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
 )
 
 type RedisClient struct {
@@ -35,10 +37,12 @@ func NewRedisClient(cfg *config.Config) (*RedisClient, error) {
 }
 
 // Implement UsageRepo interface for Redis
-func (r *RedisClient) GetDailyUsage(ctx context.Context, tenantID string) (int, float64, error) {
-	today := time.Now().Format("2006-01-02")
-	keyVol := fmt.Sprintf("usage:%s:%s:volume", tenantID, today)
-	keyCount := fmt.Sprintf("usage:%s:%s:count", tenantID, today)
+func (r *RedisClient) GetDailyUsage(ctx context.Context, tenantID string) (int, decimal.Decimal, error) {
+	day := usageDay()
+	if err := r.migrateVolume(ctx, tenantID, day); err != nil {
+		return 0, decimal.Zero, err
+	}
+	keyCount, keyVol := usageKeys(tenantID, day)
 
 	pipe := r.Client.Pipeline()
 	volCmd := pipe.Get(ctx, keyVol)
@@ -46,28 +50,30 @@ func (r *RedisClient) GetDailyUsage(ctx context.Context, tenantID string) (int,
 	_, err := pipe.Exec(ctx)
 
 	if err != nil && err != redis.Nil {
-		return 0, 0, err
+		return 0, decimal.Zero, err
 	}
 
-	vol, _ := volCmd.Float64()
+	vol, _ := volCmd.Int64()
 	count, _ := countCmd.Int()
 
-	return count, vol, nil
+	return count, model.FromMicroUSDC(vol), nil
 }
 
-func (r *RedisClient) AddDailyUsage(ctx context.Context, tenantID string, orders int, amount float64) error {
-	today := time.Now().Format("2006-01-02")
-	keyVol := fmt.Sprintf("usage:%s:%s:volume", tenantID, today)
-	keyCount := fmt.Sprintf("usage:%s:%s:count", tenantID, today)
+func (r *RedisClient) AddDailyUsage(ctx context.Context, tenantID string, orders int, amount decimal.Decimal) error {
+	day := usageDay()
+	if err := r.migrateVolume(ctx, tenantID, day); err != nil {
+		return err
+	}
+	keyCount, keyVol := usageKeys(tenantID, day)
 
 	pipe := r.Client.Pipeline()
 	// Increment
-	pipe.IncrByFloat(ctx, keyVol, amount)
+	pipe.IncrBy(ctx, keyVol, model.ToMicroUSDC(amount))
 	pipe.IncrBy(ctx, keyCount, int64(orders))
 	
 	// Set Expiry (2 days is safe)
-	pipe.Expire(ctx, keyVol, 48*time.Hour)
-	pipe.Expire(ctx, keyCount, 48*time.Hour)
+	pipe.Expire(ctx, keyVol, redisUsageTTL)
+	pipe.Expire(ctx, keyCount, redisUsageTTL)
 
 	_, err := pipe.Exec(ctx)
 	return err
@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PostgresUsageRepo struct {
+	db  *DB
+	ttl time.Duration
+}
+
+func NewPostgresUsageRepo(db *DB) (*PostgresUsageRepo, error) {
+	if err := db.Client.AutoMigrate(&model.RiskUsage{}, &model.RiskReservation{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate risk tables: %w", err)
+	}
+	return &PostgresUsageRepo{db: db, ttl: model.DefaultReservationTTL}, nil
+}
+
+func (r *PostgresUsageRepo) GetDailyUsage(ctx context.Context, tenantID string) (int, decimal.Decimal, error) {
+	var usage model.RiskUsage
+	err := r.db.Client.WithContext(ctx).
+		Where("tenant_id = ? AND day = ?", tenantID, pgUsageDay()).
+		First(&usage).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, decimal.Zero, nil
+	}
+	if err != nil {
+		return 0, decimal.Zero, err
+	}
+	return usage.Orders, usage.Volume, nil
+}
+
+func (r *PostgresUsageRepo) AddDailyUsage(ctx context.Context, tenantID string, orders int, amount decimal.Decimal) error {
+	return addUsage(r.db.Client.WithContext(ctx), tenantID, pgUsageDay(), orders, amount)
+}
+
+// ReserveUsage locks the tenant's usage row for the day, so reservations for one
+// tenant are serialized while other tenants proceed in parallel.
+func (r *PostgresUsageRepo) ReserveUsage(ctx context.Context, tenantID string, orders int, amount decimal.Decimal, limits model.UsageLimits) (*model.UsageReservation, error) {
+	day := pgUsageDay()
+	res := &model.UsageReservation{
+		ID:       uuid.New().String(),
+		TenantID: tenantID,
+		Day:      day,
+		Orders:   orders,
+		Amount:   amount,
+	}
+
+	err := r.db.Client.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		usage := model.RiskUsage{TenantID: tenantID, Day: day}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&usage).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("tenant_id = ? AND day = ?", tenantID, day).
+			First(&usage).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Where("tenant_id = ? AND day = ? AND expires_at <= ?", tenantID, day, now).
+			Delete(&model.RiskReservation{}).Error; err != nil {
+			return err
+		}
+		var pending struct {
+			Orders int
+			Amount decimal.Decimal
+		}
+		if err := tx.Model(&model.RiskReservation{}).
+			Select("COALESCE(SUM(orders), 0) AS orders, COALESCE(SUM(amount), 0) AS amount").
+			Where("tenant_id = ? AND day = ?", tenantID, day).
+			Scan(&pending).Error; err != nil {
+			return err
+		}
+
+		if err := limits.Check(usage.Orders+pending.Orders, usage.Volume.Add(pending.Amount), orders, amount); err != nil {
+			return err
+		}
+		return tx.Create(&model.RiskReservation{
+			ID:        res.ID,
+			TenantID:  tenantID,
+			Day:       day,
+			Orders:    orders,
+			Amount:    amount,
+			ExpiresAt: now.Add(r.ttl),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r *PostgresUsageRepo) CommitUsage(ctx context.Context, res *model.UsageReservation) error {
+	return r.db.Client.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.RiskReservation{}, "id = ?", res.ID).Error; err != nil {
+			return err
+		}
+		return addUsage(tx, res.TenantID, res.Day, res.Orders, res.Amount)
+	})
+}
+
+func (r *PostgresUsageRepo) ReleaseUsage(ctx context.Context, res *model.UsageReservation) error {
+	return r.db.Client.WithContext(ctx).Delete(&model.RiskReservation{}, "id = ?", res.ID).Error
+}
+
+func addUsage(tx *gorm.DB, tenantID, day string, orders int, amount decimal.Decimal) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "tenant_id"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"orders":     gorm.Expr("risk_usages.orders + ?", orders),
+			"volume":     gorm.Expr("risk_usages.volume + ?", amount),
+			"updated_at": time.Now(),
+		}),
+	}).Create(&model.RiskUsage{TenantID: tenantID, Day: day, Orders: orders, Volume: amount}).Error
+}
+
+func pgUsageDay() string {
+	return time.Now().UTC().Format("2006-01-02")
+}
+
+// --- Redis ---
+// Committed usage lives in the count/volume counters; pending reservations sit in
+// a hash (id -> "orders:amount:expiresAtMs") that the reserve script sums and prunes.
+// Volumes are integer micro-USDC so INCRBY stays exact (INCRBYFLOAT drifts).
+// Days counted before that change kept a float USDC ":volume" key; it is carried
+// over (x 1e6) the first time the day is touched.
+
+// migrateVolumeScript seeds KEYS[1] (volume_micro) from the legacy float KEYS[2] when absent.
+var migrateVolumeScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	local legacy = tonumber(redis.call('GET', KEYS[2]) or '')
+	if legacy then
+		redis.call('SET', KEYS[1], string.format('%d', math.floor(legacy * 1000000 + 0.5)), 'EX', ARGV[1])
+	end
+end
+return 1
+`)
+
+var reserveUsageScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[2]) == 0 then
+	local legacy = tonumber(redis.call('GET', KEYS[4]) or '')
+	if legacy then
+		redis.call('SET', KEYS[2], string.format('%d', math.floor(legacy * 1000000 + 0.5)), 'EX', ARGV[8])
+	end
+end
+
+local now = tonumber(ARGV[6])
+local orders = tonumber(redis.call('GET', KEYS[1]) or '0')
+local volume = tonumber(redis.call('GET', KEYS[2]) or '0')
+
+local pending = redis.call('HGETALL', KEYS[3])
+for i = 1, #pending, 2 do
+	local o, a, exp = string.match(pending[i + 1], '^([^:]+):([^:]+):([^:]+)$')
+	if exp == nil or tonumber(exp) <= now then
+		redis.call('HDEL', KEYS[3], pending[i])
+	else
+		orders = orders + tonumber(o)
+		volume = volume + tonumber(a)
+	end
+end
+
+local addOrders = tonumber(ARGV[2])
+local addAmount = tonumber(ARGV[3])
+local maxOrders = tonumber(ARGV[4])
+local maxValue = tonumber(ARGV[5])
+if maxValue > 0 and volume + addAmount > maxValue then
+	return {'daily_volume_limit', string.format('%d', orders), string.format('%d', volume)}
+end
+if maxOrders > 0 and orders + addOrders > maxOrders then
+	return {'daily_order_limit', string.format('%d', orders), string.format('%d', volume)}
+end
+
+redis.call('HSET', KEYS[3], ARGV[1], ARGV[2] .. ':' .. ARGV[3] .. ':' .. ARGV[7])
+redis.call('EXPIRE', KEYS[3], ARGV[8])
+return {'ok', string.format('%d', orders), string.format('%d', volume)}
+`)
+
+const redisUsageTTL = 48 * time.Hour
+
+func (r *RedisClient) ReserveUsage(ctx context.Context, tenantID string, orders int, amount decimal.Decimal, limits model.UsageLimits) (*model.UsageReservation, error) {
+	day := usageDay()
+	keyCount, keyVol := usageKeys(tenantID, day)
+	res := &model.UsageReservation{
+		ID:       uuid.New().String(),
+		TenantID: tenantID,
+		Day:      day,
+		Orders:   orders,
+		Amount:   amount,
+	}
+
+	now := time.Now()
+	out, err := reserveUsageScript.Run(ctx, r.Client,
+		[]string{keyCount, keyVol, usagePendingKey(tenantID, day), legacyUsageVolumeKey(tenantID, day)},
+		res.ID,
+		orders,
+		model.ToMicroUSDC(amount),
+		limits.MaxDailyOrders,
+		limits.MaxDailyValue.Shift(model.USDCDecimals).IntPart(),
+		now.UnixMilli(),
+		now.Add(model.DefaultReservationTTL).UnixMilli(),
+		int(redisUsageTTL.Seconds()),
+	).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+	if len(out) != 3 {
+		return nil, fmt.Errorf("unexpected reserve script reply: %v", out)
+	}
+	if out[0] != "ok" {
+		curOrders, _ := strconv.Atoi(out[1])
+		curVolume, _ := strconv.ParseInt(out[2], 10, 64)
+		return nil, &model.UsageLimitError{Reason: out[0], Orders: curOrders, Volume: model.FromMicroUSDC(curVolume), Amount: amount, Limits: limits}
+	}
+	return res, nil
+}
+
+// CommitUsage moves a reservation into the counters in one MULTI, so the amount
+// is never counted twice or dropped in between.
+func (r *RedisClient) CommitUsage(ctx context.Context, res *model.UsageReservation) error {
+	keyCount, keyVol := usageKeys(res.TenantID, res.Day)
+
+	pipe := r.Client.TxPipeline()
+	pipe.HDel(ctx, usagePendingKey(res.TenantID, res.Day), res.ID)
+	pipe.IncrBy(ctx, keyVol, model.ToMicroUSDC(res.Amount))
+	pipe.IncrBy(ctx, keyCount, int64(res.Orders))
+	pipe.Expire(ctx, keyVol, redisUsageTTL)
+	pipe.Expire(ctx, keyCount, redisUsageTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisClient) ReleaseUsage(ctx context.Context, res *model.UsageReservation) error {
+	return r.Client.HDel(ctx, usagePendingKey(res.TenantID, res.Day), res.ID).Err()
+}
+
+func usageDay() string {
+	return time.Now().Format("2006-01-02")
+}
+
+func usageKeys(tenantID, day string) (count, volume string) {
+	return fmt.Sprintf("usage:%s:%s:count", tenantID, day), fmt.Sprintf("usage:%s:%s:volume_micro", tenantID, day)
+}
+
+// legacyUsageVolumeKey is the float USDC volume counter used before volume_micro.
+func legacyUsageVolumeKey(tenantID, day string) string {
+	return fmt.Sprintf("usage:%s:%s:volume", tenantID, day)
+}
+
+// migrateVolume carries a legacy float volume into volume_micro for the day.
+func (r *RedisClient) migrateVolume(ctx context.Context, tenantID, day string) error {
+	_, keyVol := usageKeys(tenantID, day)
+	return migrateVolumeScript.Run(ctx, r.Client, []string{keyVol, legacyUsageVolumeKey(tenantID, day)}, int(redisUsageTTL.Seconds())).Err()
+}
+
+func usagePendingKey(tenantID, day string) string {
+	return fmt.Sprintf("usage:%s:%s:pending", tenantID, day)
+}
@@ -0,0 +1,997 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/market"
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/pkg/apperrors"
+	"github.com/GoPolymarket/polygate/internal/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	DefaultAlgoCheckInterval = 250 * time.Millisecond
+	defaultTWAPInterval      = 30 * time.Second
+	defaultVWAPInterval      = 5 * time.Second
+	algoActor                = "system:algo"
+	algoOrderTimeout         = 10 * time.Second
+	// algoSettleTimeout bounds the wait for a cancelled child's final fill report
+	algoSettleTimeout = 30 * time.Second
+	// maxAlgoFailures consecutive rejected (or uncancellable) children fail the algo
+	maxAlgoFailures = 3
+	// maxFinishedAlgos bounds the finished algos kept in memory per tenant
+	maxFinishedAlgos = 100
+)
+
+// AlgoRepo persists algos; only algos still live (or with a child still settling) are loaded on startup.
+type AlgoRepo interface {
+	SaveAlgo(ctx context.Context, algo *model.Algo) error
+	LoadActiveAlgos(ctx context.Context) ([]*model.Algo, error)
+}
+
+// AlgoService 是母单拆单执行引擎 (TWAP / VWAP / Iceberg / Pegged)：按计划切出子单，经 GatewayService.PlaceOrder
+// 下单（风控照常生效），子单成交由 user channel 回报。任一时刻每个母单最多一笔子单在途，
+// 子单在下一次切片时撤销并结算后才会下新的子单，因此不会超量成交。冰山单的子单则挂到完全成交
+// 为止，成交回报到达后立即补单；挂钩单随影子订单簿撤单重挂。
+type AlgoService struct {
+	mu      sync.Mutex
+	algos   map[string]map[string]*algoState // Key: TenantID -> ID
+	byChild map[string]*algoState            // child order id
+	repo    AlgoRepo
+	router  OrderRouter
+	market  *market.MarketService
+	fills   *market.FillStore
+	tm      *TenantManager
+	audit   AuditSink
+	now     func() time.Time
+	spawn   func(func()) // runs iceberg refills off the user stream goroutine
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup // the loop and spawned steps, so Stop can wait for in-flight orders
+	saveMu  sync.Mutex
+}
+
+type algoState struct {
+	algo        model.Algo
+	notional    decimal.Decimal // sum of price * size over settled children
+	startVolume decimal.Decimal
+	failures    int
+	child       *algoChild
+	busy        bool        // a step is in flight: the ticker and a refill must not both place a child
+	saved       algoSaveKey // what the last persisted snapshot looked like
+}
+
+// algoSaveKey captures the state changes worth persisting: the schedule is rebuilt
+// after a restart, fills on a working child are settled from its final report.
+type algoSaveKey struct {
+	status      model.AlgoStatus
+	filled      string
+	childOrders int
+	child       string
+	pulled      bool
+	lastError   string
+}
+
+func (st *algoState) saveKey() algoSaveKey {
+	key := algoSaveKey{
+		status:      st.algo.Status,
+		filled:      st.algo.Filled.String(),
+		childOrders: st.algo.ChildOrders,
+		lastError:   st.algo.LastError,
+	}
+	if st.child != nil {
+		key.child = st.child.orderID
+		key.pulled = st.child.cancelRequestedAt != nil
+	}
+	return key
+}
+
+type algoChild struct {
+	orderID           string
+	price             decimal.Decimal
+	size              decimal.Decimal
+	placedAt          time.Time
+	cancelRequestedAt *time.Time
+	fills             map[string]childFill // Key: fill id; status updates resend the same fill
+	matched           decimal.Decimal      // size_matched reported by the last order event
+	ended             bool                 // cancellation seen on the user channel
+	restored          bool                 // placed before a restart: pulled so its final report settles it
+}
+
+type childFill struct {
+	price decimal.Decimal
+	size  decimal.Decimal
+}
+
+func NewAlgoService(ctx context.Context, repo AlgoRepo, router OrderRouter, marketSvc *market.MarketService, fills *market.FillStore, tm *TenantManager, audit AuditSink) *AlgoService {
+	runCtx, cancel := context.WithCancel(context.Background())
+	s := &AlgoService{
+		algos:   make(map[string]map[string]*algoState),
+		byChild: make(map[string]*algoState),
+		repo:    repo,
+		router:  router,
+		market:  marketSvc,
+		fills:   fills,
+		tm:      tm,
+		audit:   audit,
+		now:     func() time.Time { return time.Now().UTC() },
+		spawn:   func(fn func()) { go fn() },
+		ctx:     runCtx,
+		cancel:  cancel,
+	}
+	if repo == nil {
+		return s
+	}
+	algos, err := repo.LoadActiveAlgos(ctx)
+	if err != nil {
+		logger.Error("Failed to load algos", "error", err)
+		return s
+	}
+	children := make([]*algoState, 0)
+	for _, a := range algos {
+		if st := s.restore(a); st.child != nil {
+			children = append(children, st)
+		}
+	}
+	for _, st := range children {
+		s.catchUp(ctx, st.algo.TenantID, st.child.orderID)
+	}
+	if len(algos) > 0 {
+		logger.Info("Algos restored from storage", "count", len(algos))
+	}
+	return s
+}
+
+// restore registers an algo loaded from storage. Its working child is pulled on the
+// next check: what it matched while the gateway was down is only known for sure
+// from its final report.
+func (s *AlgoService) restore(a *model.Algo) *algoState {
+	st := &algoState{algo: *a, notional: a.Notional}
+	st.algo.Working = nil
+	if w := a.Working; w != nil {
+		st.child = &algoChild{
+			orderID:           w.OrderID,
+			price:             w.Price,
+			size:              w.Size,
+			placedAt:          w.PlacedAt,
+			cancelRequestedAt: w.CancelRequestedAt,
+			fills:             make(map[string]childFill),
+			matched:           w.Filled,
+			restored:          true,
+		}
+	}
+	if a.Peg || a.Type == model.AlgoPegged {
+		s.market.Subscribe([]string{a.TokenID})
+	}
+	if a.Type == model.AlgoVWAP {
+		// The tape restarts from zero: keep counting from the volume already seen
+		volume, _ := s.tradedVolume(a.TokenID)
+		st.startVolume = volume.Sub(a.MarketVolume)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	algos, ok := s.algos[a.TenantID]
+	if !ok {
+		algos = make(map[string]*algoState)
+		s.algos[a.TenantID] = algos
+	}
+	algos[a.ID] = st
+	if st.child != nil {
+		s.byChild[st.child.orderID] = st
+	}
+	st.saved = st.saveKey()
+	return st
+}
+
+// catchUp replays fills and the last order event recorded for a child before it was indexed.
+func (s *AlgoService) catchUp(ctx context.Context, tenantID, orderID string) {
+	if s.fills == nil {
+		return
+	}
+	for _, fill := range s.fills.ListFills(ctx, tenantID, model.FillFilter{OrderID: orderID}) {
+		s.OnFill(fill)
+	}
+	if update, ok := s.fills.GetOrderUpdate(tenantID, orderID); ok {
+		s.OnOrderUpdate(update)
+	}
+}
+
+// Create validates a parent order and starts working it on the next check.
+func (s *AlgoService) Create(ctx context.Context, tenant *model.Tenant, req model.AlgoRequest) (*model.Algo, error) {
+	c := tenant.Creds
+	if c.L2ApiKey == "" || c.L2ApiSecret == "" || c.L2ApiPassphrase == "" {
+		return nil, apperrors.NewInvalidRequest("algos need the tenant's L2 credentials: child fills are tracked on its user stream")
+	}
+	interval, err := validateAlgo(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	algo := model.Algo{
+		ID:                uuid.NewString(),
+		TenantID:          tenant.ID,
+		Type:              req.Type,
+		Status:            model.AlgoRunning,
+		TokenID:           req.TokenID,
+		Side:              req.Side,
+		Size:              req.Size,
+		LimitPrice:        req.LimitPrice,
+		DurationSeconds:   req.DurationSeconds,
+		IntervalSeconds:   int(interval / time.Second),
+		ParticipationRate: req.ParticipationRate,
+		ClipSize:          req.ClipSize,
+		Peg:               req.Peg,
+		PegTo:             req.PegTo,
+		PegOffset:         req.PegOffset,
+		MinRequoteMs:      req.MinRequoteMs,
+		RequoteThreshold:  req.RequoteThreshold,
+		NextSliceAt:       now,
+		StartedAt:         now,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if req.DurationSeconds > 0 {
+		ends := now.Add(time.Duration(req.DurationSeconds) * time.Second)
+		algo.EndsAt = &ends
+	}
+	if s.market != nil {
+		if info, err := s.market.MarketInfo(ctx, req.TokenID); err == nil {
+			algo.MinChildSize = info.MinSize
+			algo.TickSize = info.TickSize
+		}
+	}
+	if algo.Type == model.AlgoIceberg && algo.MinChildSize.IsPositive() && algo.ClipSize.LessThan(algo.MinChildSize) {
+		return nil, apperrors.NewInvalidRequest(fmt.Sprintf("clip_size is below the market's minimum order size %s", algo.MinChildSize))
+	}
+	if algo.Peg || algo.Type == model.AlgoPegged {
+		s.market.Subscribe([]string{algo.TokenID})
+	}
+
+	st := &algoState{algo: algo}
+	if algo.Type == model.AlgoVWAP {
+		// Participation is measured from now on
+		st.startVolume, _ = s.tradedVolume(algo.TokenID)
+	}
+
+	s.mu.Lock()
+	running := 0
+	for _, other := range s.algos[tenant.ID] {
+		if !other.algo.Status.Terminal() {
+			running++
+		}
+	}
+	if running >= model.MaxAlgosPerTenant {
+		s.mu.Unlock()
+		return nil, apperrors.NewInvalidRequest(fmt.Sprintf("too many active algos (max %d)", model.MaxAlgosPerTenant))
+	}
+	algos, ok := s.algos[tenant.ID]
+	if !ok {
+		algos = make(map[string]*algoState)
+		s.algos[tenant.ID] = algos
+	}
+	algos[algo.ID] = st
+	snapshot := st.snapshot()
+	s.mu.Unlock()
+
+	s.persist(st)
+	return snapshot, nil
+}
+
+// List returns the tenant's algos, newest first; status "" returns all.
+func (s *AlgoService) List(tenantID string, status model.AlgoStatus) []*model.Algo {
+	s.mu.Lock()
+	out := make([]*model.Algo, 0, len(s.algos[tenantID]))
+	for _, st := range s.algos[tenantID] {
+		if status != "" && st.algo.Status != status {
+			continue
+		}
+		out = append(out, st.snapshot())
+	}
+	s.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+// Get returns one of the tenant's algos.
+func (s *AlgoService) Get(tenantID, id string) (*model.Algo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.algos[tenantID][id]
+	if !ok {
+		return nil, apperrors.New(apperrors.ErrNotFound, "algo not found", nil)
+	}
+	return st.snapshot(), nil
+}
+
+// Pause stops placing children; the working child is cancelled on the next check.
+func (s *AlgoService) Pause(tenantID, id string) (*model.Algo, error) {
+	return s.transition(tenantID, id, model.AlgoRunning, model.AlgoPaused)
+}
+
+// Resume continues a paused algo; a TWAP catches up with its schedule.
+func (s *AlgoService) Resume(tenantID, id string) (*model.Algo, error) {
+	return s.transition(tenantID, id, model.AlgoPaused, model.AlgoRunning)
+}
+
+// Cancel stops the algo for good; the working child is cancelled on the next check.
+func (s *AlgoService) Cancel(tenantID, id string) (*model.Algo, error) {
+	return s.transition(tenantID, id, "", model.AlgoCancelled)
+}
+
+// transition moves an algo from "from" ("" for any live status) to "to".
+func (s *AlgoService) transition(tenantID, id string, from, to model.AlgoStatus) (*model.Algo, error) {
+	s.mu.Lock()
+	st, ok := s.algos[tenantID][id]
+	if !ok {
+		s.mu.Unlock()
+		return nil, apperrors.New(apperrors.ErrNotFound, "algo not found", nil)
+	}
+	a := &st.algo
+	if a.Status.Terminal() || (from != "" && a.Status != from) {
+		s.mu.Unlock()
+		return nil, apperrors.NewInvalidRequest(fmt.Sprintf("algo is %s", a.Status))
+	}
+	a.Status = to
+	a.UpdatedAt = s.now()
+	if to == model.AlgoRunning {
+		a.NextSliceAt = a.UpdatedAt
+	}
+	if to == model.AlgoCancelled {
+		a.Reason = "cancelled by tenant"
+		s.pruneFinished(tenantID)
+	}
+	snapshot := st.snapshot()
+	s.mu.Unlock()
+
+	s.persist(st)
+	return snapshot, nil
+}
+
+// Start runs the periodic scheduling loop.
+func (s *AlgoService) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultAlgoCheckInterval
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+				s.CheckAll(s.ctx)
+			}
+		}
+	}()
+}
+
+// Stop halts the loop and waits for steps already placing or cancelling a child;
+// working children are left to the shutdown cancel policy.
+func (s *AlgoService) Stop() {
+	s.mu.Lock()
+	s.cancel() // under s.mu: no step is spawned once Stop has begun
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// CheckAll advances every algo that is live or still has a working child.
+func (s *AlgoService) CheckAll(ctx context.Context) {
+	s.mu.Lock()
+	states := make([]*algoState, 0)
+	for _, algos := range s.algos {
+		for _, st := range algos {
+			if !st.algo.Status.Terminal() || st.child != nil {
+				states = append(states, st)
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	for _, st := range states {
+		s.step(ctx, st)
+	}
+}
+
+// OnFill records a fill on a working child.
+func (s *AlgoService) OnFill(fill *model.Fill) {
+	if fill == nil || strings.EqualFold(fill.Status, "FAILED") {
+		return
+	}
+	price, err := decimal.NewFromString(fill.Price)
+	if err != nil {
+		return
+	}
+	size, err := decimal.NewFromString(fill.Size)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	st, ok := s.byChild[fill.OrderID]
+	if !ok || st.child == nil || st.algo.TenantID != fill.TenantID {
+		s.mu.Unlock()
+		return
+	}
+	st.child.fills[fill.ID] = childFill{price: price, size: size}
+	refill := st.refillDue() && s.ctx.Err() == nil
+	if refill {
+		s.wg.Add(1)
+	}
+	s.mu.Unlock()
+
+	if refill {
+		s.spawn(func() {
+			defer s.wg.Done()
+			s.step(s.ctx, st)
+		})
+	}
+}
+
+// OnOrderUpdate records the matched size of a working child and its cancellation.
+func (s *AlgoService) OnOrderUpdate(update *model.OrderUpdate) {
+	if update == nil {
+		return
+	}
+	s.mu.Lock()
+	st, ok := s.byChild[update.OrderID]
+	if !ok || st.child == nil || st.algo.TenantID != update.TenantID {
+		s.mu.Unlock()
+		return
+	}
+	if matched, err := decimal.NewFromString(update.SizeMatched); err == nil && matched.GreaterThan(st.child.matched) {
+		st.child.matched = matched
+	}
+	if strings.EqualFold(update.Type, market.OrderEventCancellation) {
+		st.child.ended = true
+	}
+	refill := st.refillDue() && s.ctx.Err() == nil
+	if refill {
+		s.wg.Add(1)
+	}
+	s.mu.Unlock()
+
+	if refill {
+		s.spawn(func() {
+			defer s.wg.Done()
+			s.step(s.ctx, st)
+		})
+	}
+}
+
+// refillDue reports whether a running iceberg's clip just filled; the caller holds s.mu.
+func (st *algoState) refillDue() bool {
+	if st.algo.Type != model.AlgoIceberg || st.algo.Status != model.AlgoRunning || st.child == nil {
+		return false
+	}
+	return st.child.filled().GreaterThanOrEqual(st.child.size)
+}
+
+// filled is the child's matched size as far as the user channel has reported it.
+func (c *algoChild) filled() decimal.Decimal {
+	filled := decimal.Zero
+	for _, f := range c.fills {
+		filled = filled.Add(f.size)
+	}
+	return decimal.Max(filled, c.matched)
+}
+
+// step runs one round of an algo unless another round is already in flight.
+func (s *AlgoService) step(ctx context.Context, st *algoState) {
+	if ctx.Err() != nil {
+		// Stopped: nothing may be placed after the shutdown sweep
+		return
+	}
+	s.mu.Lock()
+	if st.busy {
+		s.mu.Unlock()
+		return
+	}
+	st.busy = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		st.busy = false
+		s.mu.Unlock()
+	}()
+	s.advance(ctx, st)
+	s.persist(st)
+}
+
+// advance settles the working child, cancels it when its slice is over, and places
+// the next child once none is working. Network calls are made without s.mu.
+func (s *AlgoService) advance(ctx context.Context, st *algoState) {
+	s.mu.Lock()
+	now := s.now()
+	a := &st.algo
+	s.settle(st, now)
+
+	if child := st.child; child != nil {
+		if child.cancelRequestedAt != nil || !s.pullDue(st, now) {
+			s.mu.Unlock()
+			return
+		}
+		orderID := child.orderID
+		s.mu.Unlock()
+
+		err := s.withTenant(a.TenantID, func(tenant *model.Tenant) error {
+			orderCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), algoOrderTimeout)
+			defer cancel()
+			_, err := s.router.CancelOrder(orderCtx, tenant, model.CancelOrderInput{ID: orderID})
+			return err
+		})
+
+		s.mu.Lock()
+		if err != nil {
+			st.failures++
+			a.LastError = fmt.Sprintf("cancel child %s: %v", orderID, err)
+			logger.Warn("Failed to cancel algo child", "tenant_id", a.TenantID, "algo_id", a.ID, "order_id", orderID, "error", err)
+		}
+		if err == nil || st.failures >= maxAlgoFailures {
+			// A child that keeps refusing the cancel is most likely filled already
+			t := s.now()
+			child.cancelRequestedAt = &t
+			st.failures = 0
+		}
+		s.mu.Unlock()
+		return
+	}
+
+	if a.Status != model.AlgoRunning {
+		s.mu.Unlock()
+		return
+	}
+	remaining := a.Size.Sub(a.Filled)
+	switch {
+	case !remaining.IsPositive():
+		finished := s.finish(st, model.AlgoCompleted, "")
+		s.mu.Unlock()
+		s.record(finished)
+		return
+	case a.EndsAt != nil && !now.Before(*a.EndsAt):
+		finished := s.finish(st, model.AlgoExpired, fmt.Sprintf("%s of %s filled when the algo ran out of time", a.Filled, a.Size))
+		s.mu.Unlock()
+		s.record(finished)
+		return
+	case a.MinChildSize.IsPositive() && remaining.LessThan(a.MinChildSize):
+		finished := s.finish(st, model.AlgoCompleted, fmt.Sprintf("remaining %s is below the minimum order size %s", remaining, a.MinChildSize))
+		s.mu.Unlock()
+		s.record(finished)
+		return
+	case now.Before(a.NextSliceAt):
+		s.mu.Unlock()
+		return
+	}
+
+	qty := s.childSize(st, now)
+	a.NextSliceAt = s.nextSlice(a, now)
+	if !qty.IsPositive() || (a.MinChildSize.IsPositive() && qty.LessThan(a.MinChildSize)) {
+		// Not enough to trade yet; the shortfall rolls into a later slice
+		s.mu.Unlock()
+		return
+	}
+	price := a.LimitPrice
+	if pegged, ok := s.pegPrice(a); ok {
+		price = pegged
+	} else if a.Type == model.AlgoPegged || a.Peg {
+		// No reference to quote off: wait rather than rest at the cap
+		s.mu.Unlock()
+		return
+	}
+	req := model.OrderRequest{
+		TokenID:   a.TokenID,
+		Side:      a.Side,
+		Price:     price,
+		Size:      qty,
+		OrderType: "GTC",
+	}
+	s.mu.Unlock()
+
+	var orderID string
+	err := s.withTenant(a.TenantID, func(tenant *model.Tenant) error {
+		orderCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), algoOrderTimeout)
+		defer cancel()
+		resp, err := s.router.PlaceOrder(orderCtx, tenant, req)
+		if err == nil {
+			orderID = resp.ID
+		}
+		return err
+	})
+
+	s.mu.Lock()
+	if err != nil {
+		st.failures++
+		a.LastError = err.Error()
+		a.UpdatedAt = s.now()
+		logger.Warn("Algo child order rejected", "tenant_id", a.TenantID, "algo_id", a.ID, "failures", st.failures, "error", err)
+		var finished *model.Algo
+		if st.failures >= maxAlgoFailures {
+			finished = s.finish(st, model.AlgoFailed, fmt.Sprintf("%d child orders in a row were rejected: %v", st.failures, err))
+		}
+		s.mu.Unlock()
+		s.record(finished)
+		return
+	}
+	st.failures = 0
+	a.ChildOrders++
+	a.UpdatedAt = s.now()
+	st.child = &algoChild{
+		orderID:  orderID,
+		price:    price,
+		size:     qty,
+		placedAt: a.UpdatedAt,
+		fills:    make(map[string]childFill),
+	}
+	s.byChild[orderID] = st
+	s.mu.Unlock()
+}
+
+// settle folds a finished child into the algo's totals; the caller holds s.mu.
+// A child is finished once its fills cover it or its cancellation was reported.
+// If the report never arrives the child is counted as fully filled, so the algo
+// can only under-fill, never over-fill.
+func (s *AlgoService) settle(st *algoState, now time.Time) {
+	child := st.child
+	if child == nil {
+		return
+	}
+	filled, notional := decimal.Zero, decimal.Zero
+	for _, f := range child.fills {
+		filled = filled.Add(f.size)
+		notional = notional.Add(f.price.Mul(f.size))
+	}
+	matched := decimal.Max(filled, child.matched)
+	done := child.ended || matched.GreaterThanOrEqual(child.size)
+	timedOut := child.cancelRequestedAt != nil && now.Sub(*child.cancelRequestedAt) > algoSettleTimeout
+	if !done && !timedOut {
+		return
+	}
+
+	a := &st.algo
+	if !done {
+		matched = child.size
+		a.LastError = fmt.Sprintf("no final report for child %s after cancel; counted as filled", child.orderID)
+		logger.Warn("Algo child unsettled after cancel, counting it as filled", "tenant_id", a.TenantID, "algo_id", a.ID, "order_id", child.orderID)
+	}
+	if matched.GreaterThan(filled) {
+		// Matched size reported without its fills: value the gap at the child's price
+		notional = notional.Add(matched.Sub(filled).Mul(child.price))
+	}
+	st.notional = st.notional.Add(notional)
+	a.Filled = a.Filled.Add(matched)
+	a.UpdatedAt = now
+	delete(s.byChild, child.orderID)
+	st.child = nil
+}
+
+// childSize is the schedule's target (or the next clip) minus what is already filled; the caller holds s.mu.
+func (s *AlgoService) childSize(st *algoState, now time.Time) decimal.Decimal {
+	a := &st.algo
+	var target decimal.Decimal
+	switch a.Type {
+	case model.AlgoTWAP:
+		interval := time.Duration(a.IntervalSeconds) * time.Second
+		slices := int64((time.Duration(a.DurationSeconds)*time.Second + interval - 1) / interval)
+		elapsed := int64(now.Sub(a.StartedAt)/interval) + 1
+		if elapsed > slices {
+			elapsed = slices
+		}
+		target = a.Size.Mul(decimal.NewFromInt(elapsed)).Div(decimal.NewFromInt(slices))
+	case model.AlgoVWAP:
+		volume, ok := s.tradedVolume(a.TokenID)
+		if !ok {
+			return decimal.Zero
+		}
+		a.MarketVolume = volume.Sub(st.startVolume)
+		// Our own fills print on the tape too; participate in everyone else's volume
+		others := decimal.Max(a.MarketVolume.Sub(a.Filled), decimal.Zero)
+		rate := a.ParticipationRate
+		target = others.Mul(rate).Div(decimal.NewFromInt(1).Sub(rate))
+	case model.AlgoIceberg:
+		target = a.Filled.Add(a.ClipSize)
+	case model.AlgoPegged:
+		target = a.Size
+	}
+	qty := decimal.Min(target, a.Size).Sub(a.Filled)
+	return qty.RoundDown(2)
+}
+
+// pullDue reports whether the working child should be cancelled; the caller holds s.mu.
+// A child restored after a restart is pulled right away. Scheduled children are
+// pulled at the end of their slice; iceberg clips rest until filled, unless a pegged
+// clip no longer sits at its peg. A pegged clip or PEGGED order is requoted once its
+// target moved by requote_threshold and it rested min_requote_ms.
+func (s *AlgoService) pullDue(st *algoState, now time.Time) bool {
+	a := &st.algo
+	if a.Status != model.AlgoRunning || (a.EndsAt != nil && !now.Before(*a.EndsAt)) || st.child.restored {
+		return true
+	}
+	switch a.Type {
+	case model.AlgoIceberg, model.AlgoPegged:
+		if a.Type == model.AlgoIceberg && !a.Peg {
+			return false
+		}
+		if now.Sub(st.child.placedAt) < time.Duration(a.MinRequoteMs)*time.Millisecond {
+			return false
+		}
+		price, ok := s.pegPrice(a)
+		if !ok || price.Equal(st.child.price) {
+			// Without a trusted reference the order keeps resting where it is
+			return false
+		}
+		return price.Sub(st.child.price).Abs().GreaterThanOrEqual(a.RequoteThreshold)
+	}
+	return !now.Before(a.NextSliceAt)
+}
+
+// pegPrice is the price a pegged child should rest at, kept within limit_price:
+// the peg_to reference (by default the same-side best for a pegged iceberg) plus
+// peg_offset, rounded onto the tick away from the market. ok is false when the
+// algo is not pegged or the book has no trusted quote.
+func (s *AlgoService) pegPrice(a *model.Algo) (decimal.Decimal, bool) {
+	if s.market == nil {
+		return decimal.Zero, false
+	}
+	ref := a.PegTo
+	if a.Type == model.AlgoIceberg && a.Peg && ref == "" {
+		ref = model.PegBestBid
+		if a.Side == "SELL" {
+			ref = model.PegBestAsk
+		}
+	}
+	book := s.market.GetBook(a.TokenID)
+	if ref == "" || book == nil || !book.IsValid() {
+		// An invalid book (stream down, diverged) keeps the working child where it is
+		return decimal.Zero, false
+	}
+	var price decimal.Decimal
+	var ok bool
+	switch ref {
+	case model.PegBestBid:
+		price, ok = book.Best("BUY")
+	case model.PegBestAsk:
+		price, ok = book.Best("SELL")
+	case model.PegMid:
+		price, ok = book.Mid()
+	}
+	if !ok {
+		return decimal.Zero, false
+	}
+
+	price = price.Add(a.PegOffset)
+	tick := a.TickSize
+	if !tick.IsPositive() {
+		tick = book.Meta().TickSize
+	}
+	if tick.IsPositive() {
+		if a.Side == "BUY" {
+			price = price.Div(tick).Floor().Mul(tick)
+		} else {
+			price = price.Div(tick).Ceil().Mul(tick)
+		}
+	}
+	if a.Side == "BUY" {
+		price = decimal.Min(price, a.LimitPrice)
+	} else {
+		price = decimal.Max(price, a.LimitPrice)
+	}
+	if !price.IsPositive() || price.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+		return decimal.Zero, false
+	}
+	return price, true
+}
+
+func (s *AlgoService) nextSlice(a *model.Algo, now time.Time) time.Time {
+	interval := time.Duration(a.IntervalSeconds) * time.Second
+	next := now.Add(interval)
+	if a.Type == model.AlgoTWAP {
+		// Stay on the schedule's grid even when a check runs late
+		next = a.StartedAt.Add((now.Sub(a.StartedAt)/interval + 1) * interval)
+	}
+	if a.EndsAt != nil && next.After(*a.EndsAt) {
+		next = *a.EndsAt
+	}
+	return next
+}
+
+func (s *AlgoService) tradedVolume(tokenID string) (decimal.Decimal, bool) {
+	if s.market == nil {
+		return decimal.Zero, false
+	}
+	book := s.market.GetBook(tokenID)
+	if book == nil {
+		// Volume counts from the subscription on, which is when the algo starts
+		s.market.Subscribe([]string{tokenID})
+		if book = s.market.GetBook(tokenID); book == nil {
+			return decimal.Zero, false
+		}
+	}
+	return book.Meta().TradedVolume, true
+}
+
+// finish ends an algo and returns a snapshot to audit; the caller holds s.mu.
+func (s *AlgoService) finish(st *algoState, status model.AlgoStatus, reason string) *model.Algo {
+	st.algo.Status = status
+	st.algo.Reason = reason
+	st.algo.UpdatedAt = s.now()
+	s.pruneFinished(st.algo.TenantID)
+	return st.snapshot()
+}
+
+// persist saves the algo if it changed since it was last saved. Saves are
+// serialized so an older snapshot never overwrites a newer one.
+func (s *AlgoService) persist(st *algoState) {
+	if s.repo == nil {
+		return
+	}
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	s.mu.Lock()
+	key := st.saveKey()
+	if key == st.saved {
+		s.mu.Unlock()
+		return
+	}
+	st.saved = key
+	snapshot := st.snapshot()
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), conditionalRepoTimeout)
+	defer cancel()
+	if err := s.repo.SaveAlgo(ctx, snapshot); err != nil {
+		logger.Error("Failed to persist algo", "tenant_id", snapshot.TenantID, "algo_id", snapshot.ID, "status", snapshot.Status, "error", err)
+	}
+}
+
+func (s *AlgoService) record(a *model.Algo) {
+	if a == nil {
+		return
+	}
+	logger.Info("Algo finished", "tenant_id", a.TenantID, "algo_id", a.ID, "status", a.Status, "filled", a.Filled, "reason", a.Reason)
+	if s.audit == nil {
+		return
+	}
+	s.audit.Log(systemAuditEntry(a.TenantID, "algo_finished", map[string]interface{}{
+		"actor":        algoActor,
+		"algo_id":      a.ID,
+		"type":         a.Type,
+		"status":       a.Status,
+		"token_id":     a.TokenID,
+		"side":         a.Side,
+		"size":         a.Size.String(),
+		"filled":       a.Filled.String(),
+		"avg_price":    a.AvgPrice.String(),
+		"child_orders": a.ChildOrders,
+		"reason":       a.Reason,
+	}))
+}
+
+func (s *AlgoService) withTenant(tenantID string, fn func(tenant *model.Tenant) error) error {
+	tenant, ok := s.tm.GetTenantByID(tenantID)
+	if !ok {
+		return fmt.Errorf("tenant not found")
+	}
+	return fn(tenant)
+}
+
+// pruneFinished drops the oldest finished algos past maxFinishedAlgos; the caller holds s.mu.
+func (s *AlgoService) pruneFinished(tenantID string) {
+	finished := make([]*algoState, 0)
+	for _, st := range s.algos[tenantID] {
+		if st.algo.Status.Terminal() && st.child == nil {
+			finished = append(finished, st)
+		}
+	}
+	if len(finished) <= maxFinishedAlgos {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].algo.UpdatedAt.Before(finished[j].algo.UpdatedAt) })
+	for _, st := range finished[:len(finished)-maxFinishedAlgos] {
+		delete(s.algos[tenantID], st.algo.ID)
+	}
+}
+
+// snapshot returns a copy of the algo with its derived progress fields.
+func (st *algoState) snapshot() *model.Algo {
+	a := st.algo
+	if a.Filled.IsPositive() {
+		a.AvgPrice = st.notional.Div(a.Filled).Round(6)
+	}
+	a.Notional = st.notional
+	a.Progress, _ = a.Filled.Div(a.Size).Round(4).Float64()
+	if st.child != nil {
+		a.Working = &model.AlgoChild{
+			OrderID:           st.child.orderID,
+			Price:             st.child.price,
+			Size:              st.child.size,
+			Filled:            st.child.filled(),
+			PlacedAt:          st.child.placedAt,
+			CancelRequestedAt: st.child.cancelRequestedAt,
+		}
+	}
+	return &a
+}
+
+// validateAlgo checks a parent order, normalizing it in place, and returns its interval.
+func validateAlgo(req *model.AlgoRequest) (time.Duration, error) {
+	req.Side = strings.ToUpper(req.Side)
+	if !req.Size.IsPositive() {
+		return 0, apperrors.NewInvalidRequest("size must be positive")
+	}
+	if !req.LimitPrice.IsPositive() || req.LimitPrice.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+		return 0, apperrors.NewInvalidRequest("limit_price must be between 0 and 1")
+	}
+	if req.DurationSeconds < 0 || req.DurationSeconds > model.MaxAlgoDurationSeconds {
+		return 0, apperrors.NewInvalidRequest(fmt.Sprintf("duration_seconds must be between 1 and %d", model.MaxAlgoDurationSeconds))
+	}
+	interval := time.Duration(req.IntervalSeconds) * time.Second
+	if req.Type != model.AlgoVWAP && !req.ParticipationRate.IsZero() {
+		return 0, apperrors.NewInvalidRequest("participation_rate is only valid for VWAP")
+	}
+	if req.Type != model.AlgoIceberg && (!req.ClipSize.IsZero() || req.Peg) {
+		return 0, apperrors.NewInvalidRequest("clip_size and peg are only valid for ICEBERG")
+	}
+	pegged := req.Type == model.AlgoPegged || (req.Type == model.AlgoIceberg && req.Peg)
+	if !pegged && (req.PegTo != "" || !req.PegOffset.IsZero() || req.MinRequoteMs != 0 || !req.RequoteThreshold.IsZero()) {
+		return 0, apperrors.NewInvalidRequest("peg_to, peg_offset, min_requote_ms and requote_threshold are only valid for PEGGED and pegged ICEBERG")
+	}
+	if pegged {
+		if req.PegOffset.Abs().GreaterThanOrEqual(decimal.NewFromInt(1)) {
+			return 0, apperrors.NewInvalidRequest("peg_offset must be between -1 and 1")
+		}
+		if req.RequoteThreshold.IsNegative() || req.RequoteThreshold.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+			return 0, apperrors.NewInvalidRequest("requote_threshold must be between 0 and 1")
+		}
+		if req.MinRequoteMs < 0 || req.MinRequoteMs > model.MaxMinRequoteMs {
+			return 0, apperrors.NewInvalidRequest(fmt.Sprintf("min_requote_ms must be between 0 and %d", model.MaxMinRequoteMs))
+		}
+		if req.MinRequoteMs == 0 {
+			req.MinRequoteMs = model.DefaultMinRequoteMs
+		}
+	}
+	if (req.Type == model.AlgoIceberg || req.Type == model.AlgoPegged) && req.IntervalSeconds != 0 {
+		return 0, apperrors.NewInvalidRequest(fmt.Sprintf("interval_seconds is not used by %s", req.Type))
+	}
+
+	switch req.Type {
+	case model.AlgoTWAP:
+		if req.DurationSeconds == 0 {
+			return 0, apperrors.NewInvalidRequest("duration_seconds is required for TWAP")
+		}
+		if interval <= 0 {
+			interval = defaultTWAPInterval
+		}
+		if duration := time.Duration(req.DurationSeconds) * time.Second; interval > duration {
+			interval = duration
+		}
+	case model.AlgoVWAP:
+		if !req.ParticipationRate.IsPositive() || req.ParticipationRate.GreaterThan(decimal.NewFromFloat(model.MaxParticipationRate)) {
+			return 0, apperrors.NewInvalidRequest(fmt.Sprintf("participation_rate must be between 0 and %.1f", model.MaxParticipationRate))
+		}
+		if interval <= 0 {
+			interval = defaultVWAPInterval
+		}
+	case model.AlgoIceberg:
+		if !req.ClipSize.IsPositive() || req.ClipSize.GreaterThan(req.Size) {
+			return 0, apperrors.NewInvalidRequest("clip_size must be positive and no larger than size")
+		}
+	case model.AlgoPegged:
+		if req.PegTo == "" {
+			return 0, apperrors.NewInvalidRequest("peg_to is required for PEGGED")
+		}
+	default:
+		return 0, apperrors.NewInvalidRequest(fmt.Sprintf("unknown algo type %q", req.Type))
+	}
+	return interval, nil
+}
@@ -0,0 +1,523 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/config"
+	"github.com/GoPolymarket/polygate/internal/market"
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polymarket-go-sdk/pkg/clob/clobtypes"
+	"github.com/shopspring/decimal"
+)
+
+type algoFixture struct {
+	tm        *TenantManager
+	tenant    *model.Tenant
+	router    *fakeRouter
+	fills     *market.FillStore
+	marketSvc *market.MarketService
+	audit     *recordingAudit
+	algos     *AlgoService
+	now       time.Time
+}
+
+func newAlgoFixture() *algoFixture {
+	tm := NewTenantManager(&config.Config{}, nil)
+	tenant := &model.Tenant{ID: "t1", ApiKey: "k1", Creds: model.PolymarketCreds{L2ApiKey: "key", L2ApiSecret: "secret", L2ApiPassphrase: "pass"}}
+	tm.RegisterTenant(tenant)
+	f := &algoFixture{
+		tm:        tm,
+		tenant:    tenant,
+		router:    &fakeRouter{},
+		fills:     market.NewFillStore(100, nil),
+		marketSvc: market.NewMarketService(),
+		audit:     &recordingAudit{},
+		now:       time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	f.algos = NewAlgoService(context.Background(), nil, f.router, f.marketSvc, f.fills, tm, f.audit)
+	f.algos.now = func() time.Time { return f.now }
+	f.algos.spawn = func(fn func()) { fn() }
+	f.fills.AddListener(f.algos)
+	return f
+}
+
+func (f *algoFixture) fill(orderID, size string) {
+	f.fills.AddFill(&model.Fill{ID: "tr:" + orderID + ":" + size, TenantID: "t1", OrderID: orderID, Price: "0.50", Size: size, Status: "MATCHED"})
+}
+
+func (f *algoFixture) cancelled(orderID string) {
+	f.fills.AddOrderUpdate(&model.OrderUpdate{TenantID: "t1", OrderID: orderID, Type: market.OrderEventCancellation})
+}
+
+func TestAlgoTWAPSlicesOnSchedule(t *testing.T) {
+	ctx := context.Background()
+	f := newAlgoFixture()
+	algo, err := f.algos.Create(ctx, f.tenant, model.AlgoRequest{
+		Type:            model.AlgoTWAP,
+		TokenID:         "tok",
+		Side:            "buy",
+		Size:            decimal.NewFromInt(9),
+		LimitPrice:      decimal.RequireFromString("0.55"),
+		DurationSeconds: 60,
+		IntervalSeconds: 20,
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	f.algos.CheckAll(ctx)
+	if len(f.router.placed) != 1 || !f.router.placed[0].Size.Equal(decimal.NewFromInt(3)) || f.router.placed[0].Side != "BUY" {
+		t.Fatalf("expected a first slice of 3, got %+v", f.router.placed)
+	}
+	f.fill("0x1", "2")
+
+	// Mid-slice: nothing happens
+	f.now = f.now.Add(10 * time.Second)
+	f.algos.CheckAll(ctx)
+	if len(f.router.placed) != 1 || len(f.router.cancelled) != 0 {
+		t.Fatalf("expected no action mid-slice, placed=%d cancelled=%v", len(f.router.placed), f.router.cancelled)
+	}
+
+	// Next slice: the working child is cancelled, and only replaced once settled
+	f.now = f.now.Add(10 * time.Second)
+	f.algos.CheckAll(ctx)
+	if len(f.router.cancelled) != 1 || f.router.cancelled[0] != "0x1" {
+		t.Fatalf("expected child 0x1 to be cancelled, got %v", f.router.cancelled)
+	}
+	f.algos.CheckAll(ctx)
+	if len(f.router.placed) != 1 {
+		t.Fatalf("expected no new child before the cancel is confirmed")
+	}
+	f.cancelled("0x1")
+	f.algos.CheckAll(ctx)
+	if len(f.router.placed) != 2 || !f.router.placed[1].Size.Equal(decimal.NewFromInt(4)) {
+		t.Fatalf("expected a catch-up slice of 4 (6 due - 2 filled), got %+v", f.router.placed)
+	}
+
+	// Fully filled child settles without a cancel; the last slice completes the algo
+	f.fill("0x2", "4")
+	f.now = f.now.Add(20 * time.Second)
+	f.algos.CheckAll(ctx)
+	if len(f.router.placed) != 3 || !f.router.placed[2].Size.Equal(decimal.NewFromInt(3)) {
+		t.Fatalf("expected a final slice of 3, got %+v", f.router.placed)
+	}
+	f.fill("0x3", "3")
+	f.algos.CheckAll(ctx)
+
+	got, err := f.algos.Get("t1", algo.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.Status != model.AlgoCompleted || !got.Filled.Equal(decimal.NewFromInt(9)) || got.Progress != 1 || got.ChildOrders != 3 {
+		t.Fatalf("expected a completed algo, got %+v", got)
+	}
+	if !got.AvgPrice.Equal(decimal.RequireFromString("0.5")) {
+		t.Fatalf("expected avg price 0.5, got %s", got.AvgPrice)
+	}
+	if len(f.audit.actions) != 1 || f.audit.actions[0] != "t1:algo_finished" {
+		t.Fatalf("expected an algo_finished audit, got %v", f.audit.actions)
+	}
+}
+
+func TestAlgoVWAPFollowsVolumeAndPauses(t *testing.T) {
+	ctx := context.Background()
+	f := newAlgoFixture()
+	algo, err := f.algos.Create(ctx, f.tenant, model.AlgoRequest{
+		Type:              model.AlgoVWAP,
+		TokenID:           "tok",
+		Side:              "SELL",
+		Size:              decimal.NewFromInt(100),
+		LimitPrice:        decimal.RequireFromString("0.40"),
+		ParticipationRate: decimal.RequireFromString("0.2"),
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// No volume yet: nothing to participate in
+	f.algos.CheckAll(ctx)
+	if len(f.router.placed) != 0 {
+		t.Fatalf("expected no child without volume, got %+v", f.router.placed)
+	}
+
+	f.marketSvc.GetBook("tok").SetLastTrade(decimal.RequireFromString("0.45"), decimal.NewFromInt(40), "BUY", time.Time{})
+	f.now = f.now.Add(5 * time.Second)
+	f.algos.CheckAll(ctx)
+	if len(f.router.placed) != 1 || !f.router.placed[0].Size.Equal(decimal.NewFromInt(10)) {
+		t.Fatalf("expected a child of 10 (20%% of 50 total), got %+v", f.router.placed)
+	}
+
+	if _, err := f.algos.Pause("t1", algo.ID); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	f.algos.CheckAll(ctx)
+	if len(f.router.cancelled) != 1 {
+		t.Fatalf("expected pause to cancel the working child, got %v", f.router.cancelled)
+	}
+	f.cancelled("0x1")
+	f.now = f.now.Add(5 * time.Second)
+	f.algos.CheckAll(ctx)
+	if len(f.router.placed) != 1 {
+		t.Fatalf("expected no child while paused")
+	}
+
+	if _, err := f.algos.Resume("t1", algo.ID); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	f.algos.CheckAll(ctx)
+	if len(f.router.placed) != 2 {
+		t.Fatalf("expected resume to place a child, got %+v", f.router.placed)
+	}
+	if _, err := f.algos.Cancel("t1", algo.ID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if _, err := f.algos.Resume("t1", algo.ID); err == nil {
+		t.Fatalf("expected a cancelled algo not to resume")
+	}
+	f.algos.CheckAll(ctx)
+	if len(f.router.cancelled) != 2 || f.router.cancelled[1] != "0x2" {
+		t.Fatalf("expected cancel to pull the working child, got %v", f.router.cancelled)
+	}
+}
+
+func TestAlgoIcebergRefillsAndRepegs(t *testing.T) {
+	ctx := context.Background()
+	f := newAlgoFixture()
+	algo, err := f.algos.Create(ctx, f.tenant, model.AlgoRequest{
+		Type:       model.AlgoIceberg,
+		TokenID:    "tok",
+		Side:       "BUY",
+		Size:       decimal.NewFromInt(10),
+		LimitPrice: decimal.RequireFromString("0.55"),
+		ClipSize:   decimal.NewFromInt(4),
+		Peg:        true,
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	book := f.marketSvc.GetBook("tok")
+	book.Snapshot(
+		[]market.Level{{Price: decimal.RequireFromString("0.50"), Size: decimal.NewFromInt(100)}},
+		[]market.Level{{Price: decimal.RequireFromString("0.60"), Size: decimal.NewFromInt(100)}},
+	)
+
+	f.algos.CheckAll(ctx)
+	if len(f.router.placed) != 1 || !f.router.placed[0].Size.Equal(decimal.NewFromInt(4)) || !f.router.placed[0].Price.Equal(decimal.RequireFromString("0.50")) {
+		t.Fatalf("expected a clip of 4 at the best bid, got %+v", f.router.placed)
+	}
+
+	// A partial fill leaves the clip resting; the fill completing it refills at once
+	f.fill("0x1", "1")
+	f.algos.CheckAll(ctx)
+	if len(f.router.placed) != 1 || len(f.router.cancelled) != 0 {
+		t.Fatalf("expected the clip to keep resting, placed=%d cancelled=%v", len(f.router.placed), f.router.cancelled)
+	}
+	f.fill("0x1", "3")
+	if len(f.router.placed) != 2 || !f.router.placed[1].Size.Equal(decimal.NewFromInt(4)) {
+		t.Fatalf("expected the next clip as soon as the first filled, got %+v", f.router.placed)
+	}
+
+	// The bid moves up: the clip is pulled and re-pegged, but never above limit_price,
+	// and not before it rested min_requote_ms
+	if algo.MinRequoteMs != model.DefaultMinRequoteMs {
+		t.Fatalf("expected the default requote interval, got %d", algo.MinRequoteMs)
+	}
+	if err := book.Update("BUY", "0.58", "20"); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	f.algos.CheckAll(ctx)
+	if len(f.router.cancelled) != 0 {
+		t.Fatalf("expected no re-peg before min_requote_ms, got %v", f.router.cancelled)
+	}
+	f.now = f.now.Add(2 * time.Second)
+	f.algos.CheckAll(ctx)
+	if len(f.router.cancelled) != 1 || f.router.cancelled[0] != "0x2" {
+		t.Fatalf("expected the stale clip to be cancelled, got %v", f.router.cancelled)
+	}
+	f.cancelled("0x2")
+	f.algos.CheckAll(ctx)
+	if len(f.router.placed) != 3 || !f.router.placed[2].Price.Equal(decimal.RequireFromString("0.55")) {
+		t.Fatalf("expected a clip re-pegged at the 0.55 limit, got %+v", f.router.placed)
+	}
+	f.algos.CheckAll(ctx)
+	if len(f.router.cancelled) != 1 {
+		t.Fatalf("expected a clip at the limit to keep resting, got %v", f.router.cancelled)
+	}
+
+	f.fill("0x3", "4")
+	if len(f.router.placed) != 4 || !f.router.placed[3].Size.Equal(decimal.NewFromInt(2)) {
+		t.Fatalf("expected a last clip of 2, got %+v", f.router.placed)
+	}
+	f.fill("0x4", "2")
+	got, _ := f.algos.Get("t1", algo.ID)
+	if got.Status != model.AlgoCompleted || !got.Filled.Equal(decimal.NewFromInt(10)) {
+		t.Fatalf("expected a completed iceberg, got %+v", got)
+	}
+}
+
+func TestValidateAlgoPegFields(t *testing.T) {
+	iceberg := model.AlgoRequest{
+		Type:             model.AlgoIceberg,
+		TokenID:          "tok",
+		Side:             "BUY",
+		Size:             decimal.NewFromInt(10),
+		LimitPrice:       decimal.RequireFromString("0.55"),
+		ClipSize:         decimal.NewFromInt(4),
+		RequoteThreshold: decimal.RequireFromString("0.02"),
+	}
+	if _, err := validateAlgo(&iceberg); err == nil {
+		t.Fatalf("requote_threshold must be rejected on an iceberg that is not pegged")
+	}
+	iceberg.Peg = true
+	if _, err := validateAlgo(&iceberg); err != nil || iceberg.MinRequoteMs != model.DefaultMinRequoteMs {
+		t.Fatalf("expected a pegged iceberg to take the requote guards, got %v (min_requote_ms %d)", err, iceberg.MinRequoteMs)
+	}
+}
+
+func TestAlgoPeggedRequotesWithHysteresis(t *testing.T) {
+	ctx := context.Background()
+	f := newAlgoFixture()
+	algo, err := f.algos.Create(ctx, f.tenant, model.AlgoRequest{
+		Type:             model.AlgoPegged,
+		TokenID:          "tok",
+		Side:             "BUY",
+		Size:             decimal.NewFromInt(10),
+		LimitPrice:       decimal.RequireFromString("0.55"),
+		PegTo:            model.PegMid,
+		PegOffset:        decimal.RequireFromString("-0.01"),
+		RequoteThreshold: decimal.RequireFromString("0.02"),
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if algo.MinRequoteMs != model.DefaultMinRequoteMs {
+		t.Fatalf("expected the default requote interval, got %d", algo.MinRequoteMs)
+	}
+
+	// No trusted book yet: nothing is quoted
+	f.algos.CheckAll(ctx)
+	if len(f.router.placed) != 0 {
+		t.Fatalf("expected no quote without a book, got %+v", f.router.placed)
+	}
+	book := f.marketSvc.GetBook("tok")
+	book.SetTickSize(decimal.RequireFromString("0.01"))
+	book.Snapshot(
+		[]market.Level{{Price: decimal.RequireFromString("0.48"), Size: decimal.NewFromInt(100)}},
+		[]market.Level{{Price: decimal.RequireFromString("0.52"), Size: decimal.NewFromInt(100)}},
+	)
+	f.algos.CheckAll(ctx)
+	if len(f.router.placed) != 1 || !f.router.placed[0].Price.Equal(decimal.RequireFromString("0.49")) || !f.router.placed[0].Size.Equal(decimal.NewFromInt(10)) {
+		t.Fatalf("expected 10 quoted at mid - 0.01, got %+v", f.router.placed)
+	}
+
+	// A one-tick move stays inside the hysteresis band
+	book.Update("SELL", "0.52", "0")
+	book.Update("SELL", "0.54", "100")
+	f.now = f.now.Add(2 * time.Second)
+	f.algos.CheckAll(ctx)
+	if len(f.router.cancelled) != 0 {
+		t.Fatalf("expected no requote for a move below the threshold, got %v", f.router.cancelled)
+	}
+
+	// A larger move requotes, but only once the order rested min_requote_ms
+	f.fill("0x1", "3")
+	book.Update("BUY", "0.52", "100")
+	f.algos.CheckAll(ctx)
+	if len(f.router.cancelled) != 1 || f.router.cancelled[0] != "0x1" {
+		t.Fatalf("expected the quote to be pulled, got %v", f.router.cancelled)
+	}
+	f.cancelled("0x1")
+	f.algos.CheckAll(ctx)
+	if len(f.router.placed) != 2 || !f.router.placed[1].Price.Equal(decimal.RequireFromString("0.52")) || !f.router.placed[1].Size.Equal(decimal.NewFromInt(7)) {
+		t.Fatalf("expected the remaining 7 requoted at 0.52, got %+v", f.router.placed)
+	}
+	book.Snapshot(
+		[]market.Level{{Price: decimal.RequireFromString("0.60"), Size: decimal.NewFromInt(100)}},
+		[]market.Level{{Price: decimal.RequireFromString("0.62"), Size: decimal.NewFromInt(100)}},
+	)
+	f.now = f.now.Add(500 * time.Millisecond)
+	f.algos.CheckAll(ctx)
+	if len(f.router.cancelled) != 1 {
+		t.Fatalf("expected no requote before min_requote_ms, got %v", f.router.cancelled)
+	}
+
+	// An invalid book (stream down) never moves the quote
+	book.Invalidate("stream disconnected")
+	f.now = f.now.Add(time.Second)
+	f.algos.CheckAll(ctx)
+	if len(f.router.cancelled) != 1 {
+		t.Fatalf("expected no requote off an invalid book, got %v", f.router.cancelled)
+	}
+	book.Snapshot(
+		[]market.Level{{Price: decimal.RequireFromString("0.60"), Size: decimal.NewFromInt(100)}},
+		[]market.Level{{Price: decimal.RequireFromString("0.62"), Size: decimal.NewFromInt(100)}},
+	)
+
+	// The cap holds when the market runs away
+	f.now = f.now.Add(time.Second)
+	f.algos.CheckAll(ctx)
+	f.cancelled("0x2")
+	f.algos.CheckAll(ctx)
+	if len(f.router.placed) != 3 || !f.router.placed[2].Price.Equal(decimal.RequireFromString("0.55")) {
+		t.Fatalf("expected the quote capped at 0.55, got %+v", f.router.placed)
+	}
+}
+
+func TestAlgoValuesUnreportedFillsAtChildPrice(t *testing.T) {
+	ctx := context.Background()
+	f := newAlgoFixture()
+	algo, err := f.algos.Create(ctx, f.tenant, model.AlgoRequest{
+		Type:       model.AlgoPegged,
+		TokenID:    "tok",
+		Side:       "BUY",
+		Size:       decimal.NewFromInt(10),
+		LimitPrice: decimal.RequireFromString("0.55"),
+		PegTo:      model.PegMid,
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	book := f.marketSvc.GetBook("tok")
+	book.SetTickSize(decimal.RequireFromString("0.01"))
+	book.Snapshot(
+		[]market.Level{{Price: decimal.RequireFromString("0.48"), Size: decimal.NewFromInt(100)}},
+		[]market.Level{{Price: decimal.RequireFromString("0.52"), Size: decimal.NewFromInt(100)}},
+	)
+	f.algos.CheckAll(ctx)
+	if len(f.router.placed) != 1 || !f.router.placed[0].Price.Equal(decimal.RequireFromString("0.50")) {
+		t.Fatalf("expected a quote at mid, got %+v", f.router.placed)
+	}
+
+	// The child ends with 4 matched but no fill reports: priced where it rested, not at the cap
+	f.fills.AddOrderUpdate(&model.OrderUpdate{TenantID: "t1", OrderID: "0x1", Type: market.OrderEventCancellation, OriginalSize: "10", SizeMatched: "4"})
+	f.algos.CheckAll(ctx)
+	got, _ := f.algos.Get("t1", algo.ID)
+	if !got.Filled.Equal(decimal.NewFromInt(4)) || !got.AvgPrice.Equal(decimal.RequireFromString("0.5")) {
+		t.Fatalf("expected 4 filled at 0.50, got %s at %s", got.Filled, got.AvgPrice)
+	}
+}
+
+type memAlgoRepo struct {
+	mu    sync.Mutex
+	algos map[string]model.Algo
+}
+
+func (r *memAlgoRepo) SaveAlgo(ctx context.Context, algo *model.Algo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.algos[algo.ID] = *algo
+	return nil
+}
+
+func (r *memAlgoRepo) LoadActiveAlgos(ctx context.Context) ([]*model.Algo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*model.Algo
+	for _, a := range r.algos {
+		if !a.Status.Terminal() || a.Working != nil {
+			copied := a
+			out = append(out, &copied)
+		}
+	}
+	return out, nil
+}
+
+func TestAlgoRestoredPullsWorkingChild(t *testing.T) {
+	ctx := context.Background()
+	f := newAlgoFixture()
+	repo := &memAlgoRepo{algos: make(map[string]model.Algo)}
+	f.algos.repo = repo
+	algo, err := f.algos.Create(ctx, f.tenant, model.AlgoRequest{
+		Type:            model.AlgoTWAP,
+		TokenID:         "tok",
+		Side:            "BUY",
+		Size:            decimal.NewFromInt(9),
+		LimitPrice:      decimal.RequireFromString("0.55"),
+		DurationSeconds: 60,
+		IntervalSeconds: 20,
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	f.algos.CheckAll(ctx)
+	f.fill("0x1", "2")
+	if saved := repo.algos[algo.ID]; saved.Working == nil || saved.Working.OrderID != "0x1" {
+		t.Fatalf("expected the working child to be persisted, got %+v", saved.Working)
+	}
+
+	// Restart: the algo comes back with its child, which is pulled before anything else
+	restarted := NewAlgoService(ctx, repo, f.router, f.marketSvc, f.fills, f.tm, f.audit)
+	restarted.now = func() time.Time { return f.now }
+	restarted.spawn = func(fn func()) { fn() }
+	f.fills.AddListener(restarted)
+	restarted.CheckAll(ctx)
+	if len(f.router.cancelled) != 1 || f.router.cancelled[0] != "0x1" || len(f.router.placed) != 1 {
+		t.Fatalf("expected the restored child to be cancelled first, placed=%d cancelled=%v", len(f.router.placed), f.router.cancelled)
+	}
+	f.fills.AddOrderUpdate(&model.OrderUpdate{TenantID: "t1", OrderID: "0x1", Type: market.OrderEventCancellation, OriginalSize: "3", SizeMatched: "2"})
+	f.now = f.now.Add(20 * time.Second)
+	restarted.CheckAll(ctx)
+	got, err := restarted.Get("t1", algo.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if !got.Filled.Equal(decimal.NewFromInt(2)) || !got.AvgPrice.Equal(decimal.RequireFromString("0.5")) {
+		t.Fatalf("expected 2 filled at 0.50, got %+v", got)
+	}
+	if len(f.router.placed) != 2 || !f.router.placed[1].Size.Equal(decimal.NewFromInt(4)) {
+		t.Fatalf("expected the schedule to resume with 4 (6 due - 2 filled), got %+v", f.router.placed)
+	}
+	if saved := repo.algos[algo.ID]; !saved.Filled.Equal(decimal.NewFromInt(2)) || saved.TenantID != "t1" {
+		t.Fatalf("expected the settled child to be persisted, got %+v", saved)
+	}
+}
+
+// blockingRouter holds PlaceOrder until release is closed.
+type blockingRouter struct {
+	*fakeRouter
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (r *blockingRouter) PlaceOrder(ctx context.Context, tenant *model.Tenant, req model.OrderRequest) (*clobtypes.OrderResponse, error) {
+	close(r.entered)
+	<-r.release
+	return r.fakeRouter.PlaceOrder(ctx, tenant, req)
+}
+
+func TestAlgoStopWaitsForChildInFlight(t *testing.T) {
+	ctx := context.Background()
+	f := newAlgoFixture()
+	router := &blockingRouter{fakeRouter: f.router, entered: make(chan struct{}), release: make(chan struct{})}
+	f.algos.router = router
+	f.algos.now = func() time.Time { return time.Now().UTC() }
+	if _, err := f.algos.Create(ctx, f.tenant, model.AlgoRequest{
+		Type:            model.AlgoTWAP,
+		TokenID:         "tok",
+		Side:            "BUY",
+		Size:            decimal.NewFromInt(9),
+		LimitPrice:      decimal.RequireFromString("0.55"),
+		DurationSeconds: 60,
+		IntervalSeconds: 20,
+	}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	f.algos.Start(time.Millisecond)
+	<-router.entered
+
+	stopped := make(chan struct{})
+	go func() {
+		f.algos.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatalf("Stop returned while a child was still being placed")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(router.release)
+	<-stopped
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/pkg/apperrors"
+)
+
+// AmendOrder 改单（撤单 + 重新下单，仅托管模式）。替换单在撤单前完成风控预留，
+// 按与原单的差额计入每日额度；撤单失败则原单保持不变。
+// 返回的响应标明两条腿各自是否成功（err != nil 时也会返回已执行的部分）。
+func (s *GatewayService) AmendOrder(ctx context.Context, tenant *model.Tenant, orderID string, amend model.AmendOrderRequest) (*model.AmendOrderResponse, error) {
+	if err := s.panic.Check(tenant.ID); err != nil {
+		return nil, err
+	}
+	if amend.Price.IsZero() && amend.Size.IsZero() {
+		return nil, apperrors.NewInvalidRequest("price or size is required")
+	}
+	if s.fastSigner == nil {
+		return nil, fmt.Errorf("amending orders requires the gateway private key")
+	}
+	orig, ok := s.risk.OpenOrder(tenant.ID, orderID)
+	if !ok {
+		return nil, apperrors.New(apperrors.ErrNotFound, "order not found among the tenant's open orders", nil)
+	}
+
+	req := orig
+	req.OrderType = amend.OrderType
+	req.PostOnly = amend.PostOnly
+	req.Expiration = amend.Expiration
+	if !amend.Price.IsZero() {
+		req.Price = amend.Price
+	}
+	if !amend.Size.IsZero() {
+		req.Size = amend.Size
+	}
+	req = s.risk.NormalizeOrder(ctx, tenant, req)
+	if err := s.checkScopedHalt(ctx, tenant, req); err != nil {
+		return nil, err
+	}
+	// The replacement stays tied to the original's heartbeat session
+	if key, ok := s.sessions.keyOf(heartbeatKey(tenant.ID, ""), orderID); ok {
+		req.SessionID = strings.TrimPrefix(key, heartbeatKey(tenant.ID, ""))
+	}
+	if s.tracker != nil {
+		// The replacement keeps the strategy's client_order_id
+		if tracked, ok := s.tracker.ByOrderID(ctx, tenant.ID, orderID); ok {
+			req.ClientOrderID = tracked.ClientOrderID
+		}
+	}
+
+	// 1. Reserve the replacement before touching the original
+	reservation, err := s.risk.ReserveReplace(ctx, tenant, orderID, orig, req)
+	if err != nil {
+		return nil, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			s.risk.ReleaseOrder(context.WithoutCancel(ctx), reservation)
+		}
+	}()
+
+	// 2. Cancel leg
+	resp := &model.AmendOrderResponse{OriginalID: orderID, ClientOrderID: req.ClientOrderID}
+	if _, err := s.CancelOrder(ctx, tenant, model.CancelOrderInput{ID: orderID}); err != nil {
+		resp.Error = err.Error()
+		return resp, err
+	}
+	resp.Cancelled = true
+
+	// 3. Replace leg
+	trackID, err := s.trackOrder(ctx, tenant, req)
+	if err != nil {
+		resp.Error = err.Error()
+		return resp, err
+	}
+	s.markSubmitting(tenant.ID, trackID)
+	placed, err := s.submitOrder(ctx, tenant, req, nil, req)
+	if err != nil {
+		s.markRejected(tenant.ID, err, trackID)
+		resp.Error = err.Error()
+		return resp, err
+	}
+	s.markAccepted(tenant.ID, trackID, placed.ID)
+	s.risk.CommitOrder(context.WithoutCancel(ctx), reservation, placed.ID)
+	committed = true
+	s.placed.add(tenant.ID, placed.ID)
+	s.addSessionOrder(tenant.ID, req.SessionID, placed.ID)
+
+	resp.Placed = true
+	resp.OrderID = placed.ID
+	resp.Status = placed.Status
+	return resp, nil
+}
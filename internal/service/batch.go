@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/pkg/apperrors"
+	"github.com/GoPolymarket/polygate/internal/pkg/logger"
+	"github.com/GoPolymarket/polygate/internal/signer"
+	"github.com/GoPolymarket/polymarket-go-sdk/pkg/auth"
+	"github.com/GoPolymarket/polymarket-go-sdk/pkg/clob/clobtypes"
+	sdktypes "github.com/GoPolymarket/polymarket-go-sdk/pkg/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// clobBatchSize is the most orders the CLOB accepts in one POST /orders
+const clobBatchSize = 15
+
+// errBatchAborted is recorded on tracked orders of a batch that failed before submission
+var errBatchAborted = errors.New("batch aborted before submission")
+
+// batchNonceKey caches one exchange nonce lookup per exchange and maker within a batch.
+type batchNonceKey struct {
+	exchange common.Address
+	maker    common.Address
+}
+
+// PlaceOrders 批量下单（仅托管模式）：整批一次风控预留（总金额），
+// 使用网关私钥快速签名，经 CLOB 批量接口提交，并返回逐单结果。
+func (s *GatewayService) PlaceOrders(ctx context.Context, tenant *model.Tenant, reqs []model.OrderRequest) (*model.BatchOrderResponse, error) {
+	if err := s.panic.Check(tenant.ID); err != nil {
+		return nil, err
+	}
+	if len(reqs) == 0 {
+		return nil, apperrors.NewInvalidRequest("batch must contain at least one order")
+	}
+	if len(reqs) > model.MaxBatchOrders {
+		return nil, apperrors.NewInvalidRequest(fmt.Sprintf("batch exceeds %d orders", model.MaxBatchOrders))
+	}
+	if s.fastSigner == nil {
+		return nil, fmt.Errorf("batch orders require the gateway private key")
+	}
+	clientIDs := make(map[string]int)
+	for i, req := range reqs {
+		if req.Signature != "" || req.Signable != nil || req.L2 != nil {
+			return nil, apperrors.NewInvalidRequest(fmt.Sprintf("order %d: batch orders are signed by the gateway; signature, signable and l2 are not supported", i))
+		}
+		if req.ClientOrderID != "" {
+			if j, dup := clientIDs[req.ClientOrderID]; dup {
+				return nil, apperrors.NewInvalidRequest(fmt.Sprintf("order %d: client_order_id already used by order %d", i, j))
+			}
+			clientIDs[req.ClientOrderID] = i
+		}
+		reqs[i] = s.risk.NormalizeOrder(ctx, tenant, req)
+		if err := s.checkScopedHalt(ctx, tenant, reqs[i]); err != nil {
+			return nil, batchError(len(reqs), i, err)
+		}
+	}
+	apiKey, err := resolveAPIKey(tenant, model.OrderRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	trackIDs := make([]string, len(reqs))
+	for i, req := range reqs {
+		trackIDs[i], err = s.trackOrder(ctx, tenant, req)
+		if err != nil {
+			err = batchError(len(reqs), i, err)
+			s.markRejected(tenant.ID, err, trackIDs[:i]...)
+			return nil, err
+		}
+	}
+
+	// 1. Risk: per-order rules, cumulative exposure, one daily reservation for the batch total
+	reservation, err := s.risk.ReserveBatch(ctx, tenant, reqs)
+	if err != nil {
+		s.markRejected(tenant.ID, err, trackIDs...)
+		return nil, err
+	}
+	settled := false
+	defer func() {
+		if !settled {
+			s.risk.ReleaseOrder(context.WithoutCancel(ctx), reservation)
+			s.markRejected(tenant.ID, errBatchAborted, trackIDs...)
+		}
+	}()
+
+	// 2. Build and sign every order before anything is sent
+	gatewaySigner, err := signer.NewStaticSigner(s.fastSigner.Address().Hex(), auth.PolygonChainID)
+	if err != nil {
+		return nil, err
+	}
+	client := s.newClient(nil, nil)
+	books := make(map[string]clobtypes.OrderBookResponse)
+	nonces := make(map[batchNonceKey]*big.Int)
+	signed := make([]clobtypes.SignedOrder, len(reqs))
+	for i, req := range reqs {
+		signable, err := s.buildSignable(ctx, client, gatewaySigner, req)
+		if err != nil {
+			return nil, batchError(len(reqs), i, err)
+		}
+
+		if tenant.Risk.MaxSlippage > 0 {
+			book, ok := books[req.TokenID]
+			if !ok {
+				book, err = client.CLOB.OrderBook(ctx, &clobtypes.BookRequest{TokenID: req.TokenID})
+				if err != nil {
+					return nil, batchError(len(reqs), i, fmt.Errorf("failed to fetch order book for slippage check: %w", err))
+				}
+				books[req.TokenID] = book
+			}
+			if err := checkSlippage(book, tenant.Risk.MaxSlippage, req); err != nil {
+				return nil, batchError(len(reqs), i, err)
+			}
+		}
+
+		optOrder := toOptimizedOrder(signable.Order)
+		negRisk, exchange := s.exchangeFor(ctx, req.TokenID)
+		if s.nonceMgr != nil {
+			key := batchNonceKey{exchange: exchange, maker: signable.Order.Maker}
+			exNonce, ok := nonces[key]
+			if !ok {
+				exNonce, err = s.nonceMgr.GetExchangeNonceFor(ctx, exchange, key.maker)
+				if err != nil {
+					logger.Warn("Exchange nonce unavailable, using builder nonce", "exchange", exchange.Hex(), "maker", key.maker.Hex(), "error", err)
+					exNonce = nil
+				}
+				nonces[key] = exNonce
+			}
+			if exNonce != nil {
+				optOrder.Nonce = exNonce
+				signable.Order.Nonce = sdktypes.U256{Int: exNonce}
+			}
+		}
+
+		signature, err := s.fastSigner.SignOrderFor(optOrder, negRisk)
+		if err != nil {
+			return nil, batchError(len(reqs), i, fmt.Errorf("signing failed: %w", err))
+		}
+		signed[i] = clobtypes.SignedOrder{
+			Order:     *signable.Order,
+			Signature: signature,
+			Owner:     apiKey.Key,
+			OrderType: signable.OrderType,
+			PostOnly:  signable.PostOnly,
+		}
+	}
+
+	// 3. Submit in CLOB-sized chunks; a failed chunk only fails its own orders
+	s.markSubmitting(tenant.ID, trackIDs...)
+	execClient := s.newClient(gatewaySigner, apiKey)
+	resp := &model.BatchOrderResponse{Results: make([]model.BatchOrderResult, len(reqs))}
+	orderIDs := make([]string, len(reqs))
+	var lastErr error
+	for start := 0; start < len(signed); start += clobBatchSize {
+		end := min(start+clobBatchSize, len(signed))
+		posted, err := execClient.CLOB.PostOrders(ctx, &clobtypes.SignedOrders{Orders: signed[start:end]})
+		for i := start; i < end; i++ {
+			result := &resp.Results[i]
+			result.Index = i
+			result.ClientOrderID = reqs[i].ClientOrderID
+			if err != nil {
+				result.Error = fmt.Sprintf("polymarket api error: %v", err)
+				continue
+			}
+			k := i - start
+			if k >= len(posted) || posted[k].ID == "" {
+				result.Error = "order rejected by exchange"
+				if k < len(posted) {
+					result.Status = posted[k].Status
+				}
+				continue
+			}
+			result.OrderID = posted[k].ID
+			result.Status = posted[k].Status
+			orderIDs[i] = posted[k].ID
+		}
+		if err != nil {
+			lastErr = err
+			if strings.Contains(strings.ToLower(err.Error()), "nonce") && s.nonceMgr != nil {
+				logger.Warn("Detected nonce error, triggering re-sync", "error", err)
+				for key := range nonces {
+					_, _ = s.nonceMgr.SyncExchangeNonce(ctx, key.maker)
+				}
+			}
+		}
+	}
+
+	// The accepted orders are live upstream; count them even if the caller has gone away.
+	s.risk.CommitBatch(context.WithoutCancel(ctx), reservation, orderIDs)
+	settled = true
+	for i, orderID := range orderIDs {
+		if orderID != "" {
+			s.markAccepted(tenant.ID, trackIDs[i], orderID)
+		} else {
+			s.markRejected(tenant.ID, errors.New(resp.Results[i].Error), trackIDs[i])
+		}
+	}
+	for _, result := range resp.Results {
+		if result.OrderID != "" {
+			resp.Placed++
+			s.placed.add(tenant.ID, result.OrderID)
+			s.addSessionOrder(tenant.ID, reqs[result.Index].SessionID, result.OrderID)
+		} else {
+			resp.Failed++
+		}
+	}
+	if resp.Placed == 0 && lastErr != nil {
+		return nil, fmt.Errorf("polymarket api error: %w", lastErr)
+	}
+	return resp, nil
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/pkg/apperrors"
+	"github.com/GoPolymarket/polymarket-go-sdk/pkg/clob/clobtypes"
+)
+
+// CancelOrders 按 market / token / side 批量撤单。
+// 不带 side 时使用 CLOB 的 cancel-market-orders；side 不是 CLOB 支持的条件，
+// 此时先查询挂单再按订单号撤单（只能识别 user channel 上有明细的订单）。
+// 两种情况都需先列出匹配的挂单：列不出来就不撤，否则本地记录无从更新。
+func (s *GatewayService) CancelOrders(ctx context.Context, tenant *model.Tenant, filter model.OrderFilter) (*model.CancelOrdersResponse, error) {
+	if filter.IsEmpty() {
+		return nil, apperrors.NewInvalidRequest("market, token_id or side is required for a filtered cancel")
+	}
+	client, err := s.tm.GetClientForTenant(tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	// Matching open orders, for the response and local bookkeeping
+	open, err := s.ListOrders(ctx, tenant, filter)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(open))
+	for _, order := range open {
+		ids = append(ids, order.ID)
+	}
+
+	resp := &model.CancelOrdersResponse{}
+	if filter.Side == "" {
+		cancelled, err := client.CLOB.CancelMarketOrders(ctx, &clobtypes.CancelMarketOrdersRequest{
+			Market:  filter.Market,
+			AssetID: filter.AssetID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to cancel orders: %w", err)
+		}
+		resp.Status = cancelled.Status
+	} else if len(ids) > 0 {
+		cancelled, err := client.CLOB.CancelOrders(ctx, &clobtypes.CancelOrdersRequest{OrderIDs: ids})
+		if err != nil {
+			return nil, fmt.Errorf("failed to cancel orders: %w", err)
+		}
+		resp.Status = cancelled.Status
+	}
+
+	for _, id := range ids {
+		s.orderCancelled(ctx, tenant.ID, id)
+	}
+	resp.Cancelled = ids
+	resp.Count = len(ids)
+	return resp, nil
+}
+
+// ActivateScopedPanic halts the tenant's orders matching filter and cancels them,
+// leaving the rest of the tenant's book untouched.
+func (s *GatewayService) ActivateScopedPanic(ctx context.Context, tenant *model.Tenant, filter model.OrderFilter, actor, reason string) (*model.PanicState, *model.CancelOrdersResponse, error) {
+	state := s.panic.ActivateScoped(ctx, tenant.ID, filter, actor, reason)
+	resp, err := s.CancelOrders(ctx, tenant, filter)
+	return state, resp, err
+}
+
+// ResumeScoped lifts a scoped halt.
+func (s *GatewayService) ResumeScoped(ctx context.Context, tenantID string, filter model.OrderFilter, actor, reason string) (*model.PanicState, error) {
+	return s.panic.ResumeScoped(ctx, tenantID, filter, actor, reason)
+}
+
+func (s *GatewayService) ScopedPanicStates(tenantID string) []*model.PanicState {
+	return s.panic.Scoped(tenantID)
+}
+
+// checkScopedHalt rejects orders falling under one of the tenant's scoped halts.
+func (s *GatewayService) checkScopedHalt(ctx context.Context, tenant *model.Tenant, req model.OrderRequest) error {
+	return s.panic.CheckScoped(tenant.ID, req.TokenID, req.Side, func() string {
+		if s.market == nil {
+			return ""
+		}
+		info, err := s.market.MarketInfo(ctx, req.TokenID)
+		if err != nil {
+			return ""
+		}
+		return info.Market
+	})
+}
+
+// CancelSessionOrders cancels the orders placed with the given heartbeat session.
+func (s *GatewayService) CancelSessionOrders(ctx context.Context, tenant *model.Tenant, sessionID string) (*model.CancelOrdersResponse, error) {
+	key := heartbeatKey(tenant.ID, sessionID)
+	ids := s.sessions.list(key)
+	resp := &model.CancelOrdersResponse{Cancelled: ids, Count: len(ids)}
+	if len(ids) == 0 {
+		return resp, nil
+	}
+	client, err := s.tm.GetClientForTenant(tenant)
+	if err != nil {
+		return nil, err
+	}
+	cancelled, err := client.CLOB.CancelOrders(ctx, &clobtypes.CancelOrdersRequest{OrderIDs: ids})
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel session orders: %w", err)
+	}
+	resp.Status = cancelled.Status
+	for _, id := range ids {
+		s.orderCancelled(ctx, tenant.ID, id)
+	}
+	s.sessions.clear(key)
+	return resp, nil
+}
+
+// addSessionOrder ties an order to a heartbeat session.
+func (s *GatewayService) addSessionOrder(tenantID, sessionID, orderID string) {
+	if sessionID == "" {
+		return
+	}
+	s.sessions.add(heartbeatKey(tenantID, sessionID), orderID)
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+)
+
+func TestCancelOrdersFailsWhenOrdersCannotBeListed(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	clob := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		if r.Method == http.MethodGet {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer clob.Close()
+
+	gw, tenant := newCLOBGateway(t, clob.URL)
+	gw.placed.add("t1", "0xa")
+
+	if _, err := gw.CancelOrders(context.Background(), tenant, model.OrderFilter{Market: "0xm"}); err == nil {
+		t.Fatalf("expected the cancel to fail when the matching orders cannot be listed")
+	}
+	for _, call := range calls {
+		if call[0] == 'D' {
+			t.Fatalf("nothing may be cancelled without the list, got %v", calls)
+		}
+	}
+	if ids := gw.placed.list("t1"); len(ids) != 1 {
+		t.Fatalf("local bookkeeping must be untouched, got %v", ids)
+	}
+}
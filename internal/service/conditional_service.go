@@ -0,0 +1,527 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/market"
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/pkg/apperrors"
+	"github.com/GoPolymarket/polygate/internal/pkg/logger"
+	"github.com/GoPolymarket/polymarket-go-sdk/pkg/clob/clobtypes"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	DefaultConditionalCheckInterval = 250 * time.Millisecond
+	conditionalActor                = "system:trigger_engine"
+	conditionalPlaceTimeout         = 10 * time.Second
+	conditionalRepoTimeout          = 3 * time.Second
+	// maxFinishedConditional bounds the triggered / cancelled orders kept in memory per tenant
+	maxFinishedConditional = 100
+)
+
+// ConditionalRepo persists conditional orders; only active ones are loaded on startup.
+type ConditionalRepo interface {
+	SaveConditionalOrder(ctx context.Context, order *model.ConditionalOrder) error
+	LoadActiveConditionalOrders(ctx context.Context) ([]*model.ConditionalOrder, error)
+}
+
+// OrderPlacer submits the order of a fired trigger through the regular (risk-checked) path.
+type OrderPlacer interface {
+	PlaceOrder(ctx context.Context, tenant *model.Tenant, req model.OrderRequest) (*clobtypes.OrderResponse, error)
+}
+
+// FireListener is told about a trigger that fired before its order is placed.
+// Returning an error vetoes the order and cancels the trigger with that reason.
+type FireListener interface {
+	BeforeFire(ctx context.Context, order *model.ConditionalOrder) error
+}
+
+// ConditionalService 是网关侧的条件单触发引擎：按租户保管止损 / 止盈 / 追踪止损单，
+// 轮询 MarketService 的盘口或最新成交价，触发后经 GatewayService.PlaceOrder 下真实订单。
+type ConditionalService struct {
+	mu     sync.Mutex
+	orders map[string]map[string]*model.ConditionalOrder // Key: TenantID -> ID
+	// creating holds the slots of orders still being persisted: TenantID -> ID -> client_order_id
+	creating map[string]map[string]string
+	repo     ConditionalRepo
+	placer   OrderPlacer
+	market   *market.MarketService
+	tm       *TenantManager
+	audit    AuditSink
+	now      func() time.Time
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup // the check loop, including the orders it is placing
+
+	listeners []FireListener
+}
+
+// NewConditionalService restores active triggers so a restart never drops a stop.
+func NewConditionalService(ctx context.Context, repo ConditionalRepo, placer OrderPlacer, marketSvc *market.MarketService, tm *TenantManager, audit AuditSink) *ConditionalService {
+	loopCtx, cancel := context.WithCancel(context.Background())
+	s := &ConditionalService{
+		orders:   make(map[string]map[string]*model.ConditionalOrder),
+		creating: make(map[string]map[string]string),
+		repo:     repo,
+		placer:   placer,
+		market:   marketSvc,
+		tm:       tm,
+		audit:    audit,
+		now:      func() time.Time { return time.Now().UTC() },
+		ctx:      loopCtx,
+		cancel:   cancel,
+	}
+	if repo == nil {
+		return s
+	}
+	orders, err := repo.LoadActiveConditionalOrders(ctx)
+	if err != nil {
+		logger.Error("Failed to load conditional orders", "error", err)
+		return s
+	}
+	for _, o := range orders {
+		s.tenant(o.TenantID)[o.ID] = o
+		s.watch(o.TokenID)
+	}
+	if len(orders) > 0 {
+		logger.Info("Conditional orders restored from storage", "count", len(orders))
+	}
+	return s
+}
+
+// AddFireListener registers l for all subsequent triggers; call it before Start.
+func (s *ConditionalService) AddFireListener(l FireListener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, l)
+}
+
+// Create validates and stores a new trigger for the tenant.
+func (s *ConditionalService) Create(ctx context.Context, tenant *model.Tenant, req model.ConditionalOrderRequest) (*model.ConditionalOrder, error) {
+	if err := validateConditional(&req); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	order := &model.ConditionalOrder{
+		ID:            uuid.NewString(),
+		TenantID:      tenant.ID,
+		Type:          req.Type,
+		TriggerSource: req.TriggerSource,
+		TriggerPrice:  req.TriggerPrice,
+		TrailAmount:   req.TrailAmount,
+		TokenID:       req.Order.TokenID,
+		Side:          req.Order.Side,
+		Price:         req.Order.Price,
+		Size:          req.Order.Size,
+		OrderType:     req.Order.OrderType,
+		Expiration:    req.Order.Expiration,
+		ClientOrderID: req.Order.ClientOrderID,
+		SessionID:     req.Order.SessionID,
+		Status:        model.ConditionalActive,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	// The slot is taken before the lock is released for the save, so concurrent
+	// creates cannot both pass the cap or reuse a client_order_id
+	s.mu.Lock()
+	active := len(s.creating[tenant.ID])
+	for _, o := range s.tenant(tenant.ID) {
+		if o.Status != model.ConditionalActive {
+			continue
+		}
+		active++
+		if order.ClientOrderID != "" && o.ClientOrderID == order.ClientOrderID {
+			s.mu.Unlock()
+			return nil, conditionalClientIDInUse(order.ClientOrderID)
+		}
+	}
+	for _, clientOrderID := range s.creating[tenant.ID] {
+		if order.ClientOrderID != "" && clientOrderID == order.ClientOrderID {
+			s.mu.Unlock()
+			return nil, conditionalClientIDInUse(order.ClientOrderID)
+		}
+	}
+	if active >= model.MaxConditionalOrdersPerTenant {
+		s.mu.Unlock()
+		return nil, apperrors.NewInvalidRequest(fmt.Sprintf("too many active conditional orders (max %d)", model.MaxConditionalOrdersPerTenant))
+	}
+	if s.creating[tenant.ID] == nil {
+		s.creating[tenant.ID] = make(map[string]string)
+	}
+	s.creating[tenant.ID][order.ID] = order.ClientOrderID
+	s.mu.Unlock()
+
+	// A trigger that is not persisted would silently vanish on restart
+	var err error
+	if s.repo != nil {
+		err = s.repo.SaveConditionalOrder(ctx, order)
+	}
+
+	s.mu.Lock()
+	delete(s.creating[tenant.ID], order.ID)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, apperrors.New(apperrors.ErrInternal, "failed to persist conditional order", err)
+	}
+	s.tenant(tenant.ID)[order.ID] = order
+	snapshot := *order
+	s.mu.Unlock()
+
+	s.watch(order.TokenID)
+	return &snapshot, nil
+}
+
+func conditionalClientIDInUse(clientOrderID string) error {
+	return apperrors.NewInvalidRequest(fmt.Sprintf("client_order_id is already used by an active conditional order: %s", clientOrderID))
+}
+
+// List returns the tenant's conditional orders, newest first; status "" returns all.
+func (s *ConditionalService) List(tenantID string, status model.ConditionalStatus) []*model.ConditionalOrder {
+	s.mu.Lock()
+	out := make([]*model.ConditionalOrder, 0, len(s.orders[tenantID]))
+	for _, o := range s.orders[tenantID] {
+		if status != "" && o.Status != status {
+			continue
+		}
+		copied := *o
+		out = append(out, &copied)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+// Get returns one of the tenant's conditional orders.
+func (s *ConditionalService) Get(tenantID, id string) (*model.ConditionalOrder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.orders[tenantID][id]
+	if !ok {
+		return nil, apperrors.New(apperrors.ErrNotFound, "conditional order not found", nil)
+	}
+	copied := *o
+	return &copied, nil
+}
+
+// Cancel stops an active trigger; triggered orders are cancelled via /v1/orders instead.
+func (s *ConditionalService) Cancel(ctx context.Context, tenantID, id string) (*model.ConditionalOrder, error) {
+	s.mu.Lock()
+	o, ok := s.orders[tenantID][id]
+	if !ok {
+		s.mu.Unlock()
+		return nil, apperrors.New(apperrors.ErrNotFound, "conditional order not found", nil)
+	}
+	if o.Status != model.ConditionalActive {
+		s.mu.Unlock()
+		return nil, apperrors.NewInvalidRequest(fmt.Sprintf("conditional order is already %s", o.Status))
+	}
+	o.Status = model.ConditionalCancelled
+	o.UpdatedAt = s.now()
+	snapshot := *o
+	s.pruneFinished(tenantID)
+	s.mu.Unlock()
+
+	s.persist(&snapshot)
+	return &snapshot, nil
+}
+
+// Resize changes the size an active trigger places when it fires.
+func (s *ConditionalService) Resize(tenantID, id string, size decimal.Decimal) (*model.ConditionalOrder, error) {
+	if !size.IsPositive() {
+		return nil, apperrors.NewInvalidRequest("size must be positive")
+	}
+	s.mu.Lock()
+	o, ok := s.orders[tenantID][id]
+	if !ok {
+		s.mu.Unlock()
+		return nil, apperrors.New(apperrors.ErrNotFound, "conditional order not found", nil)
+	}
+	if o.Status != model.ConditionalActive {
+		s.mu.Unlock()
+		return nil, apperrors.NewInvalidRequest(fmt.Sprintf("conditional order is already %s", o.Status))
+	}
+	o.Size = size
+	o.UpdatedAt = s.now()
+	snapshot := *o
+	s.mu.Unlock()
+
+	s.persist(&snapshot)
+	return &snapshot, nil
+}
+
+// Start runs the periodic trigger check.
+func (s *ConditionalService) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultConditionalCheckInterval
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+				s.CheckAll(s.ctx)
+			}
+		}
+	}()
+}
+
+// Stop halts the trigger loop and waits for triggers already firing to finish
+// placing their orders, so nothing is placed after Stop returns.
+func (s *ConditionalService) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// CheckAll feeds current prices to every active trigger and places the orders of
+// those that fired. A fired trigger is marked TRIGGERED before its order is sent,
+// so it never fires twice and can no longer be cancelled.
+func (s *ConditionalService) CheckAll(ctx context.Context) {
+	var moved, fired []*model.ConditionalOrder
+	if ctx.Err() != nil {
+		return
+	}
+
+	s.mu.Lock()
+	now := s.now()
+	for _, orders := range s.orders {
+		for _, o := range orders {
+			if o.Status != model.ConditionalActive {
+				continue
+			}
+			price, ok := s.price(o)
+			if !ok {
+				continue
+			}
+			fire, trailed := o.Observe(price)
+			switch {
+			case fire:
+				o.Status = model.ConditionalTriggered
+				o.FiredPrice = price
+				o.TriggeredAt = &now
+				o.UpdatedAt = now
+				copied := *o
+				fired = append(fired, &copied)
+			case trailed:
+				o.UpdatedAt = now
+				copied := *o
+				moved = append(moved, &copied)
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	for _, o := range moved {
+		s.persist(o)
+	}
+	for _, o := range fired {
+		s.fire(ctx, o)
+	}
+}
+
+// fire places the order of a triggered conditional order and records the outcome.
+func (s *ConditionalService) fire(ctx context.Context, o *model.ConditionalOrder) {
+	s.persist(o)
+
+	s.mu.Lock()
+	listeners := s.listeners
+	s.mu.Unlock()
+	for _, l := range listeners {
+		if err := l.BeforeFire(ctx, o); err != nil {
+			s.vetoed(o, err)
+			return
+		}
+	}
+
+	var err error
+	var resp *clobtypes.OrderResponse
+	tenant, ok := s.tm.GetTenantByID(o.TenantID)
+	if !ok {
+		err = fmt.Errorf("tenant not found")
+	} else {
+		// Stopping the engine must not abandon an order already on its way out
+		placeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), conditionalPlaceTimeout)
+		resp, err = s.placer.PlaceOrder(placeCtx, tenant, o.OrderRequest())
+		cancel()
+	}
+
+	s.mu.Lock()
+	current, ok := s.orders[o.TenantID][o.ID]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	if err != nil {
+		current.Status = model.ConditionalFailed
+		current.Reason = err.Error()
+	} else {
+		current.OrderID = resp.ID
+	}
+	current.UpdatedAt = s.now()
+	snapshot := *current
+	s.pruneFinished(o.TenantID)
+	s.mu.Unlock()
+
+	s.persist(&snapshot)
+
+	action := "conditional_triggered"
+	if err != nil {
+		action = "conditional_failed"
+		logger.Error("Conditional order fired but the order was rejected", "tenant_id", o.TenantID, "id", o.ID, "error", err)
+	} else {
+		logger.Info("Conditional order fired", "tenant_id", o.TenantID, "id", o.ID, "type", o.Type, "order_id", snapshot.OrderID)
+	}
+	if s.audit != nil {
+		fields := map[string]interface{}{
+			"actor":          conditionalActor,
+			"conditional_id": o.ID,
+			"type":           o.Type,
+			"token_id":       o.TokenID,
+			"side":           o.Side,
+			"trigger_price":  o.TriggerPrice.String(),
+			"fired_price":    o.FiredPrice.String(),
+			"price":          o.Price.String(),
+			"size":           o.Size.String(),
+		}
+		if err != nil {
+			fields["error"] = err.Error()
+		} else {
+			fields["order_id"] = snapshot.OrderID
+		}
+		s.audit.Log(systemAuditEntry(o.TenantID, action, fields))
+	}
+}
+
+// vetoed cancels a fired trigger whose order a FireListener refused.
+func (s *ConditionalService) vetoed(o *model.ConditionalOrder, reason error) {
+	s.mu.Lock()
+	current, ok := s.orders[o.TenantID][o.ID]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	current.Status = model.ConditionalCancelled
+	current.Reason = reason.Error()
+	current.UpdatedAt = s.now()
+	snapshot := *current
+	s.pruneFinished(o.TenantID)
+	s.mu.Unlock()
+
+	s.persist(&snapshot)
+	logger.Info("Conditional order fired but was vetoed", "tenant_id", o.TenantID, "id", o.ID, "reason", snapshot.Reason)
+}
+
+// price reads the trigger's market price; ok is false while no price is known,
+// or while the book is invalid (stream down, diverged): a stale price must not fire a trigger.
+func (s *ConditionalService) price(o *model.ConditionalOrder) (decimal.Decimal, bool) {
+	if s.market == nil {
+		return decimal.Zero, false
+	}
+	book := s.market.GetBook(o.TokenID)
+	if book == nil {
+		s.market.Subscribe([]string{o.TokenID})
+		return decimal.Zero, false
+	}
+	if !book.IsValid() {
+		return decimal.Zero, false
+	}
+	if o.TriggerSource == model.TriggerSourceMid {
+		return book.Mid()
+	}
+	last := book.Meta().LastTradePrice
+	return last, last.IsPositive()
+}
+
+func (s *ConditionalService) watch(tokenID string) {
+	if s.market != nil {
+		s.market.Subscribe([]string{tokenID})
+	}
+}
+
+// pruneFinished drops the oldest finished orders past maxFinishedConditional; the caller holds s.mu.
+func (s *ConditionalService) pruneFinished(tenantID string) {
+	finished := make([]*model.ConditionalOrder, 0)
+	for _, o := range s.orders[tenantID] {
+		if o.Status != model.ConditionalActive {
+			finished = append(finished, o)
+		}
+	}
+	if len(finished) <= maxFinishedConditional {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].UpdatedAt.Before(finished[j].UpdatedAt) })
+	for _, o := range finished[:len(finished)-maxFinishedConditional] {
+		delete(s.orders[tenantID], o.ID)
+	}
+}
+
+// tenant returns the tenant's order map; the caller holds s.mu (or owns s).
+func (s *ConditionalService) tenant(tenantID string) map[string]*model.ConditionalOrder {
+	orders, ok := s.orders[tenantID]
+	if !ok {
+		orders = make(map[string]*model.ConditionalOrder)
+		s.orders[tenantID] = orders
+	}
+	return orders
+}
+
+func (s *ConditionalService) persist(order *model.ConditionalOrder) {
+	if s.repo == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), conditionalRepoTimeout)
+	defer cancel()
+	if err := s.repo.SaveConditionalOrder(ctx, order); err != nil {
+		logger.Error("Failed to persist conditional order", "tenant_id", order.TenantID, "id", order.ID, "status", order.Status, "error", err)
+	}
+}
+
+// validateConditional checks a trigger definition and normalizes it in place.
+func validateConditional(req *model.ConditionalOrderRequest) error {
+	order := &req.Order
+	if order.Signature != "" || order.Signable != nil || order.L2 != nil {
+		return apperrors.NewInvalidRequest("conditional orders are signed by the gateway when they fire; signature, signable and l2 are not accepted")
+	}
+	order.Side = strings.ToUpper(order.Side)
+	if !order.Price.IsPositive() || !order.Size.IsPositive() {
+		return apperrors.NewInvalidRequest("order price and size must be positive")
+	}
+	if req.TriggerSource == "" {
+		req.TriggerSource = model.TriggerSourceLastTrade
+	}
+
+	one := decimal.NewFromInt(1)
+	switch req.Type {
+	case model.ConditionalStopLoss, model.ConditionalTakeProfit:
+		if !req.TriggerPrice.IsPositive() || req.TriggerPrice.GreaterThanOrEqual(one) {
+			return apperrors.NewInvalidRequest("trigger_price must be between 0 and 1")
+		}
+		if !req.TrailAmount.IsZero() {
+			return apperrors.NewInvalidRequest("trail_amount is only valid for TRAILING_STOP")
+		}
+	case model.ConditionalTrailingStop:
+		if !req.TrailAmount.IsPositive() || req.TrailAmount.GreaterThanOrEqual(one) {
+			return apperrors.NewInvalidRequest("trail_amount must be between 0 and 1")
+		}
+		if !req.TriggerPrice.IsZero() {
+			return apperrors.NewInvalidRequest("trigger_price is derived from trail_amount for TRAILING_STOP")
+		}
+	default:
+		return apperrors.NewInvalidRequest(fmt.Sprintf("unknown conditional order type %q", req.Type))
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/config"
+	"github.com/GoPolymarket/polygate/internal/market"
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polymarket-go-sdk/pkg/clob/clobtypes"
+	"github.com/shopspring/decimal"
+)
+
+type recordingPlacer struct {
+	placed []model.OrderRequest
+	err    error
+}
+
+func (p *recordingPlacer) PlaceOrder(ctx context.Context, tenant *model.Tenant, req model.OrderRequest) (*clobtypes.OrderResponse, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	p.placed = append(p.placed, req)
+	return &clobtypes.OrderResponse{ID: "0xfired"}, nil
+}
+
+type memConditionalRepo struct {
+	orders map[string]model.ConditionalOrder
+}
+
+func (r *memConditionalRepo) SaveConditionalOrder(ctx context.Context, order *model.ConditionalOrder) error {
+	r.orders[order.ID] = *order
+	return nil
+}
+
+func (r *memConditionalRepo) LoadActiveConditionalOrders(ctx context.Context) ([]*model.ConditionalOrder, error) {
+	out := make([]*model.ConditionalOrder, 0)
+	for _, o := range r.orders {
+		if o.Status == model.ConditionalActive {
+			copied := o
+			out = append(out, &copied)
+		}
+	}
+	return out, nil
+}
+
+func conditionalRequest(typ model.ConditionalType, side string) model.ConditionalOrderRequest {
+	return model.ConditionalOrderRequest{
+		Type: typ,
+		Order: model.OrderRequest{
+			TokenID: "tok",
+			Side:    side,
+			Price:   decimal.RequireFromString("0.01"),
+			Size:    decimal.RequireFromString("10"),
+		},
+	}
+}
+
+func trade(marketSvc *market.MarketService, price string) {
+	book := marketSvc.GetBook("tok")
+	if !book.IsValid() {
+		// No stream in tests: an empty snapshot stands in for the subscription
+		book.Snapshot(nil, nil)
+	}
+	book.SetLastTrade(decimal.RequireFromString(price), decimal.NewFromInt(1), "BUY", time.Time{})
+}
+
+func TestConditionalServiceStopLossSurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	tm := NewTenantManager(&config.Config{}, nil)
+	tenant := &model.Tenant{ID: "t1", ApiKey: "k1"}
+	tm.RegisterTenant(tenant)
+	marketSvc := market.NewMarketService()
+	repo := &memConditionalRepo{orders: make(map[string]model.ConditionalOrder)}
+	placer := &recordingPlacer{}
+	audit := &recordingAudit{}
+
+	svc := NewConditionalService(ctx, repo, placer, marketSvc, tm, audit)
+	req := conditionalRequest(model.ConditionalStopLoss, "SELL")
+	req.TriggerPrice = decimal.RequireFromString("0.40")
+	stop, err := svc.Create(ctx, tenant, req)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if stop.TriggerSource != model.TriggerSourceLastTrade {
+		t.Fatalf("expected last_trade default, got %s", stop.TriggerSource)
+	}
+
+	// Gateway restart: the stop is restored from the repo
+	restarted := NewConditionalService(ctx, repo, placer, marketSvc, tm, audit)
+	trade(marketSvc, "0.45")
+	restarted.CheckAll(ctx)
+	if len(placer.placed) != 0 {
+		t.Fatalf("stop must not fire above its trigger")
+	}
+
+	// A price seen on an invalid book (stream down) must not fire the stop
+	trade(marketSvc, "0.39")
+	marketSvc.GetBook("tok").Invalidate("stream disconnected")
+	restarted.CheckAll(ctx)
+	if len(placer.placed) != 0 {
+		t.Fatalf("stop must not fire off an invalid book")
+	}
+
+	marketSvc.GetBook("tok").Snapshot(nil, nil)
+	restarted.CheckAll(ctx)
+	restarted.CheckAll(ctx)
+	if len(placer.placed) != 1 || placer.placed[0].Side != "SELL" || !placer.placed[0].Price.Equal(decimal.RequireFromString("0.01")) {
+		t.Fatalf("expected exactly one SELL order, got %+v", placer.placed)
+	}
+	got, err := restarted.Get("t1", stop.ID)
+	if err != nil || got.Status != model.ConditionalTriggered || got.OrderID != "0xfired" || !got.FiredPrice.Equal(decimal.RequireFromString("0.39")) {
+		t.Fatalf("expected TRIGGERED with order id, got %+v (%v)", got, err)
+	}
+	if repo.orders[stop.ID].Status != model.ConditionalTriggered {
+		t.Fatalf("expected trigger to be persisted, got %s", repo.orders[stop.ID].Status)
+	}
+	if _, err := restarted.Cancel(ctx, "t1", stop.ID); err == nil {
+		t.Fatalf("a triggered order can no longer be cancelled")
+	}
+	if len(audit.actions) != 1 || audit.actions[0] != "t1:conditional_triggered" {
+		t.Fatalf("unexpected audit actions: %v", audit.actions)
+	}
+}
+
+func TestConditionalServiceTrailingStopAndRejects(t *testing.T) {
+	ctx := context.Background()
+	tm := NewTenantManager(&config.Config{}, nil)
+	tenant := &model.Tenant{ID: "t1", ApiKey: "k1"}
+	tm.RegisterTenant(tenant)
+	marketSvc := market.NewMarketService()
+	placer := &recordingPlacer{err: errors.New("risk reject: max order value")}
+	svc := NewConditionalService(ctx, nil, placer, marketSvc, tm, nil)
+
+	req := conditionalRequest(model.ConditionalTrailingStop, "SELL")
+	req.TrailAmount = decimal.RequireFromString("0.05")
+	trail, err := svc.Create(ctx, tenant, req)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	for _, price := range []string{"0.50", "0.60", "0.57"} {
+		trade(marketSvc, price)
+		svc.CheckAll(ctx)
+	}
+	got, _ := svc.Get("t1", trail.ID)
+	if got.Status != model.ConditionalActive || !got.TriggerPrice.Equal(decimal.RequireFromString("0.55")) {
+		t.Fatalf("expected stop trailing the 0.60 high at 0.55, got %+v", got)
+	}
+
+	trade(marketSvc, "0.55")
+	svc.CheckAll(ctx)
+	got, _ = svc.Get("t1", trail.ID)
+	if got.Status != model.ConditionalFailed || got.Reason == "" {
+		t.Fatalf("a rejected order must leave the trigger FAILED, got %+v", got)
+	}
+
+	// Fixed triggers take no trail_amount; pre-signed orders cannot wait for a trigger
+	tp := conditionalRequest(model.ConditionalTakeProfit, "buy")
+	tp.TriggerPrice = decimal.RequireFromString("0.30")
+	tp.TrailAmount = decimal.RequireFromString("0.01")
+	if _, err := svc.Create(ctx, tenant, tp); err == nil {
+		t.Fatalf("expected trail_amount to be rejected on TAKE_PROFIT")
+	}
+	signed := conditionalRequest(model.ConditionalStopLoss, "SELL")
+	signed.TriggerPrice = decimal.RequireFromString("0.30")
+	signed.Order.Signature = "0xsig"
+	if _, err := svc.Create(ctx, tenant, signed); err == nil {
+		t.Fatalf("expected pre-signed orders to be rejected")
+	}
+}
+
+// blockingPlacer holds PlaceOrder until release is closed.
+type blockingPlacer struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingPlacer) PlaceOrder(ctx context.Context, tenant *model.Tenant, req model.OrderRequest) (*clobtypes.OrderResponse, error) {
+	close(p.entered)
+	<-p.release
+	return &clobtypes.OrderResponse{ID: "0xlate"}, nil
+}
+
+func TestConditionalServiceStopWaitsForFiringTrigger(t *testing.T) {
+	ctx := context.Background()
+	tm := NewTenantManager(&config.Config{}, nil)
+	tenant := &model.Tenant{ID: "t1", ApiKey: "k1"}
+	tm.RegisterTenant(tenant)
+	marketSvc := market.NewMarketService()
+	marketSvc.Subscribe([]string{"tok"})
+	placer := &blockingPlacer{entered: make(chan struct{}), release: make(chan struct{})}
+	svc := NewConditionalService(ctx, nil, placer, marketSvc, tm, nil)
+
+	req := conditionalRequest(model.ConditionalStopLoss, "SELL")
+	req.TriggerPrice = decimal.RequireFromString("0.40")
+	if _, err := svc.Create(ctx, tenant, req); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	trade(marketSvc, "0.39")
+	svc.Start(time.Millisecond)
+	<-placer.entered
+
+	stopped := make(chan struct{})
+	go func() {
+		svc.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatalf("Stop returned while an order was still being placed")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(placer.release)
+	<-stopped
+}
+
+// slowConditionalRepo holds every save until release is closed.
+type slowConditionalRepo struct {
+	entered chan struct{}
+	release chan struct{}
+	err     error
+}
+
+func (r *slowConditionalRepo) SaveConditionalOrder(ctx context.Context, order *model.ConditionalOrder) error {
+	r.entered <- struct{}{}
+	<-r.release
+	return r.err
+}
+
+func (r *slowConditionalRepo) LoadActiveConditionalOrders(ctx context.Context) ([]*model.ConditionalOrder, error) {
+	return nil, nil
+}
+
+func TestConditionalServiceCreateHoldsItsSlotWhileSaving(t *testing.T) {
+	ctx := context.Background()
+	tm := NewTenantManager(&config.Config{}, nil)
+	tenant := &model.Tenant{ID: "t1", ApiKey: "k1"}
+	tm.RegisterTenant(tenant)
+	repo := &slowConditionalRepo{entered: make(chan struct{}, 1), release: make(chan struct{}), err: errors.New("db down")}
+	svc := NewConditionalService(ctx, repo, &recordingPlacer{}, market.NewMarketService(), tm, nil)
+
+	req := conditionalRequest(model.ConditionalStopLoss, "SELL")
+	req.TriggerPrice = decimal.RequireFromString("0.40")
+	req.Order.ClientOrderID = "stop-1"
+	done := make(chan error, 1)
+	go func() {
+		_, err := svc.Create(ctx, tenant, req)
+		done <- err
+	}()
+	<-repo.entered
+
+	// The first create is still saving: its client_order_id is taken
+	if _, err := svc.Create(ctx, tenant, req); err == nil {
+		t.Fatalf("expected a concurrent create with the same client_order_id to be rejected")
+	}
+
+	// A failed save gives the slot back
+	close(repo.release)
+	if err := <-done; err == nil {
+		t.Fatalf("expected the failed save to fail the create")
+	}
+	repo.err = nil
+	go func() { <-repo.entered }()
+	if _, err := svc.Create(ctx, tenant, req); err != nil {
+		t.Fatalf("expected the slot to be free after the failed save: %v", err)
+	}
+	if got := svc.List("t1", model.ConditionalActive); len(got) != 1 {
+		t.Fatalf("expected one active order, got %d", len(got))
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
-	"sync/atomic"
 	"time"
 
 	"github.com/GoPolymarket/polygate/internal/config"
@@ -18,6 +17,7 @@ import (
 	"github.com/GoPolymarket/polymarket-go-sdk/pkg/auth"
 	"github.com/GoPolymarket/polymarket-go-sdk/pkg/clob"
 	"github.com/GoPolymarket/polymarket-go-sdk/pkg/clob/clobtypes"
+	"github.com/ethereum/go-ethereum/common"
 	sdktypes "github.com/GoPolymarket/polymarket-go-sdk/pkg/types"
 	"github.com/shopspring/decimal"
 )
@@ -28,21 +28,30 @@ type GatewayService struct {
 	config     *config.Config
 	nonceMgr   *manager.NonceManager
 	market     *market.MarketService
-	userStream *market.UserStream
+	fills      *market.FillStore
 	rpcURL     string
 	eip1271    *EIP1271Verifier
 	fastSigner *signer.Signer
 	httpClient *http.Client
-	panicMode  atomic.Bool
+	panic      *PanicService
+	placed     *placedOrders
+	sessions   *placedOrders // Key: TenantID + "/" + heartbeat SessionID
+	tracker    *market.OrderTracker
 }
 
-func NewGatewayService(cfg *config.Config, tm *TenantManager, risk *RiskEngine, marketSvc *market.MarketService, userStream *market.UserStream) (*GatewayService, error) {
+func NewGatewayService(cfg *config.Config, tm *TenantManager, risk *RiskEngine, marketSvc *market.MarketService, fills *market.FillStore, panicSvc *PanicService) (*GatewayService, error) {
+	if panicSvc == nil {
+		panicSvc = NewPanicService(context.Background(), nil, nil)
+	}
+
 	// Initialize Nonce Manager
 	nonceMgr, err := manager.NewNonceManager(cfg.Chain.RPCURL)
 	if err != nil {
 		if cfg.Chain.RPCURL != "" {
 			fmt.Printf("Warning: Failed to init nonce manager: %v\n", err)
 		}
+	} else {
+		nonceMgr.Start(time.Duration(cfg.Chain.NonceRefreshSeconds) * time.Second)
 	}
 
 	// Initialize High-Performance HTTP Client
@@ -61,9 +70,12 @@ func NewGatewayService(cfg *config.Config, tm *TenantManager, risk *RiskEngine,
 		config:     cfg,
 		nonceMgr:   nonceMgr,
 		market:     marketSvc,
-		userStream: userStream,
+		fills:      fills,
 		rpcURL:     cfg.Chain.RPCURL,
 		httpClient: httpClient,
+		panic:      panicSvc,
+		placed:     newPlacedOrders(),
+		sessions:   newPlacedOrders(),
 	}
 
 	// Initialize optimized signer if private key is available
@@ -79,11 +91,18 @@ func NewGatewayService(cfg *config.Config, tm *TenantManager, risk *RiskEngine,
 	return svc, nil
 }
 
-func (s *GatewayService) GetFills() []market.Fill {
-	if s.userStream == nil {
-		return nil
+// Stop releases background workers owned by the gateway.
+func (s *GatewayService) Stop() {
+	if s.nonceMgr != nil {
+		s.nonceMgr.Stop()
 	}
-	return s.userStream.GetFills()
+}
+
+func (s *GatewayService) GetFills(ctx context.Context, tenant *model.Tenant, filter model.FillFilter) []*model.Fill {
+	if s.fills == nil {
+		return []*model.Fill{}
+	}
+	return s.fills.ListFills(ctx, tenant.ID, filter)
 }
 
 func (s *GatewayService) GetOrderbook(tokenID string) *market.Orderbook {
@@ -101,8 +120,8 @@ func (s *GatewayService) GetOrderbook(tokenID string) *market.Orderbook {
 // Struct definitions moved to internal/model/dto.go
 
 func (s *GatewayService) PlaceOrder(ctx context.Context, tenant *model.Tenant, req model.OrderRequest) (*clobtypes.OrderResponse, error) {
-	if s.panicMode.Load() {
-		return nil, fmt.Errorf("system in panic mode: all trading suspended")
+	if err := s.panic.Check(tenant.ID); err != nil {
+		return nil, err
 	}
 
 	if req.Signature != "" && req.Signable == nil {
@@ -110,6 +129,10 @@ func (s *GatewayService) PlaceOrder(ctx context.Context, tenant *model.Tenant, r
 	}
 	// 1. Resolve signable order (use provided signable for non-custodial)
 	signable := req.Signable
+	if signable == nil {
+		// Only an order we build ourselves can still be moved onto the tick
+		req = s.risk.NormalizeOrder(ctx, tenant, req)
+	}
 	riskReq := req
 	if signable != nil {
 		if signable.Order == nil {
@@ -117,18 +140,57 @@ func (s *GatewayService) PlaceOrder(ctx context.Context, tenant *model.Tenant, r
 		}
 		riskReq = requestFromOrder(signable)
 	}
+	if err := s.checkScopedHalt(ctx, tenant, riskReq); err != nil {
+		return nil, err
+	}
+	trackReq := riskReq
+	trackReq.ClientOrderID = req.ClientOrderID
+	trackID, err := s.trackOrder(ctx, tenant, trackReq)
+	if err != nil {
+		return nil, err
+	}
 
-	// 2. Risk Engine Check (Pre-Trade)
-	if err := s.risk.CheckOrder(ctx, tenant, riskReq); err != nil {
+	// 2. Risk Engine Check (Pre-Trade), holding daily capacity until the order settles
+	reservation, err := s.risk.ReserveOrder(ctx, tenant, riskReq)
+	if err != nil {
+		s.markRejected(tenant.ID, err, trackID)
 		return nil, err
 	}
+	committed := false
+	defer func() {
+		if !committed {
+			s.risk.ReleaseOrder(context.WithoutCancel(ctx), reservation)
+		}
+	}()
+
+	s.markSubmitting(tenant.ID, trackID)
+	resp, err := s.submitOrder(ctx, tenant, req, signable, riskReq)
+	if err != nil {
+		s.markRejected(tenant.ID, err, trackID)
+		return nil, err
+	}
+	s.markAccepted(tenant.ID, trackID, resp.ID)
+
+	// The order is live upstream; count it even if the caller has gone away.
+	s.risk.CommitOrder(context.WithoutCancel(ctx), reservation, resp.ID)
+	committed = true
+	s.placed.add(tenant.ID, resp.ID)
+	s.addSessionOrder(tenant.ID, req.SessionID, resp.ID)
+
+	return &resp, nil
+}
+
+// submitOrder signs (or verifies) and posts an order whose risk capacity is
+// already reserved (steps 3-7 of PlaceOrder).
+func (s *GatewayService) submitOrder(ctx context.Context, tenant *model.Tenant, req model.OrderRequest, signable *clobtypes.SignableOrder, riskReq model.OrderRequest) (clobtypes.OrderResponse, error) {
+	var resp clobtypes.OrderResponse
 
 	// 3. Resolve signer (custodial or non-custodial)
 	var signerInst auth.Signer
 	useGatewaySigner := false
 	if strings.TrimSpace(req.Signature) == "" {
 		if s.fastSigner == nil {
-			return nil, fmt.Errorf("signature required or gateway private key not configured")
+			return resp, fmt.Errorf("signature required or gateway private key not configured")
 		}
 		useGatewaySigner = true
 	} else {
@@ -138,26 +200,26 @@ func (s *GatewayService) PlaceOrder(ctx context.Context, tenant *model.Tenant, r
 		}
 		if signable != nil && signable.Order != nil && req.Signer != "" {
 			if !strings.EqualFold(signable.Order.Signer.Hex(), req.Signer) {
-				return nil, fmt.Errorf("signer does not match signable order")
+				return resp, fmt.Errorf("signer does not match signable order")
 			}
 		}
 		if signerAddr == "" {
-			return nil, fmt.Errorf("signer address required when signature is provided")
+			return resp, fmt.Errorf("signer address required when signature is provided")
 		}
 		if !tenantAllowsSigner(tenant, signerAddr) {
-			return nil, fmt.Errorf("signer not allowed for tenant")
+			return resp, fmt.Errorf("signer not allowed for tenant")
 		}
 		var err error
 		signerInst, err = signer.NewStaticSigner(signerAddr, auth.PolygonChainID)
 		if err != nil {
-			return nil, err
+			return resp, err
 		}
 	}
 
 	// 4. Resolve L2 credentials
 	apiKey, err := resolveAPIKey(tenant, req)
 	if err != nil {
-		return nil, err
+		return resp, err
 	}
 
 	// 5. Build Signable Order if not provided
@@ -172,7 +234,7 @@ func (s *GatewayService) PlaceOrder(ctx context.Context, tenant *model.Tenant, r
 
 		signable, err = s.buildSignable(ctx, client, signerForBuild, req)
 		if err != nil {
-			return nil, err
+			return resp, err
 		}
 	} else {
 		if req.SignatureType != nil {
@@ -183,28 +245,30 @@ func (s *GatewayService) PlaceOrder(ctx context.Context, tenant *model.Tenant, r
 
 	// 6. Enforce max slippage (optional)
 	if err := s.checkMaxSlippage(ctx, client, tenant, riskReq); err != nil {
-		return nil, err
+		return resp, err
 	}
 
 	// 7. Execute via SDK
 	execClient := s.newClient(signerInst, apiKey)
-	var resp clobtypes.OrderResponse
 
 	if useGatewaySigner {
 		// --- FAST PATH ---
 		optOrder := toOptimizedOrder(signable.Order)
+		negRisk, exchange := s.exchangeFor(ctx, req.TokenID)
 		
 		if s.nonceMgr != nil {
-			exNonce, err := s.nonceMgr.GetExchangeNonce(ctx, s.fastSigner.Address())
+			exNonce, err := s.nonceMgr.GetExchangeNonceFor(ctx, exchange, signable.Order.Maker)
 			if err == nil {
 				optOrder.Nonce = exNonce
 				signable.Order.Nonce = sdktypes.U256{Int: exNonce}
+			} else {
+				logger.Warn("Exchange nonce unavailable, using builder nonce", "exchange", exchange.Hex(), "maker", signable.Order.Maker.Hex(), "error", err)
 			}
 		}
 
-		signature, err := s.fastSigner.SignOrder(optOrder)
+		signature, err := s.fastSigner.SignOrderFor(optOrder, negRisk)
 		if err != nil {
-			return nil, fmt.Errorf("signing failed: %w", err)
+			return resp, fmt.Errorf("signing failed: %w", err)
 		}
 
 		signed := &clobtypes.SignedOrder{
@@ -224,7 +288,7 @@ func (s *GatewayService) PlaceOrder(ctx context.Context, tenant *model.Tenant, r
 					_, _ = s.nonceMgr.SyncExchangeNonce(ctx, signable.Order.Maker)
 				}
 			}
-			return nil, fmt.Errorf("polymarket api error: %w", err)
+			return resp, fmt.Errorf("polymarket api error: %w", err)
 		}
 	} else {
 		// --- EXTERNAL SIGNER PATH ---
@@ -233,7 +297,7 @@ func (s *GatewayService) PlaceOrder(ctx context.Context, tenant *model.Tenant, r
 			sigType = signable.Order.SignatureType
 		}
 		if !signer.SignatureTypeSupported(sigType) && !tenant.Risk.AllowUnverifiedSignatures {
-			return nil, fmt.Errorf("signature type not supported for verification")
+			return resp, fmt.Errorf("signature type not supported for verification")
 		}
 		if sigType != nil && *sigType == int(auth.SignatureGnosisSafe) {
 			if tenant.Risk.AllowUnverifiedSignatures {
@@ -241,18 +305,18 @@ func (s *GatewayService) PlaceOrder(ctx context.Context, tenant *model.Tenant, r
 			} else {
 				hash, err := signer.TypedDataHash(signable.Order, signerInst.Address(), auth.PolygonChainID)
 				if err != nil {
-					return nil, fmt.Errorf("failed to hash typed data")
+					return resp, fmt.Errorf("failed to hash typed data")
 				}
 				verifier, err := s.getEIP1271Verifier()
 				if err != nil {
-					return nil, err
+					return resp, err
 				}
 				ok, err := verifier.Verify(ctx, signable.Order.Maker.Hex(), hash, req.Signature)
 				if err != nil {
-					return nil, err
+					return resp, err
 				}
 				if !ok {
-					return nil, fmt.Errorf("invalid safe signature")
+					return resp, fmt.Errorf("invalid safe signature")
 				}
 			}
 		} else if signer.SignatureTypeSupported(sigType) {
@@ -261,7 +325,7 @@ func (s *GatewayService) PlaceOrder(ctx context.Context, tenant *model.Tenant, r
 				signerAddr = signable.Order.Signer.Hex()
 			}
 			if err := signer.VerifyOrderSignature(signable.Order, req.Signature, signerAddr, auth.PolygonChainID); err != nil {
-				return nil, fmt.Errorf("invalid signature")
+				return resp, fmt.Errorf("invalid signature")
 			}
 		}
 		signed := &clobtypes.SignedOrder{
@@ -273,19 +337,48 @@ func (s *GatewayService) PlaceOrder(ctx context.Context, tenant *model.Tenant, r
 		}
 		resp, err = execClient.CLOB.PostOrder(ctx, signed)
 		if err != nil {
-			return nil, fmt.Errorf("polymarket api error: %w", err)
+			return resp, fmt.Errorf("polymarket api error: %w", err)
 		}
 	}
+	return resp, nil
+}
 
-	s.risk.PostOrderHook(ctx, tenant, riskReq)
+// ActivatePanicMode halts trading for one tenant and cancels its open orders.
+func (s *GatewayService) ActivatePanicMode(ctx context.Context, tenant *model.Tenant, actor, reason string) (*model.PanicState, error) {
+	state := s.panic.Activate(ctx, tenant.ID, actor, reason)
+	_, err := s.CancelAllOrders(ctx, tenant)
+	return state, err
+}
 
-	return &resp, nil
+// ActivateGlobalPanic halts trading for every tenant and cancels their open orders (best effort).
+// Returns the IDs of tenants whose cancel-all failed.
+func (s *GatewayService) ActivateGlobalPanic(ctx context.Context, actor, reason string) (*model.PanicState, []string) {
+	state := s.panic.Activate(ctx, "", actor, reason)
+	failed := make([]string, 0)
+	for _, tenant := range s.tm.ListTenants() {
+		if _, err := s.CancelAllOrders(ctx, tenant); err != nil {
+			logger.Error("Panic cancel-all failed", "tenant_id", tenant.ID, "error", err)
+			failed = append(failed, tenant.ID)
+		}
+	}
+	return state, failed
 }
 
-func (s *GatewayService) ActivatePanicMode(ctx context.Context, tenant *model.Tenant) error {
-	s.panicMode.Store(true)
-	_, err := s.CancelAllOrders(ctx, tenant)
-	return err
+// ResumeTrading lifts a halt; tenantID "" targets the global scope.
+func (s *GatewayService) ResumeTrading(ctx context.Context, tenantID, actor, reason string) (*model.PanicState, error) {
+	return s.panic.Resume(ctx, tenantID, actor, reason)
+}
+
+func (s *GatewayService) GetTenant(id string) (*model.Tenant, bool) {
+	return s.tm.GetTenantByID(id)
+}
+
+func (s *GatewayService) PanicStatus(tenantID string) (global, tenant *model.PanicState) {
+	return s.panic.Status(tenantID)
+}
+
+func (s *GatewayService) ListPanicStates() []*model.PanicState {
+	return s.panic.List()
 }
 
 func toOptimizedOrder(o *clobtypes.Order) *signer.Order {
@@ -329,10 +422,21 @@ func (s *GatewayService) CancelOrder(ctx context.Context, tenant *model.Tenant,
 	if err != nil {
 		return nil, fmt.Errorf("failed to cancel order: %w", err)
 	}
+	s.orderCancelled(ctx, tenant.ID, input.ID)
 
 	return &resp, nil
 }
 
+// orderCancelled updates local bookkeeping for an order the exchange cancelled.
+func (s *GatewayService) orderCancelled(ctx context.Context, tenantID, orderID string) {
+	s.placed.remove(tenantID, orderID)
+	s.sessions.removeFromAll(heartbeatKey(tenantID, ""), orderID)
+	s.risk.OrdersCancelled(tenantID, orderID)
+	if s.tracker != nil {
+		s.tracker.Cancelled(ctx, tenantID, orderID)
+	}
+}
+
 func (s *GatewayService) CancelAllOrders(ctx context.Context, tenant *model.Tenant) (*clobtypes.CancelAllResponse, error) {
 	client, err := s.tm.GetClientForTenant(tenant)
 	if err != nil {
@@ -343,6 +447,9 @@ func (s *GatewayService) CancelAllOrders(ctx context.Context, tenant *model.Tena
 	if err != nil {
 		return nil, fmt.Errorf("failed to cancel all orders: %w", err)
 	}
+	s.placed.clear(tenant.ID)
+	s.sessions.clearAll(heartbeatKey(tenant.ID, ""))
+	s.risk.OrdersCancelled(tenant.ID, "")
 	
 	return &resp, nil
 }
@@ -354,6 +461,7 @@ func (s *GatewayService) BuildTypedOrder(ctx context.Context, tenant *model.Tena
 	if !tenantAllowsSigner(tenant, req.Signer) {
 		return nil, fmt.Errorf("signer not allowed for tenant")
 	}
+	req = s.risk.NormalizeOrder(ctx, tenant, req)
 	if err := s.risk.CheckOrder(ctx, tenant, req); err != nil {
 		return nil, err
 	}
@@ -376,6 +484,17 @@ func (s *GatewayService) BuildTypedOrder(ctx context.Context, tenant *model.Tena
 	}, nil
 }
 
+// exchangeFor reports whether the token is a Neg-Risk market and the exchange its
+// orders settle on; the CTF Exchange is assumed when market info is unavailable.
+func (s *GatewayService) exchangeFor(ctx context.Context, tokenID string) (bool, common.Address) {
+	if s.market != nil {
+		if info, err := s.market.MarketInfo(ctx, tokenID); err == nil && info.NegRisk {
+			return true, manager.NegRiskCTFExchangeAddress
+		}
+	}
+	return false, manager.CTFExchangeAddress
+}
+
 func (s *GatewayService) newClient(signer auth.Signer, apiKey *auth.APIKey) *polymarket.Client {
 	opts := []polymarket.Option{
 		polymarket.WithUseServerTime(true),
@@ -411,8 +530,8 @@ func (s *GatewayService) buildSignable(ctx context.Context, client *polymarket.C
 	orderType := parseOrderType(req.OrderType)
 	builder := clob.NewOrderBuilder(client.CLOB, signer).
 		TokenID(req.TokenID).
-		Price(req.Price).
-		Size(req.Size).
+		PriceDec(req.Price).
+		SizeDec(req.Size).
 		Side(req.Side).
 		OrderType(orderType)
 	if req.PostOnly != nil {
@@ -476,37 +595,19 @@ func (s *GatewayService) checkMaxSlippage(ctx context.Context, client *polymarke
 	if err != nil {
 		return fmt.Errorf("failed to fetch order book for slippage check: %w", err)
 	}
-	price := decimal.NewFromFloat(req.Price)
-	slippage := decimal.NewFromFloat(tenant.Risk.MaxSlippage)
-	one := decimal.NewFromInt(1)
+	return checkSlippage(book, tenant.Risk.MaxSlippage, req)
+}
 
-	switch strings.ToUpper(req.Side) {
-	case "BUY":
-		if len(book.Asks) == 0 {
-			return fmt.Errorf("order book empty for slippage check")
-		}
-		bestAsk, err := decimal.NewFromString(book.Asks[0].Price)
-		if err != nil {
-			return fmt.Errorf("invalid ask price for slippage check")
-		}
-		maxAllowed := bestAsk.Mul(one.Add(slippage))
-		if price.GreaterThan(maxAllowed) {
-			return fmt.Errorf("risk reject: price %.4f exceeds max slippage", req.Price)
-		}
-	case "SELL":
-		if len(book.Bids) == 0 {
-			return fmt.Errorf("order book empty for slippage check")
-		}
-		bestBid, err := decimal.NewFromString(book.Bids[0].Price)
-		if err != nil {
-			return fmt.Errorf("invalid bid price for slippage check")
-		}
-		minAllowed := bestBid.Mul(one.Sub(slippage))
-		if price.LessThan(minAllowed) {
-			return fmt.Errorf("risk reject: price %.4f exceeds max slippage", req.Price)
-		}
+// checkSlippage sweeps a fetched book with the order and checks its average fill price against mid.
+func checkSlippage(book clobtypes.OrderBookResponse, maxSlippage float64, req model.OrderRequest) error {
+	bids, asks, err := market.LevelsFromBook(book)
+	if err != nil {
+		return fmt.Errorf("invalid order book for slippage check: %w", err)
+	}
+	if (strings.ToUpper(req.Side) == "BUY" && len(asks) == 0) || (strings.ToUpper(req.Side) == "SELL" && len(bids) == 0) {
+		return fmt.Errorf("order book empty for slippage check")
 	}
-	return nil
+	return checkSweepSlippage(bids, asks, decimal.NewFromFloat(maxSlippage), req)
 }
 
 func resolveAPIKey(tenant *model.Tenant, req model.OrderRequest) (*auth.APIKey, error) {
@@ -542,8 +643,8 @@ func tenantAllowsSigner(tenant *model.Tenant, signer string) bool {
 
 func requestFromOrder(signable *clobtypes.SignableOrder) model.OrderRequest {
 	order := signable.Order
-	price := 0.0
-	size := 0.0
+	price := decimal.Zero
+	size := decimal.Zero
 	tokenID := ""
 	if order != nil {
 		if order.TokenID.Int != nil {
@@ -557,16 +658,18 @@ func requestFromOrder(signable *clobtypes.SignableOrder) model.OrderRequest {
 		if order.TakerAmount.BigInt() != nil {
 			taker = order.TakerAmount
 		}
+		// Amounts are fixed-point base units (6 decimals); shares are the taker side of a BUY
+		// and the maker side of a SELL.
 		switch strings.ToUpper(order.Side) {
 		case "BUY":
 			if !taker.IsZero() {
-				size = taker.InexactFloat64()
-				price = maker.Div(taker).InexactFloat64()
+				size = taker.Shift(-model.USDCDecimals)
+				price = maker.Div(taker)
 			}
 		case "SELL":
 			if !maker.IsZero() {
-				size = maker.InexactFloat64()
-				price = taker.Div(maker).InexactFloat64()
+				size = maker.Shift(-model.USDCDecimals)
+				price = taker.Div(maker)
 			}
 		}
 	}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/pkg/apperrors"
+	"github.com/GoPolymarket/polygate/internal/pkg/logger"
+	"github.com/GoPolymarket/polymarket-go-sdk/pkg/clob/clobtypes"
+)
+
+const (
+	DefaultHeartbeatCheckInterval = time.Second
+	heartbeatActor                = "system:dead_mans_switch"
+	// heartbeatCancelTimeout bounds one lapsed switch's cancel, so a hung upstream
+	// call cannot hold up the others
+	heartbeatCancelTimeout = 5 * time.Second
+	// heartbeatCancelWorkers is how many lapsed switches are cancelled at once
+	heartbeatCancelWorkers = 8
+)
+
+// OrderCanceller cancels a tenant's orders when its heartbeat lapses.
+type OrderCanceller interface {
+	CancelAllOrders(ctx context.Context, tenant *model.Tenant) (*clobtypes.CancelAllResponse, error)
+	CancelSessionOrders(ctx context.Context, tenant *model.Tenant, sessionID string) (*model.CancelOrdersResponse, error)
+}
+
+// HeartbeatService 实现死手开关 (cancel-on-disconnect)：租户按 TTL 发送心跳，
+// 超时未续约则撤销该租户的全部挂单（或仅撤销该 session 标记的订单）。
+type HeartbeatService struct {
+	tm        *TenantManager
+	canceller OrderCanceller
+	audit     AuditSink
+	now       func() time.Time
+	timeout   time.Duration // per-switch cancel bound
+	ctx       context.Context
+	cancel    context.CancelFunc
+}
+
+func NewHeartbeatService(tm *TenantManager, canceller OrderCanceller, audit AuditSink) *HeartbeatService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &HeartbeatService{
+		tm:        tm,
+		canceller: canceller,
+		audit:     audit,
+		now:       func() time.Time { return time.Now().UTC() },
+		timeout:   heartbeatCancelTimeout,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Beat arms or extends the tenant's switch; a zero TTL disarms it.
+// The returned heartbeat is nil when the switch was disarmed; renewed reports a
+// plain extension of an armed switch (nothing worth auditing). A beat arriving
+// after the switch lapsed cannot save the orders: the lapse is handled (orders
+// cancelled) first, and if that cancel fails the beat fails too.
+func (s *HeartbeatService) Beat(ctx context.Context, tenant *model.Tenant, req model.HeartbeatRequest) (hb *model.Heartbeat, renewed bool, err error) {
+	if req.TTLSeconds < 0 || req.TTLSeconds > model.MaxHeartbeatTTLSeconds {
+		return nil, false, apperrors.NewInvalidRequest(fmt.Sprintf("ttl_seconds must be between 0 and %d", model.MaxHeartbeatTTLSeconds))
+	}
+	for {
+		now := s.now()
+		var lapsed model.Heartbeat
+		if req.TTLSeconds == 0 {
+			var ok bool
+			if lapsed, ok = s.tm.DisarmLive(tenant.ID, req.SessionID, now); !ok {
+				return nil, false, nil
+			}
+		} else {
+			beat, renewed, ok := s.tm.Beat(tenant.ID, req.SessionID, time.Duration(req.TTLSeconds)*time.Second, now)
+			if !ok {
+				return &beat, renewed, nil
+			}
+			lapsed = beat
+		}
+		if err := s.expire(ctx, tenant, lapsed); err != nil {
+			return nil, false, apperrors.New(apperrors.ErrUpstream, "heartbeat lapsed and cancelling its orders failed, retry", err)
+		}
+	}
+}
+
+// Status returns the tenant's armed switches.
+func (s *HeartbeatService) Status(tenantID string) []model.Heartbeat {
+	return s.tm.Heartbeats(tenantID)
+}
+
+// Start runs the periodic expiry check.
+func (s *HeartbeatService) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultHeartbeatCheckInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+				s.CheckAll(s.ctx)
+			}
+		}
+	}()
+}
+
+// Stop halts the check loop.
+func (s *HeartbeatService) Stop() {
+	s.cancel()
+}
+
+// CheckAll cancels orders for every lapsed switch. The cancels run side by side,
+// each bounded by its own timeout. A switch is only disarmed once its cancel
+// succeeded, so a failed or timed-out cancel is retried on the next check.
+func (s *HeartbeatService) CheckAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, heartbeatCancelWorkers)
+	for _, hb := range s.tm.ExpiredHeartbeats(s.now()) {
+		tenant, ok := s.tm.GetTenantByID(hb.TenantID)
+		if !ok {
+			s.tm.Disarm(hb.TenantID, hb.SessionID)
+			continue
+		}
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(hb model.Heartbeat) {
+			defer func() { <-slots; wg.Done() }()
+			cctx, cancel := context.WithTimeout(ctx, s.timeout)
+			defer cancel()
+			if err := s.expire(cctx, tenant, hb); err != nil {
+				logger.Error("Dead man's switch cancel failed, will retry", "tenant_id", hb.TenantID, "session_id", hb.SessionID, "error", err)
+			}
+		}(hb)
+	}
+	wg.Wait()
+}
+
+// expire cancels the orders covered by a lapsed switch, then disarms it.
+func (s *HeartbeatService) expire(ctx context.Context, tenant *model.Tenant, hb model.Heartbeat) error {
+	cancelled := 0
+	if hb.SessionID == "" {
+		resp, err := s.canceller.CancelAllOrders(ctx, tenant)
+		if err != nil {
+			return err
+		}
+		cancelled = resp.Count
+	} else {
+		resp, err := s.canceller.CancelSessionOrders(ctx, tenant, hb.SessionID)
+		if err != nil {
+			return err
+		}
+		cancelled = resp.Count
+	}
+	if !s.tm.DisarmExpired(hb.TenantID, hb.SessionID, hb.ExpiresAt) {
+		// Already handled, or re-armed while we were cancelling; the new deadline stands
+		return nil
+	}
+
+	logger.Warn("Dead man's switch triggered", "tenant_id", hb.TenantID, "session_id", hb.SessionID, "last_beat", hb.LastBeat, "cancelled", cancelled)
+	if s.audit != nil {
+		s.audit.Log(systemAuditEntry(hb.TenantID, "heartbeat_expired", map[string]interface{}{
+			"actor":       heartbeatActor,
+			"session_id":  hb.SessionID,
+			"ttl_seconds": hb.TTLSeconds,
+			"last_beat":   hb.LastBeat,
+			"cancelled":   cancelled,
+		}))
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/config"
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polymarket-go-sdk/pkg/clob/clobtypes"
+)
+
+type recordingCanceller struct {
+	mu       sync.Mutex
+	all      []string
+	sessions []string
+	fail     bool
+	hang     string // tenant whose cancel blocks until its context is done
+}
+
+func (c *recordingCanceller) CancelAllOrders(ctx context.Context, tenant *model.Tenant) (*clobtypes.CancelAllResponse, error) {
+	if tenant.ID == c.hang {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fail {
+		return nil, errors.New("upstream down")
+	}
+	c.all = append(c.all, tenant.ID)
+	return &clobtypes.CancelAllResponse{Count: 3}, nil
+}
+
+func (c *recordingCanceller) CancelSessionOrders(ctx context.Context, tenant *model.Tenant, sessionID string) (*model.CancelOrdersResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fail {
+		return nil, errors.New("upstream down")
+	}
+	c.sessions = append(c.sessions, tenant.ID+"/"+sessionID)
+	return &model.CancelOrdersResponse{Count: 1}, nil
+}
+
+func TestHeartbeatServiceCancelsOnLapse(t *testing.T) {
+	ctx := context.Background()
+	tm := NewTenantManager(&config.Config{}, nil)
+	tenant := &model.Tenant{ID: "t1", ApiKey: "k1"}
+	tm.RegisterTenant(tenant)
+
+	canceller := &recordingCanceller{fail: true}
+	audit := &recordingAudit{}
+	svc := NewHeartbeatService(tm, canceller, audit)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	if _, _, err := svc.Beat(ctx, tenant, model.HeartbeatRequest{TTLSeconds: 10}); err != nil {
+		t.Fatalf("beat: %v", err)
+	}
+	if _, renewed, _ := svc.Beat(ctx, tenant, model.HeartbeatRequest{TTLSeconds: 10}); !renewed {
+		t.Fatalf("expected a same-TTL beat to be a renewal")
+	}
+	if _, _, err := svc.Beat(ctx, tenant, model.HeartbeatRequest{TTLSeconds: 5, SessionID: "quoter"}); err != nil {
+		t.Fatalf("beat session: %v", err)
+	}
+
+	now = now.Add(6 * time.Second)
+	svc.CheckAll(ctx)
+	if len(tm.Heartbeats("t1")) != 2 {
+		t.Fatalf("a failed cancel must leave the switch armed for a retry")
+	}
+
+	canceller.fail = false
+	svc.CheckAll(ctx)
+	if len(canceller.sessions) != 1 || canceller.sessions[0] != "t1/quoter" || len(canceller.all) != 0 {
+		t.Fatalf("expected only the lapsed session to be cancelled, got all=%v sessions=%v", canceller.all, canceller.sessions)
+	}
+	if hbs := tm.Heartbeats("t1"); len(hbs) != 1 || hbs[0].SessionID != "" {
+		t.Fatalf("expected only the tenant-wide switch to remain armed, got %+v", hbs)
+	}
+
+	now = now.Add(5 * time.Second)
+	svc.CheckAll(ctx)
+	if len(canceller.all) != 1 || canceller.all[0] != "t1" {
+		t.Fatalf("expected a tenant-wide cancel, got %v", canceller.all)
+	}
+	if len(tm.Heartbeats("t1")) != 0 {
+		t.Fatalf("a triggered switch must be disarmed")
+	}
+	if len(audit.actions) != 2 || audit.actions[0] != "t1:heartbeat_expired" {
+		t.Fatalf("unexpected audit actions: %v", audit.actions)
+	}
+
+	if _, _, err := svc.Beat(ctx, tenant, model.HeartbeatRequest{TTLSeconds: model.MaxHeartbeatTTLSeconds + 1}); err == nil {
+		t.Fatalf("expected TTL above the cap to be rejected")
+	}
+}
+
+func TestHeartbeatBeatAfterLapseCancelsFirst(t *testing.T) {
+	ctx := context.Background()
+	tm := NewTenantManager(&config.Config{}, nil)
+	tenant := &model.Tenant{ID: "t1", ApiKey: "k1"}
+	tm.RegisterTenant(tenant)
+
+	canceller := &recordingCanceller{}
+	audit := &recordingAudit{}
+	svc := NewHeartbeatService(tm, canceller, audit)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	if _, _, err := svc.Beat(ctx, tenant, model.HeartbeatRequest{TTLSeconds: 5}); err != nil {
+		t.Fatalf("beat: %v", err)
+	}
+	// The beat comes in after the deadline, before the check loop ran
+	now = now.Add(6 * time.Second)
+	canceller.fail = true
+	if _, _, err := svc.Beat(ctx, tenant, model.HeartbeatRequest{TTLSeconds: 5}); err == nil {
+		t.Fatalf("a late beat must fail while the lapse cannot be handled")
+	}
+	if _, _, err := svc.Beat(ctx, tenant, model.HeartbeatRequest{TTLSeconds: 0}); err == nil {
+		t.Fatalf("a late disarm must fail while the lapse cannot be handled")
+	}
+	if hbs := tm.Heartbeats("t1"); len(hbs) != 1 || !now.After(hbs[0].ExpiresAt) {
+		t.Fatalf("the lapsed switch must stay in place for a retry, got %+v", hbs)
+	}
+
+	canceller.fail = false
+	hb, renewed, err := svc.Beat(ctx, tenant, model.HeartbeatRequest{TTLSeconds: 5})
+	if err != nil || renewed || !hb.ExpiresAt.Equal(now.Add(5*time.Second)) {
+		t.Fatalf("expected a fresh switch after the lapse, got %+v renewed=%v err=%v", hb, renewed, err)
+	}
+	if len(canceller.all) != 1 || len(audit.actions) != 1 || audit.actions[0] != "t1:heartbeat_expired" {
+		t.Fatalf("expected the lapse to be cancelled and audited first, got all=%v audit=%v", canceller.all, audit.actions)
+	}
+	svc.CheckAll(ctx)
+	if len(canceller.all) != 1 {
+		t.Fatalf("the new switch must not be cancelled again, got %v", canceller.all)
+	}
+}
+
+func TestHeartbeatCheckAllBoundsEachCancel(t *testing.T) {
+	ctx := context.Background()
+	tm := NewTenantManager(&config.Config{}, nil)
+	slow := &model.Tenant{ID: "slow", ApiKey: "k1"}
+	fast := &model.Tenant{ID: "fast", ApiKey: "k2"}
+	tm.RegisterTenant(slow)
+	tm.RegisterTenant(fast)
+
+	canceller := &recordingCanceller{hang: "slow"}
+	svc := NewHeartbeatService(tm, canceller, nil)
+	svc.timeout = 50 * time.Millisecond
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	svc.Beat(ctx, slow, model.HeartbeatRequest{TTLSeconds: 5})
+	svc.Beat(ctx, fast, model.HeartbeatRequest{TTLSeconds: 5})
+
+	now = now.Add(6 * time.Second)
+	start := time.Now()
+	svc.CheckAll(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("a hung cancel must be cut off by its timeout, took %s", elapsed)
+	}
+	if len(canceller.all) != 1 || canceller.all[0] != "fast" {
+		t.Fatalf("expected the other tenant to be cancelled, got %v", canceller.all)
+	}
+	if hbs := tm.Heartbeats("slow"); len(hbs) != 1 {
+		t.Fatalf("the timed-out switch must stay armed for a retry")
+	}
+	if hbs := tm.Heartbeats("fast"); len(hbs) != 0 {
+		t.Fatalf("the cancelled switch must be disarmed")
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/GoPolymarket/polygate/internal/market"
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/pkg/apperrors"
+	"github.com/GoPolymarket/polymarket-go-sdk/pkg/clob/clobtypes"
+)
+
+// SetOrderTracker enables the local order lifecycle (and client_order_id lookups).
+func (s *GatewayService) SetOrderTracker(tracker *market.OrderTracker) {
+	s.tracker = tracker
+}
+
+// GetClientOrder 按租户自定义的 client_order_id 查询订单的本地生命周期状态
+func (s *GatewayService) GetClientOrder(ctx context.Context, tenant *model.Tenant, clientOrderID string) (*model.TrackedOrder, error) {
+	if s.tracker == nil {
+		return nil, apperrors.New(apperrors.ErrNotFound, "order tracking is not enabled", nil)
+	}
+	order, ok := s.tracker.ByClientID(ctx, tenant.ID, clientOrderID)
+	if !ok {
+		return nil, apperrors.New(apperrors.ErrNotFound, "order not found", nil)
+	}
+	return order, nil
+}
+
+// CancelClientOrder 按 client_order_id 撤单
+func (s *GatewayService) CancelClientOrder(ctx context.Context, tenant *model.Tenant, clientOrderID string) (*clobtypes.CancelResponse, error) {
+	order, err := s.GetClientOrder(ctx, tenant, clientOrderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.State.Terminal() {
+		return nil, apperrors.NewInvalidRequest(fmt.Sprintf("order is already %s", order.State))
+	}
+	if order.OrderID == "" {
+		return nil, apperrors.NewInvalidRequest("order has not been acknowledged by the exchange yet")
+	}
+	return s.CancelOrder(ctx, tenant, model.CancelOrderInput{ID: order.OrderID})
+}
+
+// trackOrder registers an order in state NEW and returns its tracking ID
+// ("" when tracking is disabled).
+func (s *GatewayService) trackOrder(ctx context.Context, tenant *model.Tenant, req model.OrderRequest) (string, error) {
+	if s.tracker == nil {
+		return "", nil
+	}
+	order, err := s.tracker.Track(ctx, tenant.ID, req)
+	if errors.Is(err, market.ErrDuplicateClientOrderID) {
+		return "", apperrors.NewInvalidRequest(fmt.Sprintf("%s: %s", err.Error(), req.ClientOrderID))
+	}
+	if err != nil {
+		return "", err
+	}
+	return order.ID, nil
+}
+
+func (s *GatewayService) markSubmitting(tenantID string, trackIDs ...string) {
+	if s.tracker == nil {
+		return
+	}
+	for _, id := range trackIDs {
+		if id != "" {
+			s.tracker.Submitting(tenantID, id)
+		}
+	}
+}
+
+// markAccepted records the exchange's answer; an empty order ID means the
+// exchange did not accept the order. Events the user channel delivered before
+// the answer (common for FOK / FAK and crossing orders) found no tracked order
+// and were dropped, so the latest one is replayed once the order ID is known.
+func (s *GatewayService) markAccepted(tenantID, trackID, orderID string) {
+	if s.tracker == nil || trackID == "" {
+		return
+	}
+	if orderID == "" {
+		s.tracker.Rejected(tenantID, trackID, "order rejected by exchange")
+		return
+	}
+	s.tracker.Accepted(tenantID, trackID, orderID)
+	if s.fills == nil {
+		return
+	}
+	if update, ok := s.fills.GetOrderUpdate(tenantID, orderID); ok {
+		s.tracker.OnOrderUpdate(update)
+	}
+}
+
+func (s *GatewayService) markRejected(tenantID string, err error, trackIDs ...string) {
+	if s.tracker == nil || err == nil {
+		return
+	}
+	for _, id := range trackIDs {
+		if id != "" {
+			s.tracker.Rejected(tenantID, id, err.Error())
+		}
+	}
+}
+
+// annotateOrder adds the local lifecycle state to an order known to the tracker.
+func (s *GatewayService) annotateOrder(ctx context.Context, tenantID string, order *model.Order) {
+	if s.tracker == nil || order.ID == "" {
+		return
+	}
+	if tracked, ok := s.tracker.ByOrderID(ctx, tenantID, order.ID); ok {
+		order.ClientOrderID = tracked.ClientOrderID
+		order.State = tracked.State
+	}
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GoPolymarket/polygate/internal/config"
+	"github.com/GoPolymarket/polygate/internal/market"
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+func TestAcceptedReplaysEarlyOrderUpdates(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{}
+	tm := NewTenantManager(cfg, nil)
+	tenant := &model.Tenant{ID: "t1", ApiKey: "k1"}
+	tm.RegisterTenant(tenant)
+	fills := market.NewFillStore(100, nil)
+	gw, err := NewGatewayService(cfg, tm, nil, nil, fills, nil)
+	if err != nil {
+		t.Fatalf("gateway init failed: %v", err)
+	}
+	tracker := market.NewOrderTracker(nil)
+	gw.SetOrderTracker(tracker)
+	fills.AddListener(tracker)
+
+	trackID, err := gw.trackOrder(ctx, tenant, model.OrderRequest{TokenID: "tok", Side: "BUY", Price: decimal.RequireFromString("0.5"), Size: decimal.NewFromInt(10), OrderType: "FOK", ClientOrderID: "c1"})
+	if err != nil {
+		t.Fatalf("track: %v", err)
+	}
+	gw.markSubmitting("t1", trackID)
+
+	// A FOK fills before the POST returns: the update arrives before the order ID is known
+	fills.AddOrderUpdate(&model.OrderUpdate{TenantID: "t1", OrderID: "0xabc", Type: market.OrderEventUpdate, OriginalSize: "10", SizeMatched: "10"})
+	gw.markAccepted("t1", trackID, "0xabc")
+
+	order, ok := tracker.ByClientID(ctx, "t1", "c1")
+	if !ok || order.State != model.OrderStateFilled || !order.SizeMatched.Equal(decimal.NewFromInt(10)) {
+		t.Fatalf("expected the early update to be replayed into FILLED, got %+v", order)
+	}
+}
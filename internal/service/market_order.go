@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/market"
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/pkg/apperrors"
+	"github.com/GoPolymarket/polymarket-go-sdk/pkg/clob/clobtypes"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// marketOrderBookMaxAge is how old the shadow book may be before the REST book is used
+	marketOrderBookMaxAge = 10 * time.Second
+	// marketOrderFillWait bounds the wait for the user channel to report the fills
+	marketOrderFillWait = 2 * time.Second
+	marketOrderFillPoll = 50 * time.Millisecond
+)
+
+// PlaceMarketOrder 市价单（仅托管模式）：逐档遍历订单簿（影子簿不可信时改用 REST 簿），
+// 计算预期均价与扫单所需的限价（不劣于 worst_price），以 FOK / FAK 限价单提交，
+// 风控照常生效。响应对比预期成交与 user channel 回报的实际成交。
+func (s *GatewayService) PlaceMarketOrder(ctx context.Context, tenant *model.Tenant, req model.MarketOrderRequest) (*model.MarketOrderResponse, error) {
+	if err := s.panic.Check(tenant.ID); err != nil {
+		return nil, err
+	}
+	if err := validateMarketOrder(&req); err != nil {
+		return nil, err
+	}
+	if s.fastSigner == nil {
+		return nil, fmt.Errorf("market orders require the gateway private key")
+	}
+
+	levels, source, err := s.sweepLevels(ctx, req.TokenID, req.Side)
+	if err != nil {
+		return nil, err
+	}
+	sweep := market.SweepLevels(levels, req.Side, req.Size, req.Amount, req.WorstPrice)
+	if !sweep.Size.IsPositive() {
+		if sweep.Complete {
+			return nil, apperrors.NewInvalidRequest("amount is too small to trade a single share")
+		}
+		return nil, apperrors.NewRiskReject(fmt.Sprintf("no liquidity at or better than worst_price %s", req.WorstPrice))
+	}
+	if !sweep.Complete && req.OrderType == string(clobtypes.OrderTypeFOK) {
+		return nil, apperrors.NewRiskReject(fmt.Sprintf("insufficient liquidity at or better than worst_price %s: %s shares (%s USDC) available",
+			req.WorstPrice, sweep.Size, sweep.Notional))
+	}
+
+	resp := &model.MarketOrderResponse{
+		OrderType:  req.OrderType,
+		BookSource: source,
+		LimitPrice: sweep.WorstPrice,
+		Expected:   marketOrderFill(sweep.Size, sweep.Notional),
+	}
+	placed, err := s.PlaceOrder(ctx, tenant, model.OrderRequest{
+		TokenID:       req.TokenID,
+		Side:          req.Side,
+		Price:         sweep.WorstPrice,
+		Size:          sweep.Size,
+		OrderType:     req.OrderType,
+		ClientOrderID: req.ClientOrderID,
+		SessionID:     req.SessionID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp.OrderID = placed.ID
+	resp.Status = placed.Status
+	resp.Actual, resp.ActualPending = s.awaitFills(ctx, tenant.ID, placed.ID, sweep.Size)
+	return resp, nil
+}
+
+// QuoteOrder prices taking size shares on side from the shadow orderbook.
+func (s *GatewayService) QuoteOrder(tokenID, side string, size decimal.Decimal) (*market.BookQuote, error) {
+	side = strings.ToUpper(side)
+	if side != "BUY" && side != "SELL" {
+		return nil, apperrors.NewInvalidRequest("side must be BUY or SELL")
+	}
+	if !size.IsPositive() {
+		return nil, apperrors.NewInvalidRequest("size must be positive")
+	}
+	book := s.GetOrderbook(tokenID)
+	if book == nil {
+		return nil, apperrors.New(apperrors.ErrNotFound, "orderbook not found or not subscribed", nil)
+	}
+	if !book.IsValid() {
+		return nil, apperrors.New(apperrors.ErrUpstream, fmt.Sprintf("orderbook out of sync (%s), cannot quote", book.InvalidReason()), nil)
+	}
+	quote := book.Quote(side, size)
+	return &quote, nil
+}
+
+// sweepLevels returns the side of the book a market order takes from (asks for a
+// BUY, bids for a SELL), best first, and where it came from.
+func (s *GatewayService) sweepLevels(ctx context.Context, tokenID, side string) ([]market.Level, string, error) {
+	if book := s.GetOrderbook(tokenID); book != nil {
+		meta := book.Meta()
+		if meta.Valid && time.Since(meta.LastUpdated) <= marketOrderBookMaxAge {
+			bids, asks := book.GetCopy()
+			if side == "BUY" {
+				return asks, "stream", nil
+			}
+			return bids, "stream", nil
+		}
+	}
+
+	resp, err := s.newClient(nil, nil).CLOB.OrderBook(ctx, &clobtypes.BookRequest{TokenID: tokenID})
+	if err != nil {
+		return nil, "", apperrors.New(apperrors.ErrUpstream, "failed to fetch order book", err)
+	}
+	bids, asks, err := market.LevelsFromBook(resp)
+	if err != nil {
+		return nil, "", apperrors.New(apperrors.ErrUpstream, "invalid order book", err)
+	}
+	if side == "BUY" {
+		return asks, "rest", nil
+	}
+	return bids, "rest", nil
+}
+
+// awaitFills sums the fills reported for an immediate order, waiting briefly for
+// reports still in flight. pending is true if they did not cover size in time.
+func (s *GatewayService) awaitFills(ctx context.Context, tenantID, orderID string, size decimal.Decimal) (model.MarketOrderFill, bool) {
+	if s.fills == nil {
+		return marketOrderFill(decimal.Zero, decimal.Zero), true
+	}
+	timeout := time.NewTimer(marketOrderFillWait)
+	defer timeout.Stop()
+	ticker := time.NewTicker(marketOrderFillPoll)
+	defer ticker.Stop()
+	for {
+		filled, notional := decimal.Zero, decimal.Zero
+		for _, fill := range s.fills.ListFills(ctx, tenantID, model.FillFilter{OrderID: orderID}) {
+			if strings.EqualFold(fill.Status, "FAILED") {
+				continue
+			}
+			price, err1 := decimal.NewFromString(fill.Price)
+			qty, err2 := decimal.NewFromString(fill.Size)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			filled = filled.Add(qty)
+			notional = notional.Add(price.Mul(qty))
+		}
+		if filled.GreaterThanOrEqual(size) {
+			return marketOrderFill(filled, notional), false
+		}
+		// A FAK remainder killed by the exchange is final too
+		if update, ok := s.fills.GetOrderUpdate(tenantID, orderID); ok && strings.EqualFold(update.Type, market.OrderEventCancellation) {
+			return marketOrderFill(filled, notional), false
+		}
+
+		select {
+		case <-ctx.Done():
+			return marketOrderFill(filled, notional), true
+		case <-timeout.C:
+			return marketOrderFill(filled, notional), true
+		case <-ticker.C:
+		}
+	}
+}
+
+func marketOrderFill(size, notional decimal.Decimal) model.MarketOrderFill {
+	fill := model.MarketOrderFill{Size: size, Notional: notional}
+	if size.IsPositive() {
+		fill.AvgPrice = notional.Div(size).Round(6)
+	}
+	return fill
+}
+
+// validateMarketOrder checks a market order, normalizing it in place.
+func validateMarketOrder(req *model.MarketOrderRequest) error {
+	req.Side = strings.ToUpper(req.Side)
+	if req.Side != "BUY" && req.Side != "SELL" {
+		return apperrors.NewInvalidRequest("side must be BUY or SELL")
+	}
+	if req.Amount.IsNegative() || req.Size.IsNegative() || req.Amount.IsPositive() == req.Size.IsPositive() {
+		return apperrors.NewInvalidRequest("exactly one of amount or size is required")
+	}
+	if !req.WorstPrice.IsPositive() || req.WorstPrice.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+		return apperrors.NewInvalidRequest("worst_price must be between 0 and 1")
+	}
+	req.OrderType = strings.ToUpper(req.OrderType)
+	switch req.OrderType {
+	case "":
+		req.OrderType = string(clobtypes.OrderTypeFOK)
+	case string(clobtypes.OrderTypeFOK), string(clobtypes.OrderTypeFAK):
+	default:
+		return apperrors.NewInvalidRequest("order_type must be FOK or FAK")
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+func TestValidateMarketOrder(t *testing.T) {
+	req := model.MarketOrderRequest{TokenID: "tok", Side: "buy", Amount: decimal.NewFromInt(10), WorstPrice: decimal.RequireFromString("0.6")}
+	if err := validateMarketOrder(&req); err != nil {
+		t.Fatalf("expected a valid order, got %v", err)
+	}
+	if req.Side != "BUY" || req.OrderType != "FOK" {
+		t.Fatalf("expected side and order type to be normalized, got %+v", req)
+	}
+
+	both := req
+	both.Size = decimal.NewFromInt(5)
+	if err := validateMarketOrder(&both); err == nil {
+		t.Fatalf("expected amount and size together to be rejected")
+	}
+	gtc := req
+	gtc.OrderType = "GTC"
+	if err := validateMarketOrder(&gtc); err == nil {
+		t.Fatalf("expected a resting order type to be rejected")
+	}
+}
@@ -0,0 +1,1176 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/market"
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/pkg/apperrors"
+	"github.com/GoPolymarket/polygate/internal/pkg/logger"
+	"github.com/GoPolymarket/polymarket-go-sdk/pkg/clob/clobtypes"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	orderGroupActor   = "system:order_groups"
+	orderGroupTimeout = 10 * time.Second
+	// orderGroupCancelRetry is the first delay before a rejected leg cancel is re-sent;
+	// it doubles up to orderGroupCancelRetryMax
+	orderGroupCancelRetry    = 2 * time.Second
+	orderGroupCancelRetryMax = time.Minute
+	// maxFinishedGroups bounds the completed / cancelled groups kept in memory per tenant
+	maxFinishedGroups = 100
+	// entryLeg marks the bracket entry in a groupRef
+	entryLeg = -1
+)
+
+// OrderGroupRepo persists order groups; only groups still working, or with a leg
+// cancel pending, are loaded on startup.
+type OrderGroupRepo interface {
+	SaveOrderGroup(ctx context.Context, group *model.OrderGroup) error
+	LoadActiveOrderGroups(ctx context.Context) ([]*model.OrderGroup, error)
+}
+
+// OrderRouter places and cancels the legs of an order group through the tenant's CLOB client.
+type OrderRouter interface {
+	OrderPlacer
+	CancelOrder(ctx context.Context, tenant *model.Tenant, input model.CancelOrderInput) (*clobtypes.CancelResponse, error)
+}
+
+// groupRef locates the group (and leg) an exchange order or trigger belongs to.
+type groupRef struct {
+	tenantID string
+	groupID  string
+	leg      int // entryLeg for the bracket entry
+}
+
+// OrderGroupService 管理 OCO / 括号单：监听 user channel 的成交事件，
+// 一条腿成交（或条件单触发）即撤销其余腿；括号单的止盈 / 止损腿在入场单成交后才挂出。
+type OrderGroupService struct {
+	mu            sync.Mutex
+	groups        map[string]map[string]*model.OrderGroup // Key: TenantID -> ID
+	byOrder       map[string]groupRef                     // exchange order id
+	byConditional map[string]groupRef                     // conditional order id
+	orderFills    map[string]map[string]decimal.Decimal   // entry / leg order id -> fill id -> size
+	placedSize    map[string]decimal.Decimal              // size of leg orders placed to shrink a leg
+	repo          OrderGroupRepo
+	router        OrderRouter
+	conditional   *ConditionalService
+	fills         *market.FillStore
+	tm            *TenantManager
+	audit         AuditSink
+	now           func() time.Time
+	spawn         func(func())  // runs event follow-ups off the user stream goroutine
+	cancelRetry   time.Duration // first backoff of a rejected leg cancel
+	resizeMu      sync.Mutex    // one leg resize at a time, so a replacement is sized after the previous one
+	ctx           context.Context
+	cancel        context.CancelFunc
+	wg            sync.WaitGroup // spawned follow-ups, so Stop can wait for them
+}
+
+// NewOrderGroupService restores groups that were still working before a restart and
+// replays the fills they may have missed. Register it as a FillListener and as a
+// FireListener of the conditional service.
+func NewOrderGroupService(ctx context.Context, repo OrderGroupRepo, router OrderRouter, conditional *ConditionalService, fills *market.FillStore, tm *TenantManager, audit AuditSink) *OrderGroupService {
+	runCtx, cancel := context.WithCancel(context.Background())
+	s := &OrderGroupService{
+		groups:        make(map[string]map[string]*model.OrderGroup),
+		byOrder:       make(map[string]groupRef),
+		byConditional: make(map[string]groupRef),
+		orderFills:    make(map[string]map[string]decimal.Decimal),
+		placedSize:    make(map[string]decimal.Decimal),
+		repo:          repo,
+		router:        router,
+		conditional:   conditional,
+		fills:         fills,
+		tm:            tm,
+		audit:         audit,
+		now:           func() time.Time { return time.Now().UTC() },
+		spawn:         func(fn func()) { go fn() },
+		cancelRetry:   orderGroupCancelRetry,
+		ctx:           runCtx,
+		cancel:        cancel,
+	}
+	if repo == nil {
+		return s
+	}
+	groups, err := repo.LoadActiveOrderGroups(ctx)
+	if err != nil {
+		logger.Error("Failed to load order groups", "error", err)
+		return s
+	}
+	for _, g := range groups {
+		s.mu.Lock()
+		s.register(g)
+		s.mu.Unlock()
+	}
+	for _, g := range groups {
+		if g.EntryOrderID != "" {
+			s.catchUp(ctx, g.TenantID, g.EntryOrderID)
+		}
+		for _, leg := range g.Legs {
+			for _, orderID := range legOrderIDs(leg) {
+				s.catchUp(ctx, g.TenantID, orderID)
+			}
+		}
+	}
+	for _, g := range groups {
+		s.resumeCancels(g)
+	}
+	if len(groups) > 0 {
+		logger.Info("Order groups restored from storage", "count", len(groups))
+	}
+	return s
+}
+
+// Create places a new group: every OCO leg goes live at once (a leg that cannot be
+// placed unwinds the others); a bracket only places its entry.
+func (s *OrderGroupService) Create(ctx context.Context, tenant *model.Tenant, req model.OrderGroupRequest) (*model.OrderGroup, error) {
+	if err := validateOrderGroup(&req); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	group := &model.OrderGroup{
+		ID:        uuid.NewString(),
+		TenantID:  tenant.ID,
+		Type:      req.Type,
+		Entry:     req.Entry,
+		Legs:      make([]model.GroupLeg, 0, len(req.Legs)),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for i, leg := range req.Legs {
+		group.Legs = append(group.Legs, model.GroupLeg{Index: i, Request: leg, Status: model.GroupLegPending})
+	}
+
+	if group.Type == model.OrderGroupBracket {
+		resp, err := s.router.PlaceOrder(ctx, tenant, *req.Entry)
+		if err != nil {
+			return nil, err
+		}
+		group.EntryOrderID = resp.ID
+		group.Status = model.OrderGroupPendingEntry
+		s.mu.Lock()
+		s.register(group)
+		s.mu.Unlock()
+		s.save(tenant.ID, group.ID)
+		// The entry may have filled before it was indexed
+		s.catchUp(ctx, tenant.ID, resp.ID)
+		return s.Get(tenant.ID, group.ID)
+	}
+
+	group.Status = model.OrderGroupActive
+	s.mu.Lock()
+	s.register(group)
+	s.mu.Unlock()
+	for i := range group.Legs {
+		if err := s.openLeg(ctx, tenant, group.ID, i, decimal.Zero); err != nil {
+			s.unwind(ctx, tenant, group.ID)
+			return nil, err
+		}
+	}
+	s.save(tenant.ID, group.ID)
+	return s.Get(tenant.ID, group.ID)
+}
+
+// List returns the tenant's groups, newest first; status "" returns all.
+func (s *OrderGroupService) List(tenantID string, status model.OrderGroupStatus) []*model.OrderGroup {
+	s.mu.Lock()
+	out := make([]*model.OrderGroup, 0, len(s.groups[tenantID]))
+	for _, g := range s.groups[tenantID] {
+		if status != "" && g.Status != status {
+			continue
+		}
+		out = append(out, copyGroup(g))
+	}
+	s.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+// Get returns one of the tenant's groups.
+func (s *OrderGroupService) Get(tenantID, id string) (*model.OrderGroup, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.groups[tenantID][id]
+	if !ok {
+		return nil, apperrors.New(apperrors.ErrNotFound, "order group not found", nil)
+	}
+	return copyGroup(g), nil
+}
+
+// Cancel cancels the bracket entry (its exits are never armed) and every working leg.
+// A leg whose cancel failed is reported CANCEL_PENDING with the failure as its reason.
+func (s *OrderGroupService) Cancel(ctx context.Context, tenant *model.Tenant, id string) (*model.OrderGroup, error) {
+	s.mu.Lock()
+	g, ok := s.groups[tenant.ID][id]
+	if !ok {
+		s.mu.Unlock()
+		return nil, apperrors.New(apperrors.ErrNotFound, "order group not found", nil)
+	}
+	if g.Status.Terminal() {
+		s.mu.Unlock()
+		return nil, apperrors.NewInvalidRequest(fmt.Sprintf("order group is already %s", g.Status))
+	}
+	entryOrderID := ""
+	if g.Status == model.OrderGroupPendingEntry {
+		entryOrderID = g.EntryOrderID
+	}
+	open := s.closeLegs(g, -1, "group cancelled")
+	g.Status = model.OrderGroupCancelled
+	g.Reason = "cancelled by tenant"
+	g.UpdatedAt = s.now()
+	s.mu.Unlock()
+
+	if entryOrderID != "" {
+		if _, err := s.router.CancelOrder(ctx, tenant, model.CancelOrderInput{ID: entryOrderID}); err != nil {
+			logger.Error("Failed to cancel bracket entry", "tenant_id", tenant.ID, "group_id", id, "order_id", entryOrderID, "error", err)
+			s.mu.Lock()
+			g.Reason = fmt.Sprintf("entry cancel failed: %v", err)
+			s.mu.Unlock()
+		}
+	}
+	s.cancelLegs(ctx, tenant, id, open)
+	s.save(tenant.ID, id)
+	return s.Get(tenant.ID, id)
+}
+
+// OnFill accumulates the fills on a bracket entry or a leg.
+func (s *OrderGroupService) OnFill(fill *model.Fill) {
+	if fill == nil || fill.OrderID == "" || strings.EqualFold(fill.Status, "FAILED") {
+		return
+	}
+	size, err := decimal.NewFromString(fill.Size)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	ref, ok := s.byOrder[fill.OrderID]
+	if !ok || ref.tenantID != fill.TenantID {
+		s.mu.Unlock()
+		return
+	}
+	// Status updates resend the same fill; key by fill id so it only counts once
+	fills, ok := s.orderFills[fill.OrderID]
+	if !ok {
+		fills = make(map[string]decimal.Decimal)
+		s.orderFills[fill.OrderID] = fills
+	}
+	fills[fill.ID] = size
+	total := decimal.Zero
+	for _, f := range fills {
+		total = total.Add(f)
+	}
+	s.mu.Unlock()
+
+	if ref.leg == entryLeg {
+		s.entryProgress(ref, total, false)
+		return
+	}
+	s.legProgress(ref, fill.OrderID, total, false)
+}
+
+// OnOrderUpdate handles matched size and cancellations reported on the user channel.
+func (s *OrderGroupService) OnOrderUpdate(update *model.OrderUpdate) {
+	if update == nil || update.OrderID == "" {
+		return
+	}
+	s.mu.Lock()
+	ref, ok := s.byOrder[update.OrderID]
+	s.mu.Unlock()
+	if !ok || ref.tenantID != update.TenantID {
+		return
+	}
+
+	matched, err := decimal.NewFromString(update.SizeMatched)
+	if err != nil {
+		matched = decimal.Zero
+	}
+	ended := strings.EqualFold(update.Type, market.OrderEventCancellation)
+	if ref.leg == entryLeg {
+		s.entryProgress(ref, matched, ended)
+		return
+	}
+	s.legProgress(ref, update.OrderID, matched, ended)
+}
+
+// BeforeFire completes the group of a conditional leg that is about to place its order.
+// The sibling legs are cancelled first so the exit never competes with them for the
+// position; a trigger whose group is already finished is vetoed.
+func (s *OrderGroupService) BeforeFire(ctx context.Context, order *model.ConditionalOrder) error {
+	s.mu.Lock()
+	ref, ok := s.byConditional[order.ID]
+	s.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	others, err := s.complete(ref)
+	if err != nil {
+		return err
+	}
+	tenant, ok := s.tm.GetTenantByID(ref.tenantID)
+	if !ok {
+		return fmt.Errorf("tenant not found")
+	}
+	s.cancelLegs(ctx, tenant, ref.groupID, others)
+	s.save(ref.tenantID, ref.groupID)
+	return nil
+}
+
+// Stop waits for follow-ups already running. Exits are no longer armed once it
+// has begun; sibling cancels still go out.
+func (s *OrderGroupService) Stop() {
+	s.mu.Lock()
+	s.cancel() // under s.mu: run sees it before adding to wg
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// run starts a follow-up through spawn, or inline once Stop has begun.
+func (s *OrderGroupService) run(fn func()) {
+	s.mu.Lock()
+	stopping := s.ctx.Err() != nil
+	if !stopping {
+		s.wg.Add(1)
+	}
+	s.mu.Unlock()
+	if stopping {
+		fn()
+		return
+	}
+	s.spawn(func() {
+		defer s.wg.Done()
+		fn()
+	})
+}
+
+// entryProgress arms the bracket exits once the entry is filled, or once it ended
+// partially filled (exits sized to the filled amount).
+func (s *OrderGroupService) entryProgress(ref groupRef, filled decimal.Decimal, ended bool) {
+	s.mu.Lock()
+	g, ok := s.groups[ref.tenantID][ref.groupID]
+	if !ok || g.Status != model.OrderGroupPendingEntry {
+		s.mu.Unlock()
+		return
+	}
+	changed := false
+	if filled.GreaterThan(g.EntryFilled) {
+		g.EntryFilled = filled
+		changed = true
+	}
+	arm := false
+	switch {
+	case g.EntryFilled.GreaterThanOrEqual(g.Entry.Size), ended && g.EntryFilled.IsPositive():
+		g.Status = model.OrderGroupActive
+		arm = true
+	case ended:
+		g.Status = model.OrderGroupCancelled
+		g.Reason = "entry cancelled before any fill"
+		s.closeLegs(g, -1, "entry cancelled")
+	default:
+		if !changed {
+			s.mu.Unlock()
+			return
+		}
+	}
+	g.UpdatedAt = s.now()
+	size := g.EntryFilled
+	s.mu.Unlock()
+
+	s.save(ref.tenantID, ref.groupID)
+	if arm {
+		s.run(func() { s.arm(ref.tenantID, ref.groupID, size) })
+	}
+}
+
+// arm places the exit legs of a bracket whose entry filled.
+func (s *OrderGroupService) arm(tenantID, groupID string, size decimal.Decimal) {
+	ctx, cancel := context.WithTimeout(context.Background(), orderGroupTimeout)
+	defer cancel()
+
+	s.mu.Lock()
+	g, ok := s.groups[tenantID][groupID]
+	legs := 0
+	if ok {
+		legs = len(g.Legs)
+	}
+	s.mu.Unlock()
+	tenant, found := s.tm.GetTenantByID(tenantID)
+
+	armed, failed := 0, 0
+	for i := 0; i < legs; i++ {
+		var err error
+		switch {
+		case !found:
+			err = fmt.Errorf("tenant not found")
+		case s.ctx.Err() != nil:
+			// No exit may be placed after the shutdown sweep
+			err = fmt.Errorf("gateway shutting down")
+		default:
+			err = s.openLeg(ctx, tenant, groupID, i, size)
+		}
+		if err == nil {
+			armed++
+			continue
+		}
+		failed++
+		logger.Error("Failed to arm bracket exit", "tenant_id", tenantID, "group_id", groupID, "leg", i, "error", err)
+		s.mu.Lock()
+		if leg := &g.Legs[i]; leg.Status == model.GroupLegPending {
+			leg.Status = model.GroupLegFailed
+			leg.Reason = err.Error()
+		}
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	if ok && g.Status == model.OrderGroupActive && armed == 0 {
+		g.Status = model.OrderGroupFailed
+		g.Reason = "no exit leg could be armed"
+	}
+	if ok {
+		g.UpdatedAt = s.now()
+	}
+	s.mu.Unlock()
+	s.save(tenantID, groupID)
+
+	if s.audit != nil {
+		s.audit.Log(systemAuditEntry(tenantID, "order_group_armed", map[string]interface{}{
+			"actor":        orderGroupActor,
+			"group_id":     groupID,
+			"entry_filled": size.String(),
+			"armed":        armed,
+			"failed":       failed,
+		}))
+	}
+}
+
+// openLeg places one pending leg (size caps the leg when positive). A leg placed
+// while its group finished is cancelled right away.
+func (s *OrderGroupService) openLeg(ctx context.Context, tenant *model.Tenant, groupID string, idx int, size decimal.Decimal) error {
+	s.mu.Lock()
+	g, ok := s.groups[tenant.ID][groupID]
+	if !ok || g.Status.Terminal() || g.Legs[idx].Status != model.GroupLegPending {
+		s.mu.Unlock()
+		return nil
+	}
+	req := g.Legs[idx].Request
+	reduced := g.Legs[idx].Reduced
+	s.mu.Unlock()
+	if size.IsPositive() && size.LessThan(req.Order.Size) {
+		req.Order.Size = size
+	}
+	// Fills on legs armed before this one already closed part of the position
+	place := req
+	place.Order.Size = req.Order.Size.Sub(reduced)
+	if !place.Order.Size.IsPositive() {
+		return fmt.Errorf("the other legs already filled its size")
+	}
+
+	var orderID, conditionalID string
+	if req.IsConditional() {
+		cond, err := s.conditional.Create(ctx, tenant, place.Conditional())
+		if err != nil {
+			return err
+		}
+		conditionalID = cond.ID
+	} else {
+		resp, err := s.router.PlaceOrder(ctx, tenant, place.Order)
+		if err != nil {
+			return err
+		}
+		orderID = resp.ID
+		if reduced.IsPositive() {
+			s.mu.Lock()
+			s.placedSize[orderID] = place.Order.Size
+			s.mu.Unlock()
+		}
+	}
+
+	s.mu.Lock()
+	leg := &g.Legs[idx]
+	leg.Request = req
+	leg.OrderID = orderID
+	leg.ConditionalID = conditionalID
+	ref := groupRef{tenantID: tenant.ID, groupID: groupID, leg: idx}
+	if orderID != "" {
+		leg.Orders = map[string]decimal.Decimal{orderID: decimal.Zero}
+		s.byOrder[orderID] = ref
+	}
+	if conditionalID != "" {
+		s.byConditional[conditionalID] = ref
+	}
+	orphan := g.Status.Terminal()
+	if orphan {
+		leg.Status = model.GroupLegCancelled
+		leg.Reason = fmt.Sprintf("group %s while the leg was placed", strings.ToLower(string(g.Status)))
+	} else {
+		leg.Status = model.GroupLegOpen
+	}
+	placed := *leg
+	s.mu.Unlock()
+
+	if orphan {
+		s.cancelLegs(ctx, tenant, groupID, []model.GroupLeg{placed})
+		return nil
+	}
+	if orderID != "" {
+		s.catchUp(ctx, tenant.ID, orderID)
+	}
+	return nil
+}
+
+// legProgress records the size matched on one of a limit leg's orders. A leg that
+// covered its whole size completes the group; a partial fill shrinks the other legs
+// by the size it closed. A leg whose working order is cancelled outside the group
+// stops there.
+func (s *OrderGroupService) legProgress(ref groupRef, orderID string, matched decimal.Decimal, ended bool) {
+	s.mu.Lock()
+	g, ok := s.groups[ref.tenantID][ref.groupID]
+	if !ok || g.Status != model.OrderGroupActive || g.Legs[ref.leg].Status != model.GroupLegOpen {
+		pending := ok && g.Legs[ref.leg].Status == model.GroupLegCancelPending
+		s.mu.Unlock()
+		if pending {
+			s.pendingProgress(ref, orderID, matched, ended)
+		}
+		return
+	}
+	leg := &g.Legs[ref.leg]
+	delta := matched.Sub(leg.Orders[orderID])
+	if delta.IsPositive() {
+		if leg.Orders == nil {
+			leg.Orders = make(map[string]decimal.Decimal)
+		}
+		leg.Orders[orderID] = matched
+		leg.Filled = leg.Filled.Add(delta)
+		g.UpdatedAt = s.now()
+	}
+	working := orderID == leg.OrderID
+	full := !leg.Remaining().IsPositive()
+	s.mu.Unlock()
+
+	switch {
+	case full:
+		s.legFilled(ref)
+		return
+	case delta.IsPositive():
+		s.shrink(ref, orderID, delta)
+	}
+	if ended && working {
+		s.legCancelled(ref)
+	}
+}
+
+// shrink takes size off every working leg after a partial fill on one of ref's
+// orders: the other legs are reduced by it (and closed once nothing is left), and
+// orders resting for more than their leg still needs are replaced.
+func (s *OrderGroupService) shrink(ref groupRef, orderID string, size decimal.Decimal) {
+	s.mu.Lock()
+	g, ok := s.groups[ref.tenantID][ref.groupID]
+	if !ok || g.Status != model.OrderGroupActive {
+		s.mu.Unlock()
+		return
+	}
+	closed := make([]model.GroupLeg, 0)
+	resize := make([]int, 0)
+	for i := range g.Legs {
+		leg := &g.Legs[i]
+		if leg.Status != model.GroupLegOpen && leg.Status != model.GroupLegPending {
+			continue
+		}
+		if i == ref.leg {
+			// The leg's own fill already counts in Filled; only an order it
+			// replaced can have left the working one too large
+			if orderID != leg.OrderID && leg.Status == model.GroupLegOpen {
+				resize = append(resize, i)
+			}
+			continue
+		}
+		leg.Reduced = leg.Reduced.Add(size)
+		switch {
+		case !leg.Remaining().IsPositive():
+			if leg.Status == model.GroupLegOpen {
+				closed = append(closed, *leg)
+			}
+			leg.Status = model.GroupLegCancelled
+			leg.Reason = fmt.Sprintf("covered by fills on leg %d", ref.leg)
+		case leg.Status == model.GroupLegOpen:
+			resize = append(resize, i)
+		}
+	}
+	g.UpdatedAt = s.now()
+	s.mu.Unlock()
+	s.save(ref.tenantID, ref.groupID)
+
+	logger.Info("Order group leg partially filled", "tenant_id", ref.tenantID, "group_id", ref.groupID, "leg", ref.leg, "size", size, "closing", len(closed), "resizing", len(resize))
+	s.run(func() {
+		tenant, ok := s.tm.GetTenantByID(ref.tenantID)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), orderGroupTimeout)
+		defer cancel()
+		s.cancelLegs(ctx, tenant, ref.groupID, closed)
+		for _, idx := range resize {
+			s.resizeLeg(ctx, tenant, ref.groupID, idx)
+		}
+		s.save(ref.tenantID, ref.groupID)
+	})
+}
+
+// resizeLeg brings a working leg down to its remaining size: a trigger is resized
+// in place, a resting order is cancelled and placed again for the remainder. A
+// cancel that fails (most likely the order just filled) leaves the order as it is.
+func (s *OrderGroupService) resizeLeg(ctx context.Context, tenant *model.Tenant, groupID string, idx int) {
+	s.resizeMu.Lock()
+	defer s.resizeMu.Unlock()
+
+	s.mu.Lock()
+	g, ok := s.groups[tenant.ID][groupID]
+	if !ok || g.Status != model.OrderGroupActive || g.Legs[idx].Status != model.GroupLegOpen {
+		s.mu.Unlock()
+		return
+	}
+	leg := g.Legs[idx]
+	remaining := leg.Remaining()
+	resting := leg.Request.Order.Size
+	if size, ok := s.placedSize[leg.OrderID]; ok {
+		resting = size
+	}
+	resting = resting.Sub(leg.Orders[leg.OrderID])
+	s.mu.Unlock()
+	if !remaining.IsPositive() || (leg.OrderID != "" && !resting.GreaterThan(remaining)) {
+		return
+	}
+
+	if leg.ConditionalID != "" {
+		if _, err := s.conditional.Resize(tenant.ID, leg.ConditionalID, remaining); err != nil {
+			logger.Warn("Failed to resize order group trigger", "tenant_id", tenant.ID, "group_id", groupID, "leg", idx, "error", err)
+		}
+		return
+	}
+	if _, err := s.router.CancelOrder(ctx, tenant, model.CancelOrderInput{ID: leg.OrderID}); err != nil {
+		logger.Warn("Failed to cancel order group leg for resize", "tenant_id", tenant.ID, "group_id", groupID, "leg", idx, "error", err)
+		return
+	}
+
+	s.mu.Lock()
+	current := &g.Legs[idx]
+	if g.Status != model.OrderGroupActive || current.Status != model.GroupLegOpen || current.OrderID != leg.OrderID {
+		s.mu.Unlock()
+		return
+	}
+	order := current.Request.Order
+	order.Size = current.Remaining()
+	s.mu.Unlock()
+	if !order.Size.IsPositive() {
+		return
+	}
+
+	resp, err := s.router.PlaceOrder(ctx, tenant, order)
+	s.mu.Lock()
+	current = &g.Legs[idx]
+	if err != nil {
+		current.Status = model.GroupLegFailed
+		current.Reason = fmt.Sprintf("resize to %s failed: %v", order.Size, err)
+		g.UpdatedAt = s.now()
+		s.mu.Unlock()
+		logger.Error("Failed to replace order group leg", "tenant_id", tenant.ID, "group_id", groupID, "leg", idx, "error", err)
+		return
+	}
+	ref := groupRef{tenantID: tenant.ID, groupID: groupID, leg: idx}
+	current.OrderID = resp.ID
+	current.Orders[resp.ID] = decimal.Zero
+	s.placedSize[resp.ID] = order.Size
+	s.byOrder[resp.ID] = ref
+	orphan := g.Status.Terminal() || current.Status != model.GroupLegOpen
+	placed := *current
+	g.UpdatedAt = s.now()
+	s.mu.Unlock()
+
+	if orphan {
+		s.cancelLegs(ctx, tenant, groupID, []model.GroupLeg{placed})
+		return
+	}
+	s.catchUp(ctx, tenant.ID, resp.ID)
+}
+
+// legFilled completes the group of a limit leg that filled its whole size.
+func (s *OrderGroupService) legFilled(ref groupRef) {
+	others, err := s.complete(ref)
+	if err != nil {
+		return
+	}
+	s.run(func() {
+		tenant, ok := s.tm.GetTenantByID(ref.tenantID)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), orderGroupTimeout)
+		defer cancel()
+		s.cancelLegs(ctx, tenant, ref.groupID, others)
+		s.save(ref.tenantID, ref.groupID)
+	})
+}
+
+// complete marks the leg executed and the group done, returning the legs to cancel.
+func (s *OrderGroupService) complete(ref groupRef) ([]model.GroupLeg, error) {
+	s.mu.Lock()
+	g, ok := s.groups[ref.tenantID][ref.groupID]
+	if !ok {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("order group %s not found", ref.groupID)
+	}
+	if g.Status != model.OrderGroupActive {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("order group %s is already %s", g.ID, g.Status)
+	}
+	leg := &g.Legs[ref.leg]
+	if leg.Status != model.GroupLegOpen {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("order group %s leg %d is %s", g.ID, ref.leg, leg.Status)
+	}
+	leg.Status = model.GroupLegExecuted
+	others := s.closeLegs(g, ref.leg, fmt.Sprintf("leg %d executed", ref.leg))
+	g.Status = model.OrderGroupDone
+	g.UpdatedAt = s.now()
+	s.pruneFinished(ref.tenantID)
+	s.mu.Unlock()
+
+	s.save(ref.tenantID, ref.groupID)
+	logger.Info("Order group leg executed", "tenant_id", ref.tenantID, "group_id", ref.groupID, "leg", ref.leg, "cancelling", len(others))
+	if s.audit != nil {
+		s.audit.Log(systemAuditEntry(ref.tenantID, "order_group_completed", map[string]interface{}{
+			"actor":     orderGroupActor,
+			"group_id":  ref.groupID,
+			"type":      g.Type,
+			"leg":       ref.leg,
+			"cancelled": len(others),
+		}))
+	}
+	return others, nil
+}
+
+// legCancelled records a leg cancelled outside the group (e.g. via DELETE /v1/orders/:id).
+func (s *OrderGroupService) legCancelled(ref groupRef) {
+	s.mu.Lock()
+	g, ok := s.groups[ref.tenantID][ref.groupID]
+	if !ok || g.Status != model.OrderGroupActive || g.Legs[ref.leg].Status != model.GroupLegOpen {
+		s.mu.Unlock()
+		return
+	}
+	g.Legs[ref.leg].Status = model.GroupLegCancelled
+	g.Legs[ref.leg].Reason = "cancelled outside the group"
+	working := false
+	for _, leg := range g.Legs {
+		if leg.Status == model.GroupLegOpen || leg.Status == model.GroupLegPending {
+			working = true
+		}
+	}
+	if !working {
+		g.Status = model.OrderGroupCancelled
+		g.Reason = "all legs cancelled"
+		s.pruneFinished(ref.tenantID)
+	}
+	g.UpdatedAt = s.now()
+	s.mu.Unlock()
+
+	s.save(ref.tenantID, ref.groupID)
+}
+
+// closeLegs marks every leg but skip as cancelled and returns the ones that were
+// live upstream; the caller holds s.mu.
+func (s *OrderGroupService) closeLegs(g *model.OrderGroup, skip int, reason string) []model.GroupLeg {
+	open := make([]model.GroupLeg, 0, len(g.Legs))
+	for i := range g.Legs {
+		leg := &g.Legs[i]
+		if i == skip || (leg.Status != model.GroupLegOpen && leg.Status != model.GroupLegPending) {
+			continue
+		}
+		if leg.Status == model.GroupLegOpen {
+			open = append(open, *leg)
+		}
+		leg.Status = model.GroupLegCancelled
+		leg.Reason = reason
+	}
+	return open
+}
+
+// cancelLegs cancels legs upstream. A limit leg whose cancel the exchange rejects
+// goes CANCEL_PENDING and the cancel is retried until it is confirmed.
+func (s *OrderGroupService) cancelLegs(ctx context.Context, tenant *model.Tenant, groupID string, legs []model.GroupLeg) {
+	for _, leg := range legs {
+		if leg.ConditionalID != "" {
+			// Triggers are held here: one that is no longer active cannot fire for a finished group
+			if _, err := s.conditional.Cancel(ctx, tenant.ID, leg.ConditionalID); err != nil {
+				logger.Warn("Order group trigger already ended", "tenant_id", tenant.ID, "group_id", groupID, "leg", leg.Index, "error", err)
+			}
+		}
+		if leg.OrderID == "" {
+			continue
+		}
+		if _, err := s.router.CancelOrder(ctx, tenant, model.CancelOrderInput{ID: leg.OrderID}); err != nil {
+			s.cancelFailed(tenant, groupID, leg.Index, err)
+		}
+	}
+}
+
+// cancelFailed marks a leg whose cancel was rejected CANCEL_PENDING, audits it and
+// starts retrying the cancel.
+func (s *OrderGroupService) cancelFailed(tenant *model.Tenant, groupID string, idx int, err error) {
+	s.mu.Lock()
+	g, ok := s.groups[tenant.ID][groupID]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	leg := &g.Legs[idx]
+	leg.Status = model.GroupLegCancelPending
+	leg.Reason = fmt.Sprintf("cancel failed: %v", err)
+	orderID := leg.OrderID
+	g.UpdatedAt = s.now()
+	s.mu.Unlock()
+
+	logger.Error("Failed to cancel order group leg", "tenant_id", tenant.ID, "group_id", groupID, "leg", idx, "order_id", orderID, "error", err)
+	s.save(tenant.ID, groupID)
+	if s.audit != nil {
+		s.audit.Log(systemAuditEntry(tenant.ID, "order_group_cancel_failed", map[string]interface{}{
+			"actor":    orderGroupActor,
+			"group_id": groupID,
+			"leg":      idx,
+			"order_id": orderID,
+			"error":    err.Error(),
+		}))
+	}
+	s.run(func() { s.retryCancel(tenant, groupID, idx) })
+}
+
+// resumeCancels restarts the retries of the legs a restored group still had pending.
+func (s *OrderGroupService) resumeCancels(g *model.OrderGroup) {
+	tenant, ok := s.tm.GetTenantByID(g.TenantID)
+	if !ok {
+		return
+	}
+	for _, leg := range g.Legs {
+		if leg.Status == model.GroupLegCancelPending {
+			idx := leg.Index
+			s.run(func() { s.retryCancel(tenant, g.ID, idx) })
+		}
+	}
+}
+
+// retryCancel re-sends the cancel of a CANCEL_PENDING leg, backing off, until the
+// exchange accepts it or the order ends on its own. It gives up when the service
+// stops; the persisted leg resumes it on the next start.
+func (s *OrderGroupService) retryCancel(tenant *model.Tenant, groupID string, idx int) {
+	delay := s.cancelRetry
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		s.mu.Lock()
+		g, ok := s.groups[tenant.ID][groupID]
+		if !ok || g.Legs[idx].Status != model.GroupLegCancelPending {
+			s.mu.Unlock()
+			return
+		}
+		orderID := g.Legs[idx].OrderID
+		s.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(s.ctx, orderGroupTimeout)
+		_, err := s.router.CancelOrder(ctx, tenant, model.CancelOrderInput{ID: orderID})
+		cancel()
+		if err == nil {
+			s.settlePending(groupRef{tenantID: tenant.ID, groupID: groupID, leg: idx}, model.GroupLegCancelled, fmt.Sprintf("cancelled on retry %d", attempt))
+			return
+		}
+		logger.Warn("Order group leg cancel still failing", "tenant_id", tenant.ID, "group_id", groupID, "leg", idx, "order_id", orderID, "attempt", attempt, "error", err)
+		s.mu.Lock()
+		if g.Legs[idx].Status == model.GroupLegCancelPending {
+			g.Legs[idx].Reason = fmt.Sprintf("cancel failed (retry %d): %v", attempt, err)
+		}
+		s.mu.Unlock()
+		delay = min(delay*2, orderGroupCancelRetryMax)
+	}
+}
+
+// pendingProgress follows a CANCEL_PENDING leg until its order ends: fills that
+// slipped in before the cancel are still recorded on the leg (and audited), and a
+// cancellation or a full fill settles it.
+func (s *OrderGroupService) pendingProgress(ref groupRef, orderID string, matched decimal.Decimal, ended bool) {
+	s.mu.Lock()
+	g, ok := s.groups[ref.tenantID][ref.groupID]
+	if !ok || g.Legs[ref.leg].Status != model.GroupLegCancelPending {
+		s.mu.Unlock()
+		return
+	}
+	leg := &g.Legs[ref.leg]
+	delta := matched.Sub(leg.Orders[orderID])
+	if delta.IsPositive() {
+		if leg.Orders == nil {
+			leg.Orders = make(map[string]decimal.Decimal)
+		}
+		leg.Orders[orderID] = matched
+		leg.Filled = leg.Filled.Add(delta)
+		g.UpdatedAt = s.now()
+	}
+	full := !leg.Remaining().IsPositive()
+	working := orderID == leg.OrderID
+	s.mu.Unlock()
+
+	if delta.IsPositive() {
+		logger.Error("Order group leg filled while its cancel was pending", "tenant_id", ref.tenantID, "group_id", ref.groupID, "leg", ref.leg, "order_id", orderID, "size", delta)
+		if s.audit != nil {
+			s.audit.Log(systemAuditEntry(ref.tenantID, "order_group_leg_filled_after_cancel", map[string]interface{}{
+				"actor":    orderGroupActor,
+				"group_id": ref.groupID,
+				"leg":      ref.leg,
+				"order_id": orderID,
+				"size":     delta.String(),
+			}))
+		}
+	}
+	switch {
+	case full:
+		s.settlePending(ref, model.GroupLegExecuted, "filled before its cancel went through")
+	case ended && working:
+		s.settlePending(ref, model.GroupLegCancelled, "cancel confirmed by the exchange")
+	case delta.IsPositive():
+		s.save(ref.tenantID, ref.groupID)
+	}
+}
+
+// settlePending ends a CANCEL_PENDING leg, which stops its retries.
+func (s *OrderGroupService) settlePending(ref groupRef, status model.GroupLegStatus, reason string) {
+	s.mu.Lock()
+	g, ok := s.groups[ref.tenantID][ref.groupID]
+	if !ok || g.Legs[ref.leg].Status != model.GroupLegCancelPending {
+		s.mu.Unlock()
+		return
+	}
+	g.Legs[ref.leg].Status = status
+	g.Legs[ref.leg].Reason = reason
+	g.UpdatedAt = s.now()
+	s.pruneFinished(ref.tenantID)
+	s.mu.Unlock()
+
+	logger.Info("Order group leg cancel settled", "tenant_id", ref.tenantID, "group_id", ref.groupID, "leg", ref.leg, "status", status)
+	s.save(ref.tenantID, ref.groupID)
+}
+
+// unwind drops a group whose OCO legs could not all be placed, cancelling the placed ones.
+func (s *OrderGroupService) unwind(ctx context.Context, tenant *model.Tenant, groupID string) {
+	s.mu.Lock()
+	g, ok := s.groups[tenant.ID][groupID]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	g.Status = model.OrderGroupFailed
+	open := s.closeLegs(g, -1, "group could not be placed")
+	s.mu.Unlock()
+
+	s.cancelLegs(ctx, tenant, groupID, open)
+
+	// A leg still live upstream keeps the group, so its cancel is retried and shown
+	s.mu.Lock()
+	pending := g.CancelPending()
+	if !pending {
+		s.forget(g)
+	}
+	s.mu.Unlock()
+	if pending {
+		s.save(tenant.ID, groupID)
+	}
+}
+
+// catchUp replays fills recorded for orderID before it was indexed.
+func (s *OrderGroupService) catchUp(ctx context.Context, tenantID, orderID string) {
+	if s.fills == nil {
+		return
+	}
+	for _, fill := range s.fills.ListFills(ctx, tenantID, model.FillFilter{OrderID: orderID}) {
+		s.OnFill(fill)
+	}
+	if update, ok := s.fills.GetOrderUpdate(tenantID, orderID); ok {
+		s.OnOrderUpdate(update)
+	}
+}
+
+// register stores and indexes a group; the caller holds s.mu.
+func (s *OrderGroupService) register(g *model.OrderGroup) {
+	groups, ok := s.groups[g.TenantID]
+	if !ok {
+		groups = make(map[string]*model.OrderGroup)
+		s.groups[g.TenantID] = groups
+	}
+	groups[g.ID] = g
+	if g.EntryOrderID != "" {
+		s.byOrder[g.EntryOrderID] = groupRef{tenantID: g.TenantID, groupID: g.ID, leg: entryLeg}
+	}
+	for _, leg := range g.Legs {
+		ref := groupRef{tenantID: g.TenantID, groupID: g.ID, leg: leg.Index}
+		for _, orderID := range legOrderIDs(leg) {
+			s.byOrder[orderID] = ref
+		}
+		if leg.ConditionalID != "" {
+			s.byConditional[leg.ConditionalID] = ref
+		}
+	}
+}
+
+// forget removes a group and its indexes; the caller holds s.mu.
+func (s *OrderGroupService) forget(g *model.OrderGroup) {
+	delete(s.groups[g.TenantID], g.ID)
+	delete(s.orderFills, g.EntryOrderID)
+	delete(s.byOrder, g.EntryOrderID)
+	for _, leg := range g.Legs {
+		for _, orderID := range legOrderIDs(leg) {
+			delete(s.orderFills, orderID)
+			delete(s.placedSize, orderID)
+			delete(s.byOrder, orderID)
+		}
+		delete(s.byConditional, leg.ConditionalID)
+	}
+}
+
+// pruneFinished drops the oldest finished groups past maxFinishedGroups; the caller holds s.mu.
+func (s *OrderGroupService) pruneFinished(tenantID string) {
+	finished := make([]*model.OrderGroup, 0)
+	for _, g := range s.groups[tenantID] {
+		if g.Status.Terminal() && !g.CancelPending() {
+			finished = append(finished, g)
+		}
+	}
+	if len(finished) <= maxFinishedGroups {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].UpdatedAt.Before(finished[j].UpdatedAt) })
+	for _, g := range finished[:len(finished)-maxFinishedGroups] {
+		s.forget(g)
+	}
+}
+
+// save persists the current state of a group.
+func (s *OrderGroupService) save(tenantID, groupID string) {
+	if s.repo == nil {
+		return
+	}
+	s.mu.Lock()
+	g, ok := s.groups[tenantID][groupID]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	snapshot := copyGroup(g)
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), conditionalRepoTimeout)
+	defer cancel()
+	if err := s.repo.SaveOrderGroup(ctx, snapshot); err != nil {
+		logger.Error("Failed to persist order group", "tenant_id", tenantID, "id", groupID, "status", snapshot.Status, "error", err)
+	}
+}
+
+// legOrderIDs lists every exchange order placed for a leg, the working one included.
+func legOrderIDs(leg model.GroupLeg) []string {
+	ids := make([]string, 0, len(leg.Orders)+1)
+	if leg.OrderID != "" {
+		ids = append(ids, leg.OrderID)
+	}
+	for id := range leg.Orders {
+		if id != leg.OrderID {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func copyGroup(g *model.OrderGroup) *model.OrderGroup {
+	copied := *g
+	copied.Legs = append([]model.GroupLeg(nil), g.Legs...)
+	for i, leg := range copied.Legs {
+		if leg.Orders != nil {
+			orders := make(map[string]decimal.Decimal, len(leg.Orders))
+			for id, matched := range leg.Orders {
+				orders[id] = matched
+			}
+			copied.Legs[i].Orders = orders
+		}
+	}
+	if g.Entry != nil {
+		entry := *g.Entry
+		copied.Entry = &entry
+	}
+	return &copied
+}
+
+// validateOrderGroup checks the group shape and every leg, normalizing them in place.
+func validateOrderGroup(req *model.OrderGroupRequest) error {
+	switch req.Type {
+	case model.OrderGroupOCO:
+		if req.Entry != nil {
+			return apperrors.NewInvalidRequest("entry is only valid for BRACKET groups")
+		}
+		if len(req.Legs) < 2 {
+			return apperrors.NewInvalidRequest("an OCO group needs at least two legs")
+		}
+	case model.OrderGroupBracket:
+		if req.Entry == nil {
+			return apperrors.NewInvalidRequest("a BRACKET group needs an entry order")
+		}
+		if err := validateGroupOrder(req.Entry); err != nil {
+			return err
+		}
+	default:
+		return apperrors.NewInvalidRequest(fmt.Sprintf("unknown order group type %q", req.Type))
+	}
+	if len(req.Legs) > model.MaxOrderGroupLegs {
+		return apperrors.NewInvalidRequest(fmt.Sprintf("too many legs (max %d)", model.MaxOrderGroupLegs))
+	}
+
+	for i := range req.Legs {
+		leg := &req.Legs[i]
+		if err := validateGroupOrder(&leg.Order); err != nil {
+			return apperrors.NewInvalidRequest(fmt.Sprintf("leg %d: %s", i, err.Error()))
+		}
+		if req.Type == model.OrderGroupBracket && (leg.Order.TokenID != req.Entry.TokenID || leg.Order.Side == req.Entry.Side) {
+			return apperrors.NewInvalidRequest(fmt.Sprintf("leg %d: bracket exits must trade the entry's token on the opposite side", i))
+		}
+		if !leg.IsConditional() {
+			if !leg.TriggerPrice.IsZero() || !leg.TrailAmount.IsZero() || leg.TriggerSource != "" {
+				return apperrors.NewInvalidRequest(fmt.Sprintf("leg %d: trigger fields need a conditional type", i))
+			}
+			continue
+		}
+		cond := leg.Conditional()
+		if err := validateConditional(&cond); err != nil {
+			return apperrors.NewInvalidRequest(fmt.Sprintf("leg %d: %s", i, err.Error()))
+		}
+		leg.TriggerSource = cond.TriggerSource
+	}
+	return nil
+}
+
+// validateGroupOrder checks an order the gateway will sign later.
+func validateGroupOrder(order *model.OrderRequest) error {
+	if order.Signature != "" || order.Signable != nil || order.L2 != nil {
+		return apperrors.NewInvalidRequest("order group orders are signed by the gateway; signature, signable and l2 are not accepted")
+	}
+	order.Side = strings.ToUpper(order.Side)
+	if !order.Price.IsPositive() || !order.Size.IsPositive() {
+		return apperrors.NewInvalidRequest("order price and size must be positive")
+	}
+	return nil
+}
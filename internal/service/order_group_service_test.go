@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/config"
+	"github.com/GoPolymarket/polygate/internal/market"
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polymarket-go-sdk/pkg/clob/clobtypes"
+	"github.com/shopspring/decimal"
+)
+
+type fakeRouter struct {
+	placed    []model.OrderRequest
+	cancelled []string
+	failAfter int   // reject every placement after this many (0: never)
+	cancelErr error // returned by every cancel while set
+}
+
+func (r *fakeRouter) PlaceOrder(ctx context.Context, tenant *model.Tenant, req model.OrderRequest) (*clobtypes.OrderResponse, error) {
+	if r.failAfter > 0 && len(r.placed) >= r.failAfter {
+		return nil, errors.New("risk reject: max open orders")
+	}
+	r.placed = append(r.placed, req)
+	return &clobtypes.OrderResponse{ID: fmt.Sprintf("0x%d", len(r.placed))}, nil
+}
+
+func (r *fakeRouter) CancelOrder(ctx context.Context, tenant *model.Tenant, input model.CancelOrderInput) (*clobtypes.CancelResponse, error) {
+	if r.cancelErr != nil {
+		return nil, r.cancelErr
+	}
+	r.cancelled = append(r.cancelled, input.ID)
+	return &clobtypes.CancelResponse{}, nil
+}
+
+type groupFixture struct {
+	tenant      *model.Tenant
+	router      *fakeRouter
+	fills       *market.FillStore
+	marketSvc   *market.MarketService
+	conditional *ConditionalService
+	groups      *OrderGroupService
+}
+
+func newGroupFixture() *groupFixture {
+	ctx := context.Background()
+	tm := NewTenantManager(&config.Config{}, nil)
+	tenant := &model.Tenant{ID: "t1", ApiKey: "k1"}
+	tm.RegisterTenant(tenant)
+	router := &fakeRouter{}
+	fills := market.NewFillStore(100, nil)
+	marketSvc := market.NewMarketService()
+	conditional := NewConditionalService(ctx, nil, router, marketSvc, tm, nil)
+	groups := NewOrderGroupService(ctx, nil, router, conditional, fills, tm, nil)
+	groups.spawn = func(fn func()) { fn() }
+	fills.AddListener(groups)
+	conditional.AddFireListener(groups)
+	return &groupFixture{tenant: tenant, router: router, fills: fills, marketSvc: marketSvc, conditional: conditional, groups: groups}
+}
+
+func groupOrder(side, price, size string) model.OrderRequest {
+	return model.OrderRequest{
+		TokenID: "tok",
+		Side:    side,
+		Price:   decimal.RequireFromString(price),
+		Size:    decimal.RequireFromString(size),
+	}
+}
+
+func TestOrderGroupBracketArmsOnEntryFill(t *testing.T) {
+	ctx := context.Background()
+	f := newGroupFixture()
+	entry := groupOrder("BUY", "0.50", "10")
+	group, err := f.groups.Create(ctx, f.tenant, model.OrderGroupRequest{
+		Type:  model.OrderGroupBracket,
+		Entry: &entry,
+		Legs: []model.GroupLegRequest{
+			{Order: groupOrder("SELL", "0.70", "10")},
+			{Type: model.ConditionalStopLoss, TriggerPrice: decimal.RequireFromString("0.40"), Order: groupOrder("SELL", "0.01", "10")},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if group.Status != model.OrderGroupPendingEntry || len(f.router.placed) != 1 {
+		t.Fatalf("only the entry may be placed before it fills, got %s with %d orders", group.Status, len(f.router.placed))
+	}
+
+	f.fills.AddFill(&model.Fill{ID: "tr1:0x1", TenantID: "t1", OrderID: "0x1", Size: "4", Status: "MATCHED"})
+	f.fills.AddFill(&model.Fill{ID: "tr1:0x1", TenantID: "t1", OrderID: "0x1", Size: "4", Status: "CONFIRMED"})
+	if got, _ := f.groups.Get("t1", group.ID); got.Status != model.OrderGroupPendingEntry || !got.EntryFilled.Equal(decimal.NewFromInt(4)) {
+		t.Fatalf("a partial entry must not arm the exits, got %+v", got)
+	}
+
+	// The rest of the entry is cancelled: exits are armed for the 4 shares bought
+	f.fills.AddOrderUpdate(&model.OrderUpdate{TenantID: "t1", OrderID: "0x1", Type: market.OrderEventCancellation, OriginalSize: "10", SizeMatched: "4"})
+	got, _ := f.groups.Get("t1", group.ID)
+	if got.Status != model.OrderGroupActive || got.Legs[0].Status != model.GroupLegOpen || got.Legs[1].Status != model.GroupLegOpen {
+		t.Fatalf("expected both exits armed, got %+v", got)
+	}
+	if len(f.router.placed) != 2 || !f.router.placed[1].Size.Equal(decimal.NewFromInt(4)) {
+		t.Fatalf("expected take-profit sized to the entry fill, got %+v", f.router.placed)
+	}
+	stop, _ := f.conditional.Get("t1", got.Legs[1].ConditionalID)
+	if !stop.Size.Equal(decimal.NewFromInt(4)) {
+		t.Fatalf("expected stop sized to the entry fill, got %s", stop.Size)
+	}
+
+	// The stop fires: the take-profit is cancelled before the stop's order goes out
+	f.marketSvc.Subscribe([]string{"tok"})
+	trade(f.marketSvc, "0.39")
+	f.conditional.CheckAll(ctx)
+	if len(f.router.cancelled) != 1 || f.router.cancelled[0] != "0x2" {
+		t.Fatalf("expected the take-profit to be cancelled, got %v", f.router.cancelled)
+	}
+	if len(f.router.placed) != 3 || f.router.placed[2].Side != "SELL" {
+		t.Fatalf("expected the stop order to be placed, got %+v", f.router.placed)
+	}
+	got, _ = f.groups.Get("t1", group.ID)
+	if got.Status != model.OrderGroupDone || got.Legs[1].Status != model.GroupLegExecuted || got.Legs[0].Status != model.GroupLegCancelled {
+		t.Fatalf("expected DONE with the stop executed, got %+v", got)
+	}
+}
+
+func TestOrderGroupOCO(t *testing.T) {
+	ctx := context.Background()
+	f := newGroupFixture()
+	req := model.OrderGroupRequest{
+		Type: model.OrderGroupOCO,
+		Legs: []model.GroupLegRequest{
+			{Order: groupOrder("SELL", "0.70", "10")},
+			{Type: model.ConditionalStopLoss, TriggerPrice: decimal.RequireFromString("0.40"), Order: groupOrder("SELL", "0.01", "10")},
+		},
+	}
+	group, err := f.groups.Create(ctx, f.tenant, req)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// A partial fill on the limit leg only shrinks the stop to what is left
+	f.fills.AddOrderUpdate(&model.OrderUpdate{TenantID: "t1", OrderID: "0x1", Type: market.OrderEventUpdate, OriginalSize: "10", SizeMatched: "2"})
+	got, _ := f.groups.Get("t1", group.ID)
+	if got.Status != model.OrderGroupActive || got.Legs[0].Status != model.GroupLegOpen || !got.Legs[0].Filled.Equal(decimal.NewFromInt(2)) {
+		t.Fatalf("a partial fill must not complete the group, got %+v", got)
+	}
+	if stop, _ := f.conditional.Get("t1", got.Legs[1].ConditionalID); stop.Status != model.ConditionalActive || !stop.Size.Equal(decimal.NewFromInt(8)) {
+		t.Fatalf("expected the stop resized to 8, got %s %s", stop.Status, stop.Size)
+	}
+
+	// The fill completing the leg cancels the stop
+	f.fills.AddOrderUpdate(&model.OrderUpdate{TenantID: "t1", OrderID: "0x1", Type: market.OrderEventUpdate, OriginalSize: "10", SizeMatched: "10"})
+	got, _ = f.groups.Get("t1", group.ID)
+	if got.Status != model.OrderGroupDone || got.Legs[0].Status != model.GroupLegExecuted || got.Legs[1].Status != model.GroupLegCancelled {
+		t.Fatalf("expected DONE with the stop cancelled, got %+v", got)
+	}
+	if stop, _ := f.conditional.Get("t1", got.Legs[1].ConditionalID); stop.Status != model.ConditionalCancelled {
+		t.Fatalf("expected the trigger to be cancelled, got %s", stop.Status)
+	}
+	if _, err := f.groups.Cancel(ctx, f.tenant, group.ID); err == nil {
+		t.Fatalf("a finished group cannot be cancelled")
+	}
+
+	// A leg that cannot be placed unwinds the legs already live
+	f.router.failAfter = len(f.router.placed) + 1
+	req.Legs[1] = model.GroupLegRequest{Order: groupOrder("SELL", "0.30", "10")}
+	if _, err := f.groups.Create(ctx, f.tenant, req); err == nil {
+		t.Fatalf("expected the rejected leg to fail the group")
+	}
+	if last := f.router.cancelled[len(f.router.cancelled)-1]; last != "0x2" {
+		t.Fatalf("expected the placed leg to be cancelled, got %v", f.router.cancelled)
+	}
+	if groups := f.groups.List("t1", ""); len(groups) != 1 {
+		t.Fatalf("a failed group must not be kept, got %d groups", len(groups))
+	}
+}
+
+func TestOrderGroupRetriesRejectedSiblingCancel(t *testing.T) {
+	ctx := context.Background()
+	f := newGroupFixture()
+	audit := &recordingAudit{}
+	f.groups.audit = audit
+	f.groups.cancelRetry = time.Millisecond
+	var deferred []func()
+	f.groups.spawn = func(fn func()) { deferred = append(deferred, fn) }
+	group, err := f.groups.Create(ctx, f.tenant, model.OrderGroupRequest{
+		Type: model.OrderGroupOCO,
+		Legs: []model.GroupLegRequest{
+			{Order: groupOrder("SELL", "0.70", "10")},
+			{Order: groupOrder("SELL", "0.30", "10")},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// The sibling cancel is rejected: the leg stays visible as CANCEL_PENDING, not OPEN
+	f.router.cancelErr = errors.New("clob unavailable")
+	f.fills.AddOrderUpdate(&model.OrderUpdate{TenantID: "t1", OrderID: "0x1", Type: market.OrderEventUpdate, OriginalSize: "10", SizeMatched: "10"})
+	deferred[0]()
+	got, _ := f.groups.Get("t1", group.ID)
+	if got.Status != model.OrderGroupDone || got.Legs[1].Status != model.GroupLegCancelPending || got.Legs[1].Reason == "" {
+		t.Fatalf("expected the sibling CANCEL_PENDING, got %+v", got.Legs[1])
+	}
+	if len(audit.actions) != 2 || audit.actions[1] != "t1:order_group_cancel_failed" {
+		t.Fatalf("expected the failed cancel audited, got %v", audit.actions)
+	}
+
+	// Fills that slip in before the cancel still count on the leg
+	f.fills.AddOrderUpdate(&model.OrderUpdate{TenantID: "t1", OrderID: "0x2", Type: market.OrderEventUpdate, OriginalSize: "10", SizeMatched: "3"})
+	got, _ = f.groups.Get("t1", group.ID)
+	if got.Legs[1].Status != model.GroupLegCancelPending || !got.Legs[1].Filled.Equal(decimal.NewFromInt(3)) {
+		t.Fatalf("expected the late fill recorded, got %+v", got.Legs[1])
+	}
+	if audit.actions[len(audit.actions)-1] != "t1:order_group_leg_filled_after_cancel" {
+		t.Fatalf("expected the late fill audited, got %v", audit.actions)
+	}
+
+	// The retry goes through once the exchange accepts the cancel
+	f.router.cancelErr = nil
+	deferred[1]()
+	got, _ = f.groups.Get("t1", group.ID)
+	if got.Legs[1].Status != model.GroupLegCancelled || len(f.router.cancelled) != 1 || f.router.cancelled[0] != "0x2" {
+		t.Fatalf("expected the retried cancel to settle the leg, got %+v (cancelled %v)", got.Legs[1], f.router.cancelled)
+	}
+
+	// An exchange cancellation settles a pending leg as well
+	group, _ = f.groups.Create(ctx, f.tenant, model.OrderGroupRequest{
+		Type: model.OrderGroupOCO,
+		Legs: []model.GroupLegRequest{
+			{Order: groupOrder("SELL", "0.70", "10")},
+			{Order: groupOrder("SELL", "0.30", "10")},
+		},
+	})
+	f.router.cancelErr = errors.New("clob unavailable")
+	f.fills.AddOrderUpdate(&model.OrderUpdate{TenantID: "t1", OrderID: "0x3", Type: market.OrderEventUpdate, OriginalSize: "10", SizeMatched: "10"})
+	deferred[2]()
+	f.fills.AddOrderUpdate(&model.OrderUpdate{TenantID: "t1", OrderID: "0x4", Type: market.OrderEventCancellation, OriginalSize: "10", SizeMatched: "0"})
+	got, _ = f.groups.Get("t1", group.ID)
+	if got.Legs[1].Status != model.GroupLegCancelled {
+		t.Fatalf("expected the cancellation to settle the leg, got %+v", got.Legs[1])
+	}
+	deferred[3]() // the retry sees the leg settled and stops
+	if len(f.router.cancelled) != 1 {
+		t.Fatalf("a settled leg must not be cancelled again, got %v", f.router.cancelled)
+	}
+}
+
+func TestOrderGroupPartialFillReplacesSiblings(t *testing.T) {
+	ctx := context.Background()
+	f := newGroupFixture()
+	group, err := f.groups.Create(ctx, f.tenant, model.OrderGroupRequest{
+		Type: model.OrderGroupOCO,
+		Legs: []model.GroupLegRequest{
+			{Order: groupOrder("SELL", "0.70", "10")},
+			{Order: groupOrder("SELL", "0.30", "10")},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// 3 sold on the first leg: the second is replaced for the 7 left
+	f.fills.AddFill(&model.Fill{ID: "tr1:0x1", TenantID: "t1", OrderID: "0x1", Size: "3", Status: "MATCHED"})
+	if len(f.router.cancelled) != 1 || f.router.cancelled[0] != "0x2" {
+		t.Fatalf("expected the sibling to be cancelled for the resize, got %v", f.router.cancelled)
+	}
+	if len(f.router.placed) != 3 || !f.router.placed[2].Size.Equal(decimal.NewFromInt(7)) || f.router.placed[2].Price.String() != "0.3" {
+		t.Fatalf("expected the sibling replaced for 7, got %+v", f.router.placed)
+	}
+	got, _ := f.groups.Get("t1", group.ID)
+	if got.Legs[1].OrderID != "0x3" || !got.Legs[1].Remaining().Equal(decimal.NewFromInt(7)) || got.Status != model.OrderGroupActive {
+		t.Fatalf("expected leg 1 working as 0x3 for 7, got %+v", got.Legs[1])
+	}
+	// The status update resending the same fill changes nothing
+	f.fills.AddFill(&model.Fill{ID: "tr1:0x1", TenantID: "t1", OrderID: "0x1", Size: "3", Status: "CONFIRMED"})
+	if len(f.router.placed) != 3 {
+		t.Fatalf("a resent fill must not resize again, got %+v", f.router.placed)
+	}
+
+	// The replacement fills in full: the first leg is cancelled
+	f.fills.AddOrderUpdate(&model.OrderUpdate{TenantID: "t1", OrderID: "0x3", Type: market.OrderEventUpdate, OriginalSize: "7", SizeMatched: "7"})
+	got, _ = f.groups.Get("t1", group.ID)
+	if got.Status != model.OrderGroupDone || got.Legs[1].Status != model.GroupLegExecuted || got.Legs[0].Status != model.GroupLegCancelled {
+		t.Fatalf("expected DONE with leg 1 executed, got %+v", got)
+	}
+	if last := f.router.cancelled[len(f.router.cancelled)-1]; last != "0x1" {
+		t.Fatalf("expected the first leg to be cancelled, got %v", f.router.cancelled)
+	}
+}
+
+func TestOrderGroupStopSkipsArming(t *testing.T) {
+	ctx := context.Background()
+	f := newGroupFixture()
+	entry := groupOrder("BUY", "0.50", "10")
+	group, err := f.groups.Create(ctx, f.tenant, model.OrderGroupRequest{
+		Type:  model.OrderGroupBracket,
+		Entry: &entry,
+		Legs:  []model.GroupLegRequest{{Order: groupOrder("SELL", "0.70", "10")}},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	f.groups.Stop()
+	f.fills.AddOrderUpdate(&model.OrderUpdate{TenantID: "t1", OrderID: "0x1", Type: market.OrderEventUpdate, OriginalSize: "10", SizeMatched: "10"})
+	if len(f.router.placed) != 1 {
+		t.Fatalf("no exit may be placed after Stop, got %+v", f.router.placed)
+	}
+	got, _ := f.groups.Get("t1", group.ID)
+	if got.Status != model.OrderGroupFailed || got.Legs[0].Status != model.GroupLegFailed {
+		t.Fatalf("expected the unarmed exit to be recorded as failed, got %+v", got)
+	}
+}
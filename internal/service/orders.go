@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/pkg/apperrors"
+	"github.com/GoPolymarket/polymarket-go-sdk/pkg/clob/clobtypes"
+)
+
+// ListOrders 查询租户在 CLOB 上的挂单（market / asset 由 CLOB 过滤），
+// 并合并 user channel 记录的明细；side 过滤依赖明细，未知明细的订单不会被 side 过滤命中。
+func (s *GatewayService) ListOrders(ctx context.Context, tenant *model.Tenant, filter model.OrderFilter) ([]*model.Order, error) {
+	client, err := s.tm.GetClientForTenant(tenant)
+	if err != nil {
+		return nil, err
+	}
+	upstream, err := client.CLOB.OrdersAll(ctx, &clobtypes.OrdersRequest{Market: filter.Market, AssetID: filter.AssetID})
+	if err != nil {
+		return nil, apperrors.New(apperrors.ErrUpstream, "failed to list orders", err)
+	}
+
+	ids := make([]string, 0, len(upstream))
+	for _, o := range upstream {
+		ids = append(ids, o.ID)
+	}
+	local := s.lookupOrderUpdates(ctx, tenant.ID, ids)
+
+	orders := make([]*model.Order, 0, len(upstream))
+	side := strings.ToUpper(strings.TrimSpace(filter.Side))
+	for _, o := range upstream {
+		order := mergeOrder(o, local[o.ID])
+		if side != "" && !strings.EqualFold(order.Side, side) {
+			continue
+		}
+		s.annotateOrder(ctx, tenant.ID, order)
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+// GetOrder 查询单个订单：CLOB 状态优先，CLOB 不可用时回退到本地记录
+func (s *GatewayService) GetOrder(ctx context.Context, tenant *model.Tenant, orderID string) (*model.Order, error) {
+	client, err := s.tm.GetClientForTenant(tenant)
+	if err != nil {
+		return nil, err
+	}
+	local := s.lookupOrderUpdates(ctx, tenant.ID, []string{orderID})[orderID]
+
+	upstream, err := client.CLOB.Order(ctx, orderID)
+	if err != nil || upstream.ID == "" {
+		if local == nil {
+			if err != nil {
+				return nil, apperrors.New(apperrors.ErrUpstream, fmt.Sprintf("failed to get order %s", orderID), err)
+			}
+			return nil, apperrors.New(apperrors.ErrNotFound, "order not found", nil)
+		}
+		upstream = clobtypes.OrderResponse{}
+	}
+	order := mergeOrder(upstream, local)
+	s.annotateOrder(ctx, tenant.ID, order)
+	return order, nil
+}
+
+func (s *GatewayService) lookupOrderUpdates(ctx context.Context, tenantID string, ids []string) map[string]*model.OrderUpdate {
+	if s.fills == nil || len(ids) == 0 {
+		return map[string]*model.OrderUpdate{}
+	}
+	return s.fills.LookupOrderUpdates(ctx, tenantID, ids)
+}
+
+// mergeOrder combines the CLOB view (authoritative status) with the stream view (details).
+// An empty upstream means only the stream knows the order.
+func mergeOrder(upstream clobtypes.OrderResponse, local *model.OrderUpdate) *model.Order {
+	order := &model.Order{ID: upstream.ID, Status: upstream.Status, Source: "clob"}
+	if local == nil {
+		return order
+	}
+	if order.ID == "" {
+		order.ID = local.OrderID
+		order.Source = "stream"
+	} else {
+		order.Source = "clob+stream"
+	}
+	if order.Status == "" {
+		order.Status = local.Type
+	}
+	order.Market = local.Market
+	order.AssetID = local.AssetID
+	order.Outcome = local.Outcome
+	order.Side = strings.ToUpper(local.Side)
+	order.Price = local.Price
+	order.OriginalSize = local.OriginalSize
+	order.SizeMatched = local.SizeMatched
+	order.LastEvent = local.Type
+	if !local.UpdatedAt.IsZero() {
+		updated := local.UpdatedAt
+		order.UpdatedAt = &updated
+	} else if !local.Timestamp.IsZero() {
+		updated := local.Timestamp
+		order.UpdatedAt = &updated
+	}
+	return order
+}
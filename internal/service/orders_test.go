@@ -0,0 +1,42 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polymarket-go-sdk/pkg/clob/clobtypes"
+)
+
+func TestMergeOrder(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	local := &model.OrderUpdate{
+		OrderID:      "o1",
+		Market:       "m1",
+		AssetID:      "a1",
+		Side:         "buy",
+		Price:        "0.45",
+		OriginalSize: "100",
+		SizeMatched:  "40",
+		Type:         "UPDATE",
+		UpdatedAt:    at,
+	}
+
+	clobOnly := mergeOrder(clobtypes.OrderResponse{ID: "o1", Status: "LIVE"}, nil)
+	if clobOnly.Source != "clob" || clobOnly.Status != "LIVE" || clobOnly.Side != "" {
+		t.Fatalf("unexpected clob-only order: %+v", clobOnly)
+	}
+
+	merged := mergeOrder(clobtypes.OrderResponse{ID: "o1", Status: "LIVE"}, local)
+	if merged.Source != "clob+stream" || merged.Status != "LIVE" || merged.Side != "BUY" || merged.SizeMatched != "40" {
+		t.Fatalf("unexpected merged order: %+v", merged)
+	}
+	if merged.UpdatedAt == nil || !merged.UpdatedAt.Equal(at) {
+		t.Fatalf("expected updated_at %v, got %v", at, merged.UpdatedAt)
+	}
+
+	streamOnly := mergeOrder(clobtypes.OrderResponse{}, local)
+	if streamOnly.Source != "stream" || streamOnly.ID != "o1" || streamOnly.Status != "UPDATE" {
+		t.Fatalf("unexpected stream-only order: %+v", streamOnly)
+	}
+}
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/pkg/logger"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrPanicNotActive    = errors.New("trading is not halted for this scope")
+	ErrPanicReasonNeeded = errors.New("reason is required")
+	// ErrPanicResumeForbidden: a tenant may only lift halts it raised itself
+	ErrPanicResumeForbidden = errors.New("halt was set by an operator or the loss guard and can only be lifted by an admin")
+)
+
+type PanicRepo interface {
+	SavePanicState(ctx context.Context, state *model.PanicState) error
+	LoadPanicStates(ctx context.Context) ([]*model.PanicState, error)
+}
+
+// AuditSink receives audit entries generated outside of an HTTP request.
+type AuditSink interface {
+	Log(entry *model.AuditLog)
+}
+
+// PanicService 管理全局 / 租户级熔断状态，状态变更会持久化并写入审计
+type PanicService struct {
+	mu     sync.RWMutex
+	states map[string]*model.PanicState // Key: Scope
+	repo   PanicRepo
+	audit  AuditSink
+}
+
+// NewPanicService restores persisted state so a restart never silently resumes trading.
+func NewPanicService(ctx context.Context, repo PanicRepo, audit AuditSink) *PanicService {
+	s := &PanicService{
+		states: make(map[string]*model.PanicState),
+		repo:   repo,
+		audit:  audit,
+	}
+	if repo == nil {
+		return s
+	}
+	states, err := repo.LoadPanicStates(ctx)
+	if err != nil {
+		logger.Error("Failed to load panic state", "error", err)
+		return s
+	}
+	for _, st := range states {
+		s.states[st.Scope] = st
+		if st.Active {
+			logger.Warn("Panic mode restored from storage", "scope", st.Scope, "reason", st.Reason)
+		}
+	}
+	return s
+}
+
+// Activate halts trading for a tenant, or globally when tenantID is empty.
+func (s *PanicService) Activate(ctx context.Context, tenantID, actor, reason string) *model.PanicState {
+	return s.ActivateScoped(ctx, tenantID, model.OrderFilter{}, actor, reason)
+}
+
+// ActivateScoped halts only the tenant's orders matching filter (market / token / side);
+// an empty filter halts the whole tenant. Re-activating a scope that is already
+// halted keeps the original owner, unless an operator takes over a tenant's own
+// halt, so a tenant cannot claim (and then lift) an operator or loss-guard halt.
+func (s *PanicService) ActivateScoped(ctx context.Context, tenantID string, filter model.OrderFilter, actor, reason string) *model.PanicState {
+	now := time.Now().UTC()
+	scope := model.PanicScopeFor(tenantID, filter)
+
+	s.mu.Lock()
+	state := &model.PanicState{
+		Scope:       scope,
+		TenantID:    tenantID,
+		Market:      filter.Market,
+		AssetID:     filter.AssetID,
+		Side:        strings.ToUpper(filter.Side),
+		Active:      true,
+		Reason:      strings.TrimSpace(reason),
+		ActivatedBy: actor,
+		ActivatedAt: now,
+		UpdatedAt:   now,
+	}
+	if prev, ok := s.states[scope]; ok && prev.Active && (isTenantActor(actor) || !isTenantActor(prev.ActivatedBy)) {
+		state.ActivatedBy = prev.ActivatedBy
+		state.ActivatedAt = prev.ActivatedAt
+		state.Reason = prev.Reason
+	}
+	s.states[scope] = state
+	snapshot := *state
+	s.mu.Unlock()
+
+	s.persist(ctx, &snapshot)
+	s.record(tenantID, "panic_activated", actor, &snapshot)
+	logger.Warn("Panic mode activated", "scope", scope, "actor", actor, "reason", snapshot.Reason)
+	return &snapshot
+}
+
+// Resume lifts the halt for one scope; a reason is mandatory.
+func (s *PanicService) Resume(ctx context.Context, tenantID, actor, reason string) (*model.PanicState, error) {
+	return s.ResumeScoped(ctx, tenantID, model.OrderFilter{}, actor, reason)
+}
+
+// ResumeScoped lifts a halt created by ActivateScoped with the same filter.
+// A tenant actor cannot lift a halt raised by an admin or a system guard.
+func (s *PanicService) ResumeScoped(ctx context.Context, tenantID string, filter model.OrderFilter, actor, reason string) (*model.PanicState, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrPanicReasonNeeded
+	}
+	now := time.Now().UTC()
+	scope := model.PanicScopeFor(tenantID, filter)
+
+	s.mu.Lock()
+	state, ok := s.states[scope]
+	if !ok || !state.Active {
+		s.mu.Unlock()
+		return nil, ErrPanicNotActive
+	}
+	if isTenantActor(actor) && !isTenantActor(state.ActivatedBy) {
+		s.mu.Unlock()
+		return nil, ErrPanicResumeForbidden
+	}
+	state.Active = false
+	state.ResumedBy = actor
+	state.ResumeReason = reason
+	state.ResumedAt = now
+	state.UpdatedAt = now
+	snapshot := *state
+	s.mu.Unlock()
+
+	s.persist(ctx, &snapshot)
+	s.record(tenantID, "panic_resumed", actor, &snapshot)
+	logger.Info("Panic mode lifted", "scope", scope, "actor", actor, "reason", reason)
+	return &snapshot, nil
+}
+
+// Check returns an error if trading is halted for the tenant (globally or individually).
+func (s *PanicService) Check(tenantID string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if st, ok := s.states[model.PanicScopeGlobal]; ok && st.Active {
+		return fmt.Errorf("system in panic mode: all trading suspended (%s)", st.Reason)
+	}
+	if st, ok := s.states[model.PanicScope(tenantID)]; ok && st.Active {
+		return fmt.Errorf("tenant in panic mode: trading suspended (%s)", st.Reason)
+	}
+	return nil
+}
+
+// CheckScoped returns an error if an order (token, side) falls under one of the
+// tenant's scoped halts. marketOf resolves the token's condition id and is only
+// called when a halt is scoped by market.
+func (s *PanicService) CheckScoped(tenantID, tokenID, side string, marketOf func() string) error {
+	scoped := s.Scoped(tenantID)
+	market, resolved := "", false
+	for _, st := range scoped {
+		if !st.Active {
+			continue
+		}
+		if st.Market != "" && !resolved {
+			market, resolved = marketOf(), true
+		}
+		if st.Filter().Matches(market, tokenID, side) {
+			return fmt.Errorf("scoped panic mode: trading suspended for %s (%s)", st.Scope, st.Reason)
+		}
+	}
+	return nil
+}
+
+// Scoped returns the tenant's market / token / side scoped states.
+func (s *PanicService) Scoped(tenantID string) []*model.PanicState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*model.PanicState, 0)
+	for _, st := range s.states {
+		if tenantID == "" || st.TenantID != tenantID || st.Filter().IsEmpty() {
+			continue
+		}
+		cp := *st
+		out = append(out, &cp)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Scope < out[j].Scope })
+	return out
+}
+
+// Status returns the effective state for a tenant: global first, then tenant scope.
+func (s *PanicService) Status(tenantID string) (global, tenant *model.PanicState) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if st, ok := s.states[model.PanicScopeGlobal]; ok {
+		cp := *st
+		global = &cp
+	}
+	if tenantID != "" {
+		if st, ok := s.states[model.PanicScope(tenantID)]; ok {
+			cp := *st
+			tenant = &cp
+		}
+	}
+	return global, tenant
+}
+
+// List returns all known scopes
+func (s *PanicService) List() []*model.PanicState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*model.PanicState, 0, len(s.states))
+	for _, st := range s.states {
+		cp := *st
+		out = append(out, &cp)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Scope < out[j].Scope })
+	return out
+}
+
+func (s *PanicService) persist(ctx context.Context, state *model.PanicState) {
+	if s.repo == nil {
+		return
+	}
+	if err := s.repo.SavePanicState(ctx, state); err != nil {
+		logger.Error("Failed to persist panic state", "scope", state.Scope, "error", err)
+	}
+}
+
+func (s *PanicService) record(tenantID, action, actor string, state *model.PanicState) {
+	if s.audit == nil {
+		return
+	}
+	s.audit.Log(systemAuditEntry(tenantID, action, map[string]interface{}{
+		"scope":  state.Scope,
+		"actor":  actor,
+		"active": state.Active,
+		"reason": stateReason(state),
+	}))
+}
+
+func isTenantActor(actor string) bool {
+	return strings.HasPrefix(actor, "tenant:")
+}
+
+func stateReason(state *model.PanicState) string {
+	if !state.Active {
+		return state.ResumeReason
+	}
+	return state.Reason
+}
+
+// systemAuditEntry builds an audit record for state changes not tied to one HTTP request.
+func systemAuditEntry(tenantID, action string, fields map[string]interface{}) *model.AuditLog {
+	ctx := make(map[string]interface{}, len(fields)+1)
+	for k, v := range fields {
+		ctx[k] = v
+	}
+	ctx["action"] = action
+	return &model.AuditLog{
+		ID:        uuid.New().String(),
+		TenantID:  tenantID,
+		Method:    "SYSTEM",
+		Path:      action,
+		Context:   ctx,
+		CreatedAt: time.Now(),
+	}
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+)
+
+type memPanicRepo struct {
+	states map[string]model.PanicState
+}
+
+func (r *memPanicRepo) SavePanicState(ctx context.Context, state *model.PanicState) error {
+	r.states[state.Scope] = *state
+	return nil
+}
+
+func (r *memPanicRepo) LoadPanicStates(ctx context.Context) ([]*model.PanicState, error) {
+	out := make([]*model.PanicState, 0, len(r.states))
+	for _, st := range r.states {
+		cp := st
+		out = append(out, &cp)
+	}
+	return out, nil
+}
+
+type recordingAudit struct {
+	actions []string
+}
+
+func (a *recordingAudit) Log(entry *model.AuditLog) {
+	a.actions = append(a.actions, entry.TenantID+":"+entry.Context["action"].(string))
+}
+
+func TestPanicServiceScopesAndPersistence(t *testing.T) {
+	ctx := context.Background()
+	repo := &memPanicRepo{states: make(map[string]model.PanicState)}
+	audit := &recordingAudit{}
+	svc := NewPanicService(ctx, repo, audit)
+
+	svc.Activate(ctx, "t1", "tenant:t1", "runaway bot")
+	if err := svc.Check("t1"); err == nil {
+		t.Fatalf("expected t1 to be halted")
+	}
+	if err := svc.Check("t2"); err != nil {
+		t.Fatalf("tenant panic must not halt other tenants: %v", err)
+	}
+
+	if _, err := svc.Resume(ctx, "t1", "tenant:t1", " "); !errors.Is(err, ErrPanicReasonNeeded) {
+		t.Fatalf("expected reason to be required, got %v", err)
+	}
+	if _, err := svc.Resume(ctx, "t2", "tenant:t2", "nothing to resume"); !errors.Is(err, ErrPanicNotActive) {
+		t.Fatalf("expected not-active error, got %v", err)
+	}
+
+	svc.Activate(ctx, "", "admin", "exchange incident")
+	if err := svc.Check("t2"); err == nil {
+		t.Fatalf("global panic must halt every tenant")
+	}
+
+	// Restart: state comes back from the repo
+	restored := NewPanicService(ctx, repo, nil)
+	if err := restored.Check("t2"); err == nil {
+		t.Fatalf("expected global halt to survive restart")
+	}
+	if _, err := restored.Resume(ctx, "", "admin", "incident resolved"); err != nil {
+		t.Fatalf("resume failed: %v", err)
+	}
+	if err := restored.Check("t2"); err != nil {
+		t.Fatalf("expected t2 to trade after global resume: %v", err)
+	}
+	if err := restored.Check("t1"); err == nil {
+		t.Fatalf("t1 tenant halt must remain after global resume")
+	}
+	if repo.states[model.PanicScopeGlobal].Active {
+		t.Fatalf("expected resumed state to be persisted")
+	}
+
+	want := []string{"t1:panic_activated", ":panic_activated"}
+	if len(audit.actions) != len(want) || audit.actions[0] != want[0] || audit.actions[1] != want[1] {
+		t.Fatalf("unexpected audit entries: %v", audit.actions)
+	}
+}
+
+func TestPanicServiceScopedHalt(t *testing.T) {
+	ctx := context.Background()
+	svc := NewPanicService(ctx, nil, nil)
+	marketOf := func(market string) func() string {
+		return func() string { return market }
+	}
+
+	svc.ActivateScoped(ctx, "t1", model.OrderFilter{Market: "0xevent"}, "tenant:t1", "event going haywire")
+	svc.ActivateScoped(ctx, "t1", model.OrderFilter{AssetID: "tok-2", Side: "sell"}, "tenant:t1", "stop selling tok-2")
+
+	if err := svc.Check("t1"); err != nil {
+		t.Fatalf("scoped halts must not halt the whole tenant: %v", err)
+	}
+	if err := svc.CheckScoped("t1", "tok-1", "BUY", marketOf("0xevent")); err == nil {
+		t.Fatalf("expected orders in the halted market to be rejected")
+	}
+	if err := svc.CheckScoped("t1", "tok-1", "BUY", marketOf("0xother")); err != nil {
+		t.Fatalf("other markets must keep trading: %v", err)
+	}
+	if err := svc.CheckScoped("t1", "tok-2", "SELL", marketOf("0xother")); err == nil {
+		t.Fatalf("expected SELL on tok-2 to be rejected")
+	}
+	if err := svc.CheckScoped("t1", "tok-2", "BUY", marketOf("0xother")); err != nil {
+		t.Fatalf("BUY on tok-2 must keep trading: %v", err)
+	}
+	if err := svc.CheckScoped("t2", "tok-1", "BUY", marketOf("0xevent")); err != nil {
+		t.Fatalf("scoped halts are per tenant: %v", err)
+	}
+	if got := len(svc.Scoped("t1")); got != 2 {
+		t.Fatalf("expected 2 scoped states, got %d", got)
+	}
+
+	if _, err := svc.ResumeScoped(ctx, "t1", model.OrderFilter{Market: "0xevent"}, "tenant:t1", "calm again"); err != nil {
+		t.Fatalf("resume scoped: %v", err)
+	}
+	if err := svc.CheckScoped("t1", "tok-1", "BUY", marketOf("0xevent")); err != nil {
+		t.Fatalf("market should trade again after resume: %v", err)
+	}
+	if _, err := svc.ResumeScoped(ctx, "t1", model.OrderFilter{Market: "0xevent"}, "tenant:t1", "again"); !errors.Is(err, ErrPanicNotActive) {
+		t.Fatalf("expected ErrPanicNotActive, got %v", err)
+	}
+}
+
+func TestPanicServiceTenantCannotLiftOperatorHalt(t *testing.T) {
+	ctx := context.Background()
+	svc := NewPanicService(ctx, nil, nil)
+
+	for _, actor := range []string{lossGuardActor, "admin"} {
+		svc.Activate(ctx, "t1", actor, "halted by "+actor)
+		if _, err := svc.Resume(ctx, "t1", "tenant:t1", "let me trade"); !errors.Is(err, ErrPanicResumeForbidden) {
+			t.Fatalf("expected tenant resume of a %s halt to be forbidden, got %v", actor, err)
+		}
+		if err := svc.Check("t1"); err == nil {
+			t.Fatalf("%s halt must stay in force", actor)
+		}
+		if _, err := svc.Resume(ctx, "t1", "admin", "reviewed"); err != nil {
+			t.Fatalf("admin resume failed: %v", err)
+		}
+	}
+
+	// Scoped halts follow the same rule
+	filter := model.OrderFilter{Market: "0xevent"}
+	svc.ActivateScoped(ctx, "t1", filter, "admin", "event under review")
+	if _, err := svc.ResumeScoped(ctx, "t1", filter, "tenant:t1", "done"); !errors.Is(err, ErrPanicResumeForbidden) {
+		t.Fatalf("expected scoped resume to be forbidden, got %v", err)
+	}
+}
+
+func TestPanicServiceTenantCannotClaimOperatorHalt(t *testing.T) {
+	ctx := context.Background()
+	svc := NewPanicService(ctx, nil, nil)
+
+	svc.Activate(ctx, "t1", "admin", "under review")
+	// The tenant halts again on top of the operator halt, then tries to lift it
+	state := svc.Activate(ctx, "t1", "tenant:t1", "my own halt")
+	if state.ActivatedBy != "admin" || state.Reason != "under review" {
+		t.Fatalf("re-activation must keep the operator as owner, got %+v", state)
+	}
+	if _, err := svc.Resume(ctx, "t1", "tenant:t1", "let me trade"); !errors.Is(err, ErrPanicResumeForbidden) {
+		t.Fatalf("expected tenant resume to stay forbidden, got %v", err)
+	}
+
+	// An operator taking over a tenant's own halt does become the owner
+	svc.Resume(ctx, "t1", "admin", "reviewed")
+	svc.Activate(ctx, "t1", "tenant:t1", "pausing")
+	if state := svc.Activate(ctx, "t1", lossGuardActor, "daily loss"); state.ActivatedBy != lossGuardActor {
+		t.Fatalf("expected the loss guard to take over, got %+v", state)
+	}
+	if _, err := svc.Resume(ctx, "t1", "tenant:t1", "let me trade"); !errors.Is(err, ErrPanicResumeForbidden) {
+		t.Fatalf("expected tenant resume of the loss-guard halt to be forbidden, got %v", err)
+	}
+}
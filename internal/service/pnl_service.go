@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/market"
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/pkg/logger"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	DefaultPnLCheckInterval = 5 * time.Second
+	lossGuardActor          = "system:loss_guard"
+)
+
+// TenantHalter moves a tenant to cancel-only (open orders cancelled, new orders rejected).
+type TenantHalter interface {
+	ActivatePanicMode(ctx context.Context, tenant *model.Tenant, actor, reason string) (*model.PanicState, error)
+	PanicStatus(tenantID string) (global, tenant *model.PanicState)
+}
+
+// PnLRepo persists the loss guard's baselines so a restart keeps the high-water mark.
+type PnLRepo interface {
+	SavePnLBaseline(ctx context.Context, baseline *model.PnLBaseline) error
+	LoadPnLBaselines(ctx context.Context) ([]*model.PnLBaseline, error)
+}
+
+// PnLService marks tenant positions at mid and trips the loss kill switch
+// when a tenant breaches MaxDailyLoss or MaxDrawdown.
+type PnLService struct {
+	mu        sync.Mutex
+	baselines map[string]*model.PnLBaseline // Key: TenantID
+	repo      PnLRepo
+	positions *market.PositionStore
+	market    *market.MarketService
+	tm        *TenantManager
+	halter    TenantHalter
+	audit     AuditSink
+	defaults  model.LossLimits
+	ctx       context.Context
+	cancel    context.CancelFunc
+}
+
+func NewPnLService(ctx context.Context, repo PnLRepo, positions *market.PositionStore, marketSvc *market.MarketService, tm *TenantManager, halter TenantHalter, audit AuditSink, defaults model.LossLimits) *PnLService {
+	baselines := make(map[string]*model.PnLBaseline)
+	if repo != nil {
+		loaded, err := repo.LoadPnLBaselines(ctx)
+		if err != nil {
+			logger.Error("Failed to load PnL baselines", "error", err)
+		}
+		for _, b := range loaded {
+			baselines[b.TenantID] = b
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &PnLService{
+		baselines: baselines,
+		repo:      repo,
+		positions: positions,
+		market:    marketSvc,
+		tm:        tm,
+		halter:    halter,
+		audit:     audit,
+		defaults:  defaults,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Start runs the periodic mark-and-check loop.
+func (s *PnLService) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultPnLCheckInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+				s.CheckAll(s.ctx)
+			}
+		}
+	}()
+}
+
+// Stop halts the check loop.
+func (s *PnLService) Stop() {
+	s.cancel()
+}
+
+// Snapshot returns the tenant's current PnL and updates its high-water mark.
+func (s *PnLService) Snapshot(tenantID string) *model.PnL {
+	now := time.Now().UTC()
+	realized, dailyRealized := s.positions.RealizedPnL(tenantID, now.Format("2006-01-02"))
+	pnl := &model.PnL{
+		TenantID:      tenantID,
+		Realized:      realized,
+		DailyRealized: dailyRealized,
+		Positions:     make([]*model.PositionMark, 0),
+		UpdatedAt:     now,
+	}
+	for _, pos := range s.positions.Positions(tenantID) {
+		mark, source := s.markPrice(pos)
+		unrealized := mark.Sub(pos.AvgPrice).Mul(pos.Size)
+		pnl.Unrealized = pnl.Unrealized.Add(unrealized)
+		pnl.Positions = append(pnl.Positions, &model.PositionMark{
+			Position:   *pos,
+			MarkPrice:  mark,
+			MarkSource: source,
+			Unrealized: unrealized,
+		})
+	}
+	pnl.Daily = pnl.DailyRealized.Add(pnl.Unrealized)
+	pnl.Total = pnl.Realized.Add(pnl.Unrealized)
+
+	s.mu.Lock()
+	b, ok := s.baselines[tenantID]
+	var changed *model.PnLBaseline
+	if !ok || pnl.Total.GreaterThan(b.Peak) {
+		if !ok {
+			b = &model.PnLBaseline{TenantID: tenantID}
+			s.baselines[tenantID] = b
+		}
+		b.Peak = pnl.Total
+		b.UpdatedAt = now
+		cp := *b
+		changed = &cp
+	}
+	pnl.Peak = b.Peak
+	s.mu.Unlock()
+	pnl.Drawdown = pnl.Peak.Sub(pnl.Total)
+	if changed != nil {
+		s.persist(s.ctx, changed)
+	}
+	return pnl
+}
+
+// rebase resets the tenant's baseline to its current PnL once per operator resume
+// of a loss-guard halt, so the resumed tenant is not halted again for the same loss.
+// It returns the daily PnL already forgiven today.
+func (s *PnLService) rebase(ctx context.Context, tenantID string, pnl *model.PnL) decimal.Decimal {
+	day := pnl.UpdatedAt.Format("2006-01-02")
+	_, state := s.halter.PanicStatus(tenantID)
+
+	s.mu.Lock()
+	b, ok := s.baselines[tenantID]
+	if !ok {
+		b = &model.PnLBaseline{TenantID: tenantID, Peak: pnl.Peak}
+		s.baselines[tenantID] = b
+	}
+	var changed *model.PnLBaseline
+	if state != nil && !state.Active && state.ActivatedBy == lossGuardActor && state.ResumedAt.After(b.RebasedAt) {
+		b.Peak = pnl.Total
+		b.Day = day
+		b.DailyBase = decimal.Min(pnl.Daily, decimal.Zero)
+		b.RebasedAt = state.ResumedAt
+		b.UpdatedAt = pnl.UpdatedAt
+		cp := *b
+		changed = &cp
+		pnl.Peak = b.Peak
+		pnl.Drawdown = decimal.Zero
+	}
+	base := decimal.Zero
+	if b.Day == day {
+		base = b.DailyBase
+	}
+	s.mu.Unlock()
+
+	if changed != nil {
+		logger.Info("Loss guard baseline reset after operator resume", "tenant_id", tenantID, "peak", changed.Peak, "daily_base", changed.DailyBase)
+		s.persist(ctx, changed)
+	}
+	return base
+}
+
+// CheckAll evaluates every tenant with a loss limit configured.
+func (s *PnLService) CheckAll(ctx context.Context) {
+	for _, tenant := range s.tm.ListTenants() {
+		s.Check(ctx, tenant)
+	}
+}
+
+// Check moves the tenant to cancel-only if it breached a loss limit.
+// It returns the breach reason, or "" when the tenant is within limits.
+func (s *PnLService) Check(ctx context.Context, tenant *model.Tenant) string {
+	limits := model.LossLimits{
+		MaxDailyLoss: chooseFloat(s.defaults.MaxDailyLoss, tenant.Risk.MaxDailyLoss),
+		MaxDrawdown:  chooseFloat(s.defaults.MaxDrawdown, tenant.Risk.MaxDrawdown),
+	}
+	if limits.MaxDailyLoss <= 0 && limits.MaxDrawdown <= 0 {
+		return ""
+	}
+
+	pnl := s.Snapshot(tenant.ID)
+	// Losses already taken when an operator resumed the tenant do not count again
+	dailyLoss := pnl.Daily.Sub(s.rebase(ctx, tenant.ID, pnl)).Neg()
+	var reason, rule string
+	var limit float64
+	switch {
+	case limits.MaxDailyLoss > 0 && dailyLoss.GreaterThanOrEqual(decimal.NewFromFloat(limits.MaxDailyLoss)):
+		rule, limit = "daily_loss_limit", limits.MaxDailyLoss
+		reason = fmt.Sprintf("daily loss %s reached limit %.2f", dailyLoss.StringFixed(2), limits.MaxDailyLoss)
+	case limits.MaxDrawdown > 0 && pnl.Drawdown.GreaterThanOrEqual(decimal.NewFromFloat(limits.MaxDrawdown)):
+		rule, limit = "drawdown_limit", limits.MaxDrawdown
+		reason = fmt.Sprintf("drawdown %s reached limit %.2f", pnl.Drawdown.StringFixed(2), limits.MaxDrawdown)
+	default:
+		return ""
+	}
+
+	if _, state := s.halter.PanicStatus(tenant.ID); state != nil && state.Active {
+		// Already cancel-only; an operator has to resume it.
+		return reason
+	}
+
+	logger.Warn("Loss limit breached, moving tenant to cancel-only", "tenant_id", tenant.ID, "rule", rule, "reason", reason)
+	if s.audit != nil {
+		s.audit.Log(systemAuditEntry(tenant.ID, "loss_limit_breached", map[string]interface{}{
+			"rule":           rule,
+			"limit":          limit,
+			"daily_pnl":      pnl.Daily.String(),
+			"total_pnl":      pnl.Total.String(),
+			"unrealized_pnl": pnl.Unrealized.String(),
+			"drawdown":       pnl.Drawdown.String(),
+			"reason":         reason,
+		}))
+	}
+	if _, err := s.halter.ActivatePanicMode(ctx, tenant, lossGuardActor, reason); err != nil {
+		logger.Error("Loss guard cancel-all failed", "tenant_id", tenant.ID, "error", err)
+	}
+	return reason
+}
+
+func (s *PnLService) persist(ctx context.Context, b *model.PnLBaseline) {
+	if s.repo == nil {
+		return
+	}
+	if err := s.repo.SavePnLBaseline(ctx, b); err != nil {
+		logger.Error("Failed to persist PnL baseline", "tenant_id", b.TenantID, "error", err)
+	}
+}
+
+// markPrice prefers the book mid, then the last trade, then the entry price (no unrealized PnL).
+func (s *PnLService) markPrice(pos *model.Position) (decimal.Decimal, string) {
+	if s.market != nil {
+		book := s.market.GetBook(pos.TokenID)
+		if book == nil {
+			s.market.Subscribe([]string{pos.TokenID})
+		} else {
+			if mid, ok := book.Mid(); ok {
+				return mid, "mid"
+			}
+			if last := book.Meta().LastTradePrice; last.IsPositive() {
+				return last, "last_trade"
+			}
+		}
+	}
+	return pos.AvgPrice, "entry"
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/market"
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+type fakeHalter struct {
+	halted map[string]string
+	states map[string]*model.PanicState
+}
+
+func (h *fakeHalter) ActivatePanicMode(ctx context.Context, tenant *model.Tenant, actor, reason string) (*model.PanicState, error) {
+	h.halted[tenant.ID] = reason
+	if h.states == nil {
+		h.states = make(map[string]*model.PanicState)
+	}
+	h.states[tenant.ID] = &model.PanicState{Scope: model.PanicScope(tenant.ID), Active: true, Reason: reason, ActivatedBy: actor}
+	return h.states[tenant.ID], nil
+}
+
+func (h *fakeHalter) PanicStatus(tenantID string) (global, tenant *model.PanicState) {
+	if st, ok := h.states[tenantID]; ok {
+		cp := *st
+		return nil, &cp
+	}
+	return nil, nil
+}
+
+// resume lifts the tenant's halt the way an admin would.
+func (h *fakeHalter) resume(tenantID string, at time.Time) {
+	delete(h.halted, tenantID)
+	st := h.states[tenantID]
+	st.Active = false
+	st.ResumedBy = "admin"
+	st.ResumedAt = at
+}
+
+type memPnLRepo struct {
+	baselines map[string]model.PnLBaseline
+}
+
+func (r *memPnLRepo) SavePnLBaseline(ctx context.Context, b *model.PnLBaseline) error {
+	r.baselines[b.TenantID] = *b
+	return nil
+}
+
+func (r *memPnLRepo) LoadPnLBaselines(ctx context.Context) ([]*model.PnLBaseline, error) {
+	out := make([]*model.PnLBaseline, 0, len(r.baselines))
+	for _, b := range r.baselines {
+		cp := b
+		out = append(out, &cp)
+	}
+	return out, nil
+}
+
+func setMid(ms *market.MarketService, tokenID, bid, ask string) {
+	ms.Subscribe([]string{tokenID})
+	ms.GetBook(tokenID).Snapshot(
+		[]market.Level{{Price: decimal.RequireFromString(bid), Size: decimal.NewFromInt(100)}},
+		[]market.Level{{Price: decimal.RequireFromString(ask), Size: decimal.NewFromInt(100)}},
+	)
+}
+
+func TestPnLServiceTripsDailyLossAndDrawdown(t *testing.T) {
+	ctx := context.Background()
+	positions := market.NewPositionStore()
+	ms := market.NewMarketService()
+	halter := &fakeHalter{halted: make(map[string]string)}
+	audit := &recordingAudit{}
+	svc := NewPnLService(ctx, nil, positions, ms, nil, halter, audit, model.LossLimits{MaxDrawdown: 15})
+
+	now := time.Now().UTC()
+	positions.OnFill(&model.Fill{ID: "f1", TenantID: "t1", AssetID: "yes", Side: "BUY", Price: "0.50", Size: "100", Status: "MATCHED", Timestamp: now})
+	positions.OnFill(&model.Fill{ID: "f2", TenantID: "t1", AssetID: "yes", Side: "SELL", Price: "0.45", Size: "20", Status: "MATCHED", Timestamp: now})
+
+	tenant := &model.Tenant{ID: "t1", Risk: model.RiskConfig{MaxDailyLoss: 10}}
+	setMid(ms, "yes", "0.48", "0.50") // mid 0.49
+
+	pnl := svc.Snapshot("t1")
+	// realized: (0.45-0.50)*20 = -1; unrealized: (0.49-0.50)*80 = -0.8
+	if !pnl.Realized.Equal(decimal.NewFromInt(-1)) || !pnl.Unrealized.Equal(decimal.RequireFromString("-0.8")) {
+		t.Fatalf("unexpected pnl: realized %s unrealized %s", pnl.Realized, pnl.Unrealized)
+	}
+	if reason := svc.Check(ctx, tenant); reason != "" {
+		t.Fatalf("tenant within limits was halted: %s", reason)
+	}
+
+	// Price slides: unrealized (0.36-0.50)*80 = -11.2, daily loss 12.2 >= 10
+	setMid(ms, "yes", "0.35", "0.37")
+	if reason := svc.Check(ctx, tenant); reason == "" {
+		t.Fatalf("expected daily loss breach")
+	}
+	if _, ok := halter.halted["t1"]; !ok {
+		t.Fatalf("tenant should be cancel-only")
+	}
+	if len(audit.actions) != 1 || audit.actions[0] != "t1:loss_limit_breached" {
+		t.Fatalf("expected one loss audit event, got %v", audit.actions)
+	}
+
+	// Already halted: no second activation or audit entry
+	svc.Check(ctx, tenant)
+	if len(audit.actions) != 1 {
+		t.Fatalf("breach must be recorded once, got %v", audit.actions)
+	}
+
+	// Drawdown from the high-water mark uses the global default
+	positions.OnFill(&model.Fill{ID: "g1", TenantID: "t2", AssetID: "no", Side: "BUY", Price: "0.20", Size: "100", Status: "MATCHED", Timestamp: now})
+	setMid(ms, "no", "0.39", "0.41") // +20
+	other := &model.Tenant{ID: "t2"}
+	if reason := svc.Check(ctx, other); reason != "" {
+		t.Fatalf("unexpected breach at the peak: %s", reason)
+	}
+	setMid(ms, "no", "0.24", "0.26") // +5, 15 below the peak
+	if reason := svc.Check(ctx, other); reason == "" {
+		t.Fatalf("expected drawdown breach")
+	}
+}
+
+func TestPnLServiceRebasesAfterOperatorResume(t *testing.T) {
+	ctx := context.Background()
+	positions := market.NewPositionStore()
+	ms := market.NewMarketService()
+	halter := &fakeHalter{halted: make(map[string]string)}
+	repo := &memPnLRepo{baselines: make(map[string]model.PnLBaseline)}
+	svc := NewPnLService(ctx, repo, positions, ms, nil, halter, nil, model.LossLimits{MaxDrawdown: 15})
+	tenant := &model.Tenant{ID: "t1", Risk: model.RiskConfig{MaxDailyLoss: 30}}
+
+	now := time.Now().UTC()
+	positions.OnFill(&model.Fill{ID: "f1", TenantID: "t1", AssetID: "no", Side: "BUY", Price: "0.20", Size: "100", Status: "MATCHED", Timestamp: now})
+	setMid(ms, "no", "0.39", "0.41") // +20, the peak
+	svc.Check(ctx, tenant)
+
+	// The peak survives a restart
+	svc = NewPnLService(ctx, repo, positions, ms, nil, halter, nil, model.LossLimits{MaxDrawdown: 15})
+	setMid(ms, "no", "0.24", "0.26") // +5, 15 below the peak
+	if reason := svc.Check(ctx, tenant); reason == "" {
+		t.Fatalf("expected drawdown breach against the persisted peak")
+	}
+
+	halter.resume("t1", now.Add(time.Minute))
+	if reason := svc.Check(ctx, tenant); reason != "" {
+		t.Fatalf("resumed tenant was halted again: %s", reason)
+	}
+	if _, ok := halter.halted["t1"]; ok {
+		t.Fatalf("resume must not be undone by the next check")
+	}
+	if got := repo.baselines["t1"].Peak; !got.Equal(decimal.NewFromInt(5)) {
+		t.Fatalf("expected the rebased peak to be persisted, got %s", got)
+	}
+
+	// A fresh drawdown from the rebased peak still trips
+	setMid(ms, "no", "0.09", "0.11") // -10, 15 below the new peak
+	if reason := svc.Check(ctx, tenant); reason == "" {
+		t.Fatalf("expected drawdown breach from the rebased peak")
+	}
+}
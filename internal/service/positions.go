@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/GoPolymarket/polygate/internal/market"
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/pkg/logger"
+)
+
+// SeedPositions 启动时用持久化成交与 CLOB 挂单恢复每个租户的持仓与挂单敞口，
+// 否则重启后风控只能看到重启之后的成交。
+func (s *GatewayService) SeedPositions(ctx context.Context, positions *market.PositionStore, repo market.FillRepo) {
+	for _, tenant := range s.tm.ListTenants() {
+		if repo != nil {
+			if err := positions.Seed(ctx, repo, tenant.ID); errors.Is(err, market.ErrFillHistoryTruncated) {
+				logger.Error("Positions not seeded: fill history is truncated, use Postgres to keep it whole", "tenant_id", tenant.ID)
+			} else if err != nil {
+				logger.Error("Failed to seed positions from fills", "tenant_id", tenant.ID, "error", err)
+			}
+		}
+
+		orders, err := s.ListOrders(ctx, tenant, model.OrderFilter{})
+		if err != nil {
+			logger.Warn("Failed to seed open orders", "tenant_id", tenant.ID, "error", err)
+			continue
+		}
+		skipped := 0
+		for _, o := range orders {
+			// Only orders the user channel described can be priced
+			if o.OriginalSize == "" || o.Price == "" {
+				skipped++
+				continue
+			}
+			positions.OnOrderUpdate(&model.OrderUpdate{
+				OrderID:      o.ID,
+				TenantID:     tenant.ID,
+				Market:       o.Market,
+				AssetID:      o.AssetID,
+				Side:         o.Side,
+				Price:        o.Price,
+				OriginalSize: o.OriginalSize,
+				SizeMatched:  o.SizeMatched,
+				Type:         market.OrderEventUpdate,
+			})
+		}
+		if skipped > 0 {
+			logger.Warn("Open orders without details left out of exposure", "tenant_id", tenant.ID, "count", skipped)
+		}
+	}
+}
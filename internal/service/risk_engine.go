@@ -2,89 +2,342 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/GoPolymarket/polygate/internal/market"
 	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/pkg/apperrors"
+	"github.com/GoPolymarket/polygate/internal/pkg/logger"
 	"github.com/GoPolymarket/polygate/internal/pkg/metrics"
 	"github.com/shopspring/decimal"
 )
 
+// UsageRepo stores daily usage (USDC, exact to the micro). ReserveUsage must check
+// limits and take the reservation atomically so concurrent orders cannot overshoot.
 type UsageRepo interface {
-	GetDailyUsage(ctx context.Context, tenantID string) (int, float64, error)
-	AddDailyUsage(ctx context.Context, tenantID string, orders int, amount float64) error
+	GetDailyUsage(ctx context.Context, tenantID string) (int, decimal.Decimal, error)
+	AddDailyUsage(ctx context.Context, tenantID string, orders int, amount decimal.Decimal) error
+	ReserveUsage(ctx context.Context, tenantID string, orders int, amount decimal.Decimal, limits model.UsageLimits) (*model.UsageReservation, error)
+	CommitUsage(ctx context.Context, res *model.UsageReservation) error
+	ReleaseUsage(ctx context.Context, res *model.UsageReservation) error
 }
 
 type RiskEngine struct {
-	repo   UsageRepo
-	market *market.MarketService
+	repo      UsageRepo
+	market    *market.MarketService
+	positions *market.PositionStore
+	exposure  model.ExposureLimits // global defaults for tenants without their own
 }
 
 func NewRiskEngine(repo UsageRepo, marketSvc *market.MarketService) *RiskEngine {
 	return &RiskEngine{repo: repo, market: marketSvc}
 }
 
-// CheckOrder 执行下单前的所有风控检查
+// SetPositionStore enables position / exposure limits. defaults apply to any
+// limit a tenant leaves at 0.
+func (e *RiskEngine) SetPositionStore(positions *market.PositionStore, defaults model.ExposureLimits) {
+	e.positions = positions
+	e.exposure = defaults
+}
+
+// OrderReservation is the risk capacity held for in-flight orders (one, or a whole batch).
+type OrderReservation struct {
+	TenantID string
+	usage    *model.UsageReservation
+	holds    []string          // PositionStore hold IDs, one per order ("" when limits are off)
+	values   []decimal.Decimal // order value, one per order
+	replace  bool              // amend: the order replaces one already counted for the day
+}
+
+// CheckOrder 执行下单前的所有风控检查（只读，不占用额度）
 // 如果返回 error，则必须拒绝订单
 func (e *RiskEngine) CheckOrder(ctx context.Context, tenant *model.Tenant, req model.OrderRequest) error {
+	orderVal, err := e.checkOrderRules(ctx, tenant, req)
+	if err != nil {
+		return err
+	}
+
+	// 5. 持仓 / 敞口检查 (Position & Exposure)
+	if e.positions != nil {
+		if err := e.positions.Check(tenant.ID, orderExposure(req), e.exposureLimits(tenant.Risk)); err != nil {
+			return rejectExposure(err)
+		}
+	}
+
+	// 6. 每日限额检查 (Daily Limit)
+	config := tenant.Risk
+	if config.MaxDailyValue > 0 || config.MaxDailyOrders > 0 {
+		currentOrders, currentVol, err := e.repo.GetDailyUsage(ctx, tenant.ID)
+		if err != nil {
+			return fmt.Errorf("risk check failed: %w", err)
+		}
+		if err := usageLimits(config).Check(currentOrders, currentVol, 1, orderVal); err != nil {
+			return rejectUsage(err)
+		}
+	}
+
+	return nil
+}
+
+// ReserveOrder 执行与 CheckOrder 相同的检查，并原子地占用敞口与每日额度。
+// 下单成功后必须调用 CommitOrder，失败则调用 ReleaseOrder。
+func (e *RiskEngine) ReserveOrder(ctx context.Context, tenant *model.Tenant, req model.OrderRequest) (*OrderReservation, error) {
+	return e.reserve(ctx, tenant, []model.OrderRequest{req})
+}
+
+// ReserveBatch 对整批订单执行检查：单笔规则逐单检查，敞口逐单累加，
+// 每日额度按整批（订单数 + 总金额）一次性占用。任一订单不通过则整批拒绝。
+func (e *RiskEngine) ReserveBatch(ctx context.Context, tenant *model.Tenant, reqs []model.OrderRequest) (*OrderReservation, error) {
+	return e.reserve(ctx, tenant, reqs)
+}
+
+func (e *RiskEngine) reserve(ctx context.Context, tenant *model.Tenant, reqs []model.OrderRequest) (*OrderReservation, error) {
+	res := &OrderReservation{
+		TenantID: tenant.ID,
+		holds:    make([]string, len(reqs)),
+		values:   make([]decimal.Decimal, len(reqs)),
+	}
+	total := decimal.Zero
+	for i, req := range reqs {
+		orderVal, err := e.checkOrderRules(ctx, tenant, req)
+		if err != nil {
+			e.releaseHolds(res)
+			return nil, batchError(len(reqs), i, err)
+		}
+		res.values[i] = orderVal
+		total = total.Add(orderVal)
+
+		// 5. 持仓 / 敞口检查 + 预留 (Position & Exposure)
+		if e.positions != nil {
+			res.holds[i], err = e.positions.Reserve(tenant.ID, orderExposure(req), e.exposureLimits(tenant.Risk))
+			if err != nil {
+				e.releaseHolds(res)
+				return nil, batchError(len(reqs), i, rejectExposure(err))
+			}
+		}
+	}
+
+	// 6. 每日限额检查 + 预留 (Daily Limit)
+	var err error
+	res.usage, err = e.repo.ReserveUsage(ctx, tenant.ID, len(reqs), total, usageLimits(tenant.Risk))
+	if err != nil {
+		e.releaseHolds(res)
+		return nil, rejectUsage(err)
+	}
+	return res, nil
+}
+
+// OpenOrder returns a tracked open order of the tenant as an order request
+// (remaining size), or false if the order is unknown or exposure tracking is off.
+func (e *RiskEngine) OpenOrder(tenantID, orderID string) (model.OrderRequest, bool) {
+	if e.positions == nil {
+		return model.OrderRequest{}, false
+	}
+	o, ok := e.positions.OpenOrder(tenantID, orderID)
+	if !ok {
+		return model.OrderRequest{}, false
+	}
+	return model.OrderRequest{TokenID: o.TokenID, Side: o.Side, Price: o.Price, Size: o.Size}, true
+}
+
+// ReserveReplace 改单预留：替换单按完整规则检查，敞口按原单已撤销计算，
+// 每日额度只占用相对原单增加的金额（不重复计数订单数）。
+func (e *RiskEngine) ReserveReplace(ctx context.Context, tenant *model.Tenant, origID string, orig, req model.OrderRequest) (*OrderReservation, error) {
+	orderVal, err := e.checkOrderRules(ctx, tenant, req)
+	if err != nil {
+		return nil, err
+	}
+	delta := decimal.Max(orderVal.Sub(model.RoundUSDC(orig.Price.Mul(orig.Size))), decimal.Zero)
+	res := &OrderReservation{
+		TenantID: tenant.ID,
+		holds:    make([]string, 1),
+		values:   []decimal.Decimal{delta},
+		replace:  true,
+	}
+
+	if e.positions != nil {
+		res.holds[0], err = e.positions.ReserveReplacing(tenant.ID, origID, orderExposure(req), e.exposureLimits(tenant.Risk))
+		if err != nil {
+			return nil, rejectExposure(err)
+		}
+	}
+
+	if delta.IsPositive() {
+		res.usage, err = e.repo.ReserveUsage(ctx, tenant.ID, 0, delta, usageLimits(tenant.Risk))
+		if err != nil {
+			e.releaseHolds(res)
+			return nil, rejectUsage(err)
+		}
+	}
+	return res, nil
+}
+
+// CommitOrder 下单成功后调用，将预留额度计入当日用量，并登记挂单
+func (e *RiskEngine) CommitOrder(ctx context.Context, res *OrderReservation, orderID string) {
+	e.commit(ctx, res, []string{orderID}, []bool{true})
+}
+
+// CommitBatch settles a batch reservation. orderIDs lines up with the reserved
+// orders; an empty ID marks an order the exchange rejected, whose share of the
+// reservation is given back.
+func (e *RiskEngine) CommitBatch(ctx context.Context, res *OrderReservation, orderIDs []string) {
+	if res == nil {
+		return
+	}
+	accepted := make([]bool, len(res.holds))
+	for i := range accepted {
+		accepted[i] = i < len(orderIDs) && orderIDs[i] != ""
+	}
+	e.commit(ctx, res, orderIDs, accepted)
+}
+
+func (e *RiskEngine) commit(ctx context.Context, res *OrderReservation, orderIDs []string, accepted []bool) {
+	if res == nil {
+		return
+	}
+	placed := 0
+	amount := decimal.Zero
+	for i, hold := range res.holds {
+		if accepted[i] {
+			placed++
+			amount = amount.Add(res.values[i])
+		}
+		if hold == "" {
+			continue
+		}
+		if accepted[i] {
+			e.positions.Confirm(res.TenantID, hold, orderIDs[i])
+		} else {
+			e.positions.Release(res.TenantID, hold)
+		}
+	}
+	if res.usage == nil {
+		return
+	}
+	if placed == 0 {
+		e.releaseUsage(ctx, res)
+		return
+	}
+	usage := *res.usage
+	usage.Orders = placed
+	usage.Amount = amount
+	if res.replace {
+		usage.Orders = 0
+	}
+	if err := e.repo.CommitUsage(ctx, &usage); err != nil {
+		logger.Error("Failed to commit risk usage", "tenant_id", res.TenantID, "reservation_id", usage.ID, "error", err)
+	}
+}
+
+// ReleaseOrder 下单失败后调用，归还预留额度
+func (e *RiskEngine) ReleaseOrder(ctx context.Context, res *OrderReservation) {
+	if res == nil {
+		return
+	}
+	e.releaseHolds(res)
+	if res.usage != nil {
+		e.releaseUsage(ctx, res)
+	}
+}
+
+func (e *RiskEngine) releaseHolds(res *OrderReservation) {
+	for _, hold := range res.holds {
+		if hold != "" {
+			e.positions.Release(res.TenantID, hold)
+		}
+	}
+}
+
+func (e *RiskEngine) releaseUsage(ctx context.Context, res *OrderReservation) {
+	if err := e.repo.ReleaseUsage(ctx, res.usage); err != nil {
+		// The reservation expires on its own; this only delays the capacity coming back.
+		logger.Warn("Failed to release risk reservation", "tenant_id", res.TenantID, "reservation_id", res.usage.ID, "error", err)
+	}
+}
+
+// OrdersCancelled 撤单成功后调用，释放对应挂单敞口（orderID 为空表示全部撤销）
+func (e *RiskEngine) OrdersCancelled(tenantID, orderID string) {
+	if e.positions == nil {
+		return
+	}
+	if orderID == "" {
+		e.positions.ClearOrders(tenantID)
+		return
+	}
+	e.positions.RemoveOrder(tenantID, orderID)
+}
+
+// NormalizeOrder 在租户开启 RoundToTick 时将价格对齐到 tick（买单向下、卖单向上取整）
+func (e *RiskEngine) NormalizeOrder(ctx context.Context, tenant *model.Tenant, req model.OrderRequest) model.OrderRequest {
+	if !tenant.Risk.RoundToTick || e.market == nil {
+		return req
+	}
+	info, err := e.market.MarketInfo(ctx, req.TokenID)
+	if err != nil || !info.TickSize.IsPositive() {
+		return req
+	}
+	steps := req.Price.Div(info.TickSize)
+	if req.Side == "BUY" {
+		steps = steps.Floor()
+	} else {
+		steps = steps.Ceil()
+	}
+	req.Price = steps.Mul(info.TickSize)
+	return req
+}
+
+// checkOrderRules 执行无状态的检查 (1-4)，返回订单金额
+func (e *RiskEngine) checkOrderRules(ctx context.Context, tenant *model.Tenant, req model.OrderRequest) (decimal.Decimal, error) {
 	config := tenant.Risk
+	one := decimal.NewFromInt(1)
 
 	// 1. 基础检查：价格合理性 (Fat Finger Check)
-	if req.Price <= 0 || req.Price >= 1.0 {
+	if !req.Price.IsPositive() || req.Price.GreaterThanOrEqual(one) {
 		metrics.RiskRejects.WithLabelValues("price_bounds").Inc()
-		return fmt.Errorf("risk reject: price %.4f out of bounds (0-1)", req.Price)
+		return decimal.Zero, fmt.Errorf("risk reject: price %s out of bounds (0-1)", req.Price)
 	}
 
-	if req.Size <= 0 {
+	if !req.Size.IsPositive() {
 		metrics.RiskRejects.WithLabelValues("invalid_size").Inc()
-		return fmt.Errorf("risk reject: size must be positive")
+		return decimal.Zero, fmt.Errorf("risk reject: size must be positive")
+	}
+
+	// 1b. 价格单位与最小下单量 (Tick Size & Min Size)
+	if err := e.checkTickAndSize(ctx, req); err != nil {
+		return decimal.Zero, err
 	}
 
-	orderVal := req.Price * req.Size
+	orderVal := model.RoundUSDC(req.Price.Mul(req.Size))
 
 	// 2. 单笔限额 (Max Order Value)
-	if config.MaxOrderValue > 0 && orderVal > config.MaxOrderValue {
+	if config.MaxOrderValue > 0 && orderVal.GreaterThan(decimal.NewFromFloat(config.MaxOrderValue)) {
 		metrics.RiskRejects.WithLabelValues("max_value").Inc()
-		return fmt.Errorf("risk reject: order value %.2f exceeds limit %.2f", orderVal, config.MaxOrderValue)
+		return decimal.Zero, fmt.Errorf("risk reject: order value %s exceeds limit %.2f", orderVal, config.MaxOrderValue)
 	}
 
 	// 3. 价格偏离检查 (Price Deviation / Fat Finger)
 	if config.MaxSlippage > 0 && e.market != nil {
 		book := e.market.GetBook(req.TokenID)
 		if book != nil {
+			// Integrity Check: a book known to have diverged must not be trusted
+			if !book.IsValid() {
+				metrics.RiskRejects.WithLabelValues("invalid_book").Inc()
+				return decimal.Zero, fmt.Errorf("risk reject: orderbook out of sync (%s), cannot verify price safely", book.InvalidReason())
+			}
+
 			// Stale Data Check
 			if time.Since(book.LastUpdated) > 10*time.Second {
 				metrics.RiskRejects.WithLabelValues("stale_data").Inc()
-				return fmt.Errorf("risk reject: market data stale (>10s), cannot verify price safely")
+				return decimal.Zero, fmt.Errorf("risk reject: market data stale (>10s), cannot verify price safely")
 			}
 
-			reqPrice := decimal.NewFromFloat(req.Price)
-			slippage := decimal.NewFromFloat(config.MaxSlippage)
-			one := decimal.NewFromInt(1)
-			
 			bids, asks := book.GetCopy()
-
-			if req.Side == "BUY" {
-				if len(asks) > 0 {
-					bestAsk := asks[0].Price
-					maxPrice := bestAsk.Mul(one.Add(slippage))
-					if reqPrice.GreaterThan(maxPrice) {
-						metrics.RiskRejects.WithLabelValues("slippage").Inc()
-						return fmt.Errorf("risk reject: buy price %.4f deviates too much from best ask %.4f (limit: %.4f)", 
-							req.Price, bestAsk.InexactFloat64(), maxPrice.InexactFloat64())
-					}
-				}
-			} else {
-				if len(bids) > 0 {
-					bestBid := bids[0].Price
-					minPrice := bestBid.Mul(one.Sub(slippage))
-					if reqPrice.LessThan(minPrice) {
-						metrics.RiskRejects.WithLabelValues("slippage").Inc()
-						return fmt.Errorf("risk reject: sell price %.4f deviates too much from best bid %.4f (limit: %.4f)",
-							req.Price, bestBid.InexactFloat64(), minPrice.InexactFloat64())
-					}
-				}
+			if err := checkSweepSlippage(bids, asks, decimal.NewFromFloat(config.MaxSlippage), req); err != nil {
+				metrics.RiskRejects.WithLabelValues("slippage").Inc()
+				return decimal.Zero, err
 			}
 		}
 	}
@@ -93,36 +346,109 @@ func (e *RiskEngine) CheckOrder(ctx context.Context, tenant *model.Tenant, req m
 	for _, restrictedID := range config.RestrictedMkts {
 		if req.TokenID == restrictedID {
 			metrics.RiskRejects.WithLabelValues("restricted_market").Inc()
-			return fmt.Errorf("risk reject: market %s is restricted", req.TokenID)
+			return decimal.Zero, fmt.Errorf("risk reject: market %s is restricted", req.TokenID)
 		}
 	}
+	return orderVal, nil
+}
 
-	// 5. 每日限额检查 (Daily Limit)
-	if config.MaxDailyValue > 0 || config.MaxDailyOrders > 0 {
-		currentOrders, currentVol, err := e.repo.GetDailyUsage(ctx, tenant.ID)
-		if err != nil {
-			return fmt.Errorf("risk check failed: %w", err)
-		}
+// checkSweepSlippage rejects an order whose crossing part, walked level by level up
+// to its limit price, would fill at an average price more than maxSlippage worse
+// than mid. An order that does not cross only rests and passes.
+func checkSweepSlippage(bids, asks []market.Level, maxSlippage decimal.Decimal, req model.OrderRequest) error {
+	side := strings.ToUpper(req.Side)
+	sweep := market.CrossingSweep(bids, asks, side, req.Size, req.Price)
+	ref := market.SlippageRef(bids, asks, side)
+	if slip := sweep.Slippage(side, ref); slip.GreaterThan(maxSlippage) {
+		return fmt.Errorf("risk reject: %s of %s up to %s would fill at avg %s across %d levels, %s from %s (limit: %s)",
+			strings.ToLower(side), sweep.Size, req.Price, sweep.AvgPrice(), sweep.Levels, slip, ref, maxSlippage)
+	}
+	return nil
+}
 
-		if config.MaxDailyValue > 0 && currentVol+orderVal > config.MaxDailyValue {
-			metrics.RiskRejects.WithLabelValues("daily_volume_limit").Inc()
-			return fmt.Errorf("risk reject: daily volume limit exceeded (curr: %.2f, new: %.2f, max: %.2f)",
-				currentVol, orderVal, config.MaxDailyValue)
+// checkTickAndSize rejects orders the CLOB would refuse for their price increment or size.
+// Without market info (lookup failed) the check is skipped and the CLOB has the final word.
+func (e *RiskEngine) checkTickAndSize(ctx context.Context, req model.OrderRequest) error {
+	if e.market == nil {
+		return nil
+	}
+	info, err := e.market.MarketInfo(ctx, req.TokenID)
+	if err != nil {
+		logger.Warn("Tick size unavailable, skipping tick check", "token_id", req.TokenID, "error", err)
+		return nil
+	}
+	if e.positions != nil && info.Market != "" {
+		e.positions.SetMarket(req.TokenID, info.Market)
+	}
+
+	price := req.Price
+	tick := info.TickSize
+	if tick.IsPositive() {
+		if !price.Mod(tick).IsZero() {
+			metrics.RiskRejects.WithLabelValues("tick_size").Inc()
+			return apperrors.NewInvalidRequest(fmt.Sprintf("price %s is not a multiple of tick size %s", price, tick))
 		}
-		if config.MaxDailyOrders > 0 && currentOrders+1 > config.MaxDailyOrders {
-			metrics.RiskRejects.WithLabelValues("daily_order_limit").Inc()
-			return fmt.Errorf("risk reject: daily order limit exceeded (curr: %d, max: %d)",
-				currentOrders, config.MaxDailyOrders)
+		if price.LessThan(tick) || price.GreaterThan(decimal.NewFromInt(1).Sub(tick)) {
+			metrics.RiskRejects.WithLabelValues("tick_size").Inc()
+			return apperrors.NewInvalidRequest(fmt.Sprintf("price %s outside tradable range [%s, %s]", price, tick, decimal.NewFromInt(1).Sub(tick)))
 		}
 	}
-
+	size := req.Size
+	if info.MinSize.IsPositive() && size.LessThan(info.MinSize) {
+		metrics.RiskRejects.WithLabelValues("min_size").Inc()
+		return apperrors.NewInvalidRequest(fmt.Sprintf("size %s is below the minimum order size %s", size, info.MinSize))
+	}
 	return nil
 }
 
-// PostOrderHook 下单成功后调用，用于更新风控状态
-func (e *RiskEngine) PostOrderHook(ctx context.Context, tenant *model.Tenant, req model.OrderRequest) {
-	orderVal := req.Price * req.Size
-	// Async or Sync? For strict limits, Sync is better but slower.
-	// We'll do Sync here to ensure consistency.
-	_ = e.repo.AddDailyUsage(ctx, tenant.ID, 1, orderVal)
-}
\ No newline at end of file
+func usageLimits(config model.RiskConfig) model.UsageLimits {
+	return model.UsageLimits{MaxDailyValue: decimal.NewFromFloat(config.MaxDailyValue), MaxDailyOrders: config.MaxDailyOrders}
+}
+
+func (e *RiskEngine) exposureLimits(config model.RiskConfig) model.ExposureLimits {
+	return model.ExposureLimits{
+		MaxPositionSize:   chooseFloat(e.exposure.MaxPositionSize, config.MaxPositionSize),
+		MaxEventValue:     chooseFloat(e.exposure.MaxEventValue, config.MaxEventValue),
+		MaxOpenOrderValue: chooseFloat(e.exposure.MaxOpenOrderValue, config.MaxOpenOrderValue),
+	}
+}
+
+func orderExposure(req model.OrderRequest) market.OrderExposure {
+	return market.OrderExposure{
+		TokenID: req.TokenID,
+		Side:    req.Side,
+		Price:   req.Price,
+		Size:    req.Size,
+	}
+}
+
+// batchError prefixes a per-order error with the order's position in a batch.
+func batchError(size, index int, err error) error {
+	if size <= 1 {
+		return err
+	}
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		return apperrors.New(appErr.Type, fmt.Sprintf("order %d: %s", index, appErr.Message), appErr.Cause)
+	}
+	return fmt.Errorf("order %d: %w", index, err)
+}
+
+// rejectExposure records the reject metric for exposure limit errors.
+func rejectExposure(err error) error {
+	var limitErr *model.ExposureLimitError
+	if errors.As(err, &limitErr) {
+		metrics.RiskRejects.WithLabelValues(limitErr.Reason).Inc()
+	}
+	return err
+}
+
+// rejectUsage records the reject metric for limit errors and wraps store failures.
+func rejectUsage(err error) error {
+	var limitErr *model.UsageLimitError
+	if errors.As(err, &limitErr) {
+		metrics.RiskRejects.WithLabelValues(limitErr.Reason).Inc()
+		return limitErr
+	}
+	return fmt.Errorf("risk check failed: %w", err)
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/market"
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/pkg/apperrors"
+	"github.com/GoPolymarket/polymarket-go-sdk/pkg/clob/clobtypes"
+	"github.com/shopspring/decimal"
+)
+
+type fixedInfoSource struct {
+	info market.MarketInfo
+}
+
+func (s *fixedInfoSource) MarketInfo(ctx context.Context, tokenID string) (market.MarketInfo, error) {
+	info := s.info
+	info.TokenID = tokenID
+	info.FetchedAt = time.Now()
+	return info, nil
+}
+
+func TestRiskEngineTickAndMinSize(t *testing.T) {
+	ctx := context.Background()
+	ms := market.NewMarketService()
+	ms.SetMarketInfoSource(&fixedInfoSource{info: market.MarketInfo{
+		TickSize: decimal.RequireFromString("0.01"),
+		MinSize:  decimal.NewFromInt(5),
+	}}, time.Minute)
+	engine := NewRiskEngine(NewRiskUsageStore(), ms)
+	tenant := &model.Tenant{ID: "t1"}
+
+	d := decimal.RequireFromString
+
+	isInvalidRequest := func(err error) bool {
+		var appErr *apperrors.AppError
+		return errors.As(err, &appErr) && appErr.Type == apperrors.ErrInvalidRequest
+	}
+
+	if err := engine.CheckOrder(ctx, tenant, model.OrderRequest{TokenID: "111", Price: d("0.55"), Size: d("10"), Side: "BUY"}); err != nil {
+		t.Fatalf("valid order rejected: %v", err)
+	}
+	if err := engine.CheckOrder(ctx, tenant, model.OrderRequest{TokenID: "111", Price: d("0.555"), Size: d("10"), Side: "BUY"}); !isInvalidRequest(err) {
+		t.Fatalf("expected INVALID_REQUEST for off-tick price, got %v", err)
+	}
+	if err := engine.CheckOrder(ctx, tenant, model.OrderRequest{TokenID: "111", Price: d("0.55"), Size: d("4"), Side: "BUY"}); !isInvalidRequest(err) {
+		t.Fatalf("expected INVALID_REQUEST for undersized order, got %v", err)
+	}
+
+	// Opted-in tenants get the price moved onto the tick, in their favour
+	tenant.Risk.RoundToTick = true
+	buy := engine.NormalizeOrder(ctx, tenant, model.OrderRequest{TokenID: "111", Price: d("0.555"), Size: d("10"), Side: "BUY"})
+	sell := engine.NormalizeOrder(ctx, tenant, model.OrderRequest{TokenID: "111", Price: d("0.555"), Size: d("10"), Side: "SELL"})
+	if !buy.Price.Equal(d("0.55")) || !sell.Price.Equal(d("0.56")) {
+		t.Fatalf("unexpected rounding: buy %v sell %v", buy.Price, sell.Price)
+	}
+	if err := engine.CheckOrder(ctx, tenant, buy); err != nil {
+		t.Fatalf("rounded order rejected: %v", err)
+	}
+}
+
+func TestRiskEngineDailyValueIsExact(t *testing.T) {
+	ctx := context.Background()
+	engine := NewRiskEngine(NewRiskUsageStore(), nil)
+	tenant := &model.Tenant{ID: "t1", Risk: model.RiskConfig{MaxDailyValue: 0.3}}
+	req := model.OrderRequest{TokenID: "111", Price: decimal.RequireFromString("0.1"), Size: decimal.NewFromInt(1), Side: "BUY"}
+
+	// 0.1 + 0.1 + 0.1 == 0.3 must fit; with float64 the third order overshoots the limit
+	for i := 0; i < 3; i++ {
+		res, err := engine.ReserveOrder(ctx, tenant, req)
+		if err != nil {
+			t.Fatalf("order %d rejected: %v", i+1, err)
+		}
+		engine.CommitOrder(ctx, res, "")
+	}
+	if _, err := engine.ReserveOrder(ctx, tenant, req); err == nil {
+		t.Fatalf("expected daily volume limit once 0.3 is used")
+	}
+	_, volume, _ := engine.repo.GetDailyUsage(ctx, "t1")
+	if volume.String() != "0.3" {
+		t.Fatalf("expected volume 0.3, got %s", volume)
+	}
+}
+
+func TestMicroUSDCConversion(t *testing.T) {
+	cases := map[string]int64{
+		"0":          0,
+		"1.5":        1500000,
+		"0.000001":   1,
+		"0.0000001":  1, // sub-micro amounts round up
+		"1234.56789": 1234567890,
+	}
+	for in, want := range cases {
+		if got := model.ToMicroUSDC(decimal.RequireFromString(in)); got != want {
+			t.Fatalf("ToMicroUSDC(%s) = %d, want %d", in, got, want)
+		}
+	}
+	if got := model.FromMicroUSDC(1500000); !got.Equal(decimal.RequireFromString("1.5")) {
+		t.Fatalf("FromMicroUSDC(1500000) = %s", got)
+	}
+}
+
+func TestRequestFromOrderUsesBaseUnits(t *testing.T) {
+	order := &clobtypes.Order{
+		Side:        "BUY",
+		MakerAmount: decimal.NewFromInt(5500000),  // 5.5 USDC
+		TakerAmount: decimal.NewFromInt(10000000), // 10 shares
+	}
+	req := requestFromOrder(&clobtypes.SignableOrder{Order: order})
+	if !req.Price.Equal(decimal.RequireFromString("0.55")) || !req.Size.Equal(decimal.NewFromInt(10)) {
+		t.Fatalf("unexpected price/size: %s / %s", req.Price, req.Size)
+	}
+
+	order.Side = "SELL"
+	order.MakerAmount = decimal.NewFromInt(3000000) // 3 shares
+	order.TakerAmount = decimal.NewFromInt(1230000) // 1.23 USDC
+	req = requestFromOrder(&clobtypes.SignableOrder{Order: order})
+	if !req.Price.Equal(decimal.RequireFromString("0.41")) || !req.Size.Equal(decimal.NewFromInt(3)) {
+		t.Fatalf("unexpected price/size: %s / %s", req.Price, req.Size)
+	}
+}
+
+func TestRiskEngineBatchUsesAggregateNotional(t *testing.T) {
+	ctx := context.Background()
+	engine := NewRiskEngine(NewRiskUsageStore(), nil)
+	tenant := &model.Tenant{ID: "t1", Risk: model.RiskConfig{MaxDailyValue: 10}}
+	order := model.OrderRequest{TokenID: "111", Price: decimal.RequireFromString("0.4"), Size: decimal.NewFromInt(10), Side: "BUY"}
+
+	// 3 x 4 USDC: each order fits on its own, the batch does not
+	if _, err := engine.ReserveBatch(ctx, tenant, []model.OrderRequest{order, order, order}); err == nil {
+		t.Fatalf("expected batch over the daily value to be rejected")
+	}
+
+	// A bad order rejects the batch and names its index
+	bad := order
+	bad.Price = decimal.RequireFromString("1.5")
+	if _, err := engine.ReserveBatch(ctx, tenant, []model.OrderRequest{order, bad}); err == nil || !strings.Contains(err.Error(), "order 1") {
+		t.Fatalf("expected order 1 to be reported, got %v", err)
+	}
+
+	res, err := engine.ReserveBatch(ctx, tenant, []model.OrderRequest{order, order})
+	if err != nil {
+		t.Fatalf("batch within limits rejected: %v", err)
+	}
+	// Only the accepted order is counted; the rejected one frees its share
+	engine.CommitBatch(ctx, res, []string{"0x1", ""})
+	orders, volume, _ := engine.repo.GetDailyUsage(ctx, "t1")
+	if orders != 1 || !volume.Equal(decimal.NewFromInt(4)) {
+		t.Fatalf("expected 1 order / 4 volume, got %d / %s", orders, volume)
+	}
+	if _, err := engine.ReserveBatch(ctx, tenant, []model.OrderRequest{order}); err != nil {
+		t.Fatalf("released share should be reusable: %v", err)
+	}
+}
+
+func TestRiskEngineReplaceCountsOnlyTheDelta(t *testing.T) {
+	ctx := context.Background()
+	engine := NewRiskEngine(NewRiskUsageStore(), nil)
+	positions := market.NewPositionStore()
+	engine.SetPositionStore(positions, model.ExposureLimits{})
+	tenant := &model.Tenant{ID: "t1", Risk: model.RiskConfig{MaxDailyValue: 10, MaxDailyOrders: 1, MaxOpenOrderValue: 6}}
+	d := decimal.RequireFromString
+
+	res, err := engine.ReserveOrder(ctx, tenant, model.OrderRequest{TokenID: "111", Price: d("0.5"), Size: d("10"), Side: "BUY"})
+	if err != nil {
+		t.Fatalf("reserve original: %v", err)
+	}
+	engine.CommitOrder(ctx, res, "o1")
+
+	orig, ok := engine.OpenOrder("t1", "o1")
+	if !ok || !orig.Size.Equal(d("10")) {
+		t.Fatalf("original order not tracked: %+v", orig)
+	}
+
+	// 5 -> 6 USDC: fits the open-order limit only because o1 is being replaced,
+	// and does not count as a second order for the day
+	res, err = engine.ReserveReplace(ctx, tenant, "o1", orig, model.OrderRequest{TokenID: "111", Price: d("0.6"), Size: d("10"), Side: "BUY"})
+	if err != nil {
+		t.Fatalf("replace rejected: %v", err)
+	}
+	engine.OrdersCancelled("t1", "o1")
+	engine.CommitOrder(ctx, res, "o2")
+
+	orders, volume, _ := engine.repo.GetDailyUsage(ctx, "t1")
+	if orders != 1 || !volume.Equal(d("6")) {
+		t.Fatalf("expected 1 order / 6 volume, got %d / %s", orders, volume)
+	}
+
+	// Shrinking an order reserves nothing
+	orig, _ = engine.OpenOrder("t1", "o2")
+	res, err = engine.ReserveReplace(ctx, tenant, "o2", orig, model.OrderRequest{TokenID: "111", Price: d("0.6"), Size: d("5"), Side: "BUY"})
+	if err != nil {
+		t.Fatalf("shrink rejected: %v", err)
+	}
+	engine.ReleaseOrder(ctx, res)
+	if _, volume, _ = engine.repo.GetDailyUsage(ctx, "t1"); !volume.Equal(d("6")) {
+		t.Fatalf("shrink changed usage: %s", volume)
+	}
+}
+
+func TestRiskEngineSlippageSweepsTheBook(t *testing.T) {
+	ctx := context.Background()
+	d := decimal.RequireFromString
+	ms := market.NewMarketService()
+	ms.Subscribe([]string{"111"})
+	ms.GetBook("111").Snapshot(
+		[]market.Level{{Price: d("0.49"), Size: d("100")}},
+		[]market.Level{{Price: d("0.51"), Size: d("1")}, {Price: d("0.56"), Size: d("100")}},
+	)
+	engine := NewRiskEngine(NewRiskUsageStore(), ms)
+	tenant := &model.Tenant{ID: "t1"}
+	tenant.Risk.MaxSlippage = 0.10
+
+	// A generous limit is fine when the size fills near mid (0.50)
+	if err := engine.CheckOrder(ctx, tenant, model.OrderRequest{TokenID: "111", Price: d("0.99"), Size: d("1"), Side: "BUY"}); err != nil {
+		t.Fatalf("small crossing order rejected: %v", err)
+	}
+	if err := engine.CheckOrder(ctx, tenant, model.OrderRequest{TokenID: "111", Price: d("0.01"), Size: d("10"), Side: "SELL"}); err != nil {
+		t.Fatalf("sell into a deep bid rejected: %v", err)
+	}
+	// Within 10% of the best ask, but the size walks into 0.56 and averages 0.5575
+	req := model.OrderRequest{TokenID: "111", Price: d("0.56"), Size: d("20"), Side: "BUY"}
+	if err := engine.CheckOrder(ctx, tenant, req); err == nil || !strings.Contains(err.Error(), "avg 0.5575") {
+		t.Fatalf("expected the sweep to exceed max slippage, got %v", err)
+	}
+
+	// The REST-book check used by the direct path agrees
+	book := clobtypes.OrderBookResponse{
+		Bids: []clobtypes.PriceLevel{{Price: "0.49", Size: "100"}},
+		Asks: []clobtypes.PriceLevel{{Price: "0.51", Size: "1"}, {Price: "0.56", Size: "100"}},
+	}
+	if err := checkSlippage(book, 0.10, req); err == nil {
+		t.Fatalf("expected the REST book sweep to exceed max slippage")
+	}
+	req.Size = d("1")
+	if err := checkSlippage(book, 0.10, req); err != nil {
+		t.Fatalf("small crossing order rejected: %v", err)
+	}
+}
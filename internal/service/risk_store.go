@@ -4,38 +4,105 @@ import (
 	"context"
 	"sync"
 	"time"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 // RiskUsageStore 跟踪租户的实时用量（如当日交易额）
 type RiskUsageStore struct {
 	mu          sync.RWMutex
-	dailyVolume map[string]float64 // Key: TenantID:YYYY-MM-DD
+	dailyVolume map[string]decimal.Decimal // Key: TenantID:YYYY-MM-DD
 	dailyOrders map[string]int
+	pending     map[string]*pendingUsage // Key: Reservation ID
+	ttl         time.Duration
+}
+
+type pendingUsage struct {
+	key       string
+	orders    int
+	amount    decimal.Decimal
+	expiresAt time.Time
 }
 
 func NewRiskUsageStore() *RiskUsageStore {
 	return &RiskUsageStore{
-		dailyVolume: make(map[string]float64),
+		dailyVolume: make(map[string]decimal.Decimal),
 		dailyOrders: make(map[string]int),
+		pending:     make(map[string]*pendingUsage),
+		ttl:         model.DefaultReservationTTL,
 	}
 }
 
-func (s *RiskUsageStore) GetDailyUsage(ctx context.Context, tenantID string) (int, float64, error) {
+func (s *RiskUsageStore) GetDailyUsage(ctx context.Context, tenantID string) (int, decimal.Decimal, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	key := s.makeKey(tenantID)
 	return s.dailyOrders[key], s.dailyVolume[key], nil
 }
 
-func (s *RiskUsageStore) AddDailyUsage(ctx context.Context, tenantID string, orders int, amount float64) error {
+func (s *RiskUsageStore) AddDailyUsage(ctx context.Context, tenantID string, orders int, amount decimal.Decimal) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	key := s.makeKey(tenantID)
-	s.dailyVolume[key] += amount
+	s.dailyVolume[key] = s.dailyVolume[key].Add(amount)
 	s.dailyOrders[key] += orders
 	return nil
 }
 
+// ReserveUsage 原子地检查限额并占用额度（已提交 + 未提交预留 + 本单）
+func (s *RiskUsageStore) ReserveUsage(ctx context.Context, tenantID string, orders int, amount decimal.Decimal, limits model.UsageLimits) (*model.UsageReservation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	key := s.makeKey(tenantID)
+	curOrders, curVolume := s.dailyOrders[key], s.dailyVolume[key]
+	for id, p := range s.pending {
+		if now.After(p.expiresAt) {
+			delete(s.pending, id)
+			continue
+		}
+		if p.key == key {
+			curOrders += p.orders
+			curVolume = curVolume.Add(p.amount)
+		}
+	}
+
+	if err := limits.Check(curOrders, curVolume, orders, amount); err != nil {
+		return nil, err
+	}
+
+	res := &model.UsageReservation{
+		ID:       uuid.New().String(),
+		TenantID: tenantID,
+		Day:      key,
+		Orders:   orders,
+		Amount:   amount,
+	}
+	s.pending[res.ID] = &pendingUsage{key: key, orders: orders, amount: amount, expiresAt: now.Add(s.ttl)}
+	return res, nil
+}
+
+// CommitUsage turns a reservation into usage. An expired reservation is still
+// counted: the order went through, so the usage is real.
+func (s *RiskUsageStore) CommitUsage(ctx context.Context, res *model.UsageReservation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, res.ID)
+	s.dailyVolume[res.Day] = s.dailyVolume[res.Day].Add(res.Amount)
+	s.dailyOrders[res.Day] += res.Orders
+	return nil
+}
+
+func (s *RiskUsageStore) ReleaseUsage(ctx context.Context, res *model.UsageReservation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, res.ID)
+	return nil
+}
+
 func (s *RiskUsageStore) makeKey(tenantID string) string {
 	// 按 UTC 日期分割
 	return tenantID + ":" + time.Now().UTC().Format("2006-01-02")
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+func TestRiskUsageStoreReservationsAreExactUnderConcurrency(t *testing.T) {
+	ctx := context.Background()
+	store := NewRiskUsageStore()
+	limits := model.UsageLimits{MaxDailyValue: decimal.NewFromInt(100), MaxDailyOrders: 1000}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var accepted []*model.UsageReservation
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := store.ReserveUsage(ctx, "t1", 1, decimal.NewFromInt(10), limits)
+			if err != nil {
+				var limitErr *model.UsageLimitError
+				if !errors.As(err, &limitErr) || limitErr.Reason != "daily_volume_limit" {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			mu.Lock()
+			accepted = append(accepted, res)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if len(accepted) != 10 {
+		t.Fatalf("expected exactly 10 reservations, got %d", len(accepted))
+	}
+
+	// Release one, commit the rest: the freed slot becomes available again.
+	if err := store.ReleaseUsage(ctx, accepted[0]); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	for _, res := range accepted[1:] {
+		if err := store.CommitUsage(ctx, res); err != nil {
+			t.Fatalf("commit: %v", err)
+		}
+	}
+	orders, volume, _ := store.GetDailyUsage(ctx, "t1")
+	if orders != 9 || !volume.Equal(decimal.NewFromInt(90)) {
+		t.Fatalf("expected 9 orders / 90 volume committed, got %d / %s", orders, volume)
+	}
+	if _, err := store.ReserveUsage(ctx, "t1", 1, decimal.NewFromInt(10), limits); err != nil {
+		t.Fatalf("released capacity should be reusable: %v", err)
+	}
+	if _, err := store.ReserveUsage(ctx, "t1", 1, decimal.NewFromInt(10), limits); err == nil {
+		t.Fatalf("expected limit to be enforced after reuse")
+	}
+}
+
+func TestRiskUsageStoreExpiredReservationsFreeCapacity(t *testing.T) {
+	ctx := context.Background()
+	store := NewRiskUsageStore()
+	store.ttl = -time.Second
+	limits := model.UsageLimits{MaxDailyOrders: 1}
+
+	if _, err := store.ReserveUsage(ctx, "t1", 1, decimal.NewFromInt(1), limits); err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	if _, err := store.ReserveUsage(ctx, "t1", 1, decimal.NewFromInt(1), limits); err != nil {
+		t.Fatalf("expired reservation should not hold capacity: %v", err)
+	}
+	if _, err := store.ReserveUsage(ctx, "t2", 1, decimal.NewFromInt(1), limits); err != nil {
+		t.Fatalf("tenants must not share limits: %v", err)
+	}
+}
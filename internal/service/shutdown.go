@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GoPolymarket/polygate/internal/market"
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polygate/internal/pkg/logger"
+	"github.com/GoPolymarket/polymarket-go-sdk/pkg/clob/clobtypes"
+	"github.com/shopspring/decimal"
+)
+
+// Shutdown cancel policies
+const (
+	ShutdownCancelAll           = "cancel_all"            // CancelAll per tenant (also hits orders placed elsewhere)
+	ShutdownCancelGatewayOrders = "cancel_gateway_orders" // only orders this process placed
+	ShutdownCancelNone          = "none"
+)
+
+const shutdownRetryBackoff = 200 * time.Millisecond
+
+// ShutdownReport summarises the cancel sweep run on SIGTERM
+type ShutdownReport struct {
+	Policy    string            `json:"policy"`
+	Tenants   int               `json:"tenants"`
+	Cancelled map[string]int    `json:"cancelled"` // TenantID -> orders (-1 when upstream did not report a count)
+	Skipped   []string          `json:"skipped,omitempty"`
+	Failed    map[string]string `json:"failed,omitempty"`
+	Duration  time.Duration     `json:"duration"`
+}
+
+// CancelOnShutdown applies the shutdown policy to every tenant before ctx expires.
+// Each tenant is retried up to `retries` extra times; the summary goes to the audit sink.
+func (s *GatewayService) CancelOnShutdown(ctx context.Context, policy string, retries int, audit AuditSink) *ShutdownReport {
+	start := time.Now()
+	report := &ShutdownReport{
+		Policy:    policy,
+		Cancelled: make(map[string]int),
+		Failed:    make(map[string]string),
+	}
+	if policy == "" || policy == ShutdownCancelNone {
+		return report
+	}
+
+	tenants := s.tm.ListTenants()
+	report.Tenants = len(tenants)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, tenant := range tenants {
+		if tenant.Creds.L2ApiKey == "" {
+			report.Skipped = append(report.Skipped, tenant.ID)
+			continue
+		}
+		wg.Add(1)
+		go func(t *model.Tenant) {
+			defer wg.Done()
+			count, err := s.cancelWithRetry(ctx, t, policy, retries)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				report.Failed[t.ID] = err.Error()
+				return
+			}
+			report.Cancelled[t.ID] = count
+		}(tenant)
+	}
+	wg.Wait()
+	sort.Strings(report.Skipped)
+	report.Duration = time.Since(start)
+
+	logger.Info("Shutdown cancel sweep finished", "policy", policy, "tenants", report.Tenants,
+		"cancelled", len(report.Cancelled), "failed", len(report.Failed), "duration", report.Duration)
+	if audit != nil {
+		audit.Log(systemAuditEntry("", "shutdown_cancel", map[string]interface{}{
+			"policy":      report.Policy,
+			"tenants":     report.Tenants,
+			"cancelled":   report.Cancelled,
+			"skipped":     report.Skipped,
+			"failed":      report.Failed,
+			"duration_ms": report.Duration.Milliseconds(),
+		}))
+	}
+	return report
+}
+
+func (s *GatewayService) cancelWithRetry(ctx context.Context, tenant *model.Tenant, policy string, retries int) (int, error) {
+	var lastErr error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return 0, fmt.Errorf("deadline exceeded after %d attempts: %w", attempt, lastErr)
+			case <-time.After(shutdownRetryBackoff * time.Duration(attempt)):
+			}
+		}
+		var count int
+		var err error
+		switch policy {
+		case ShutdownCancelGatewayOrders:
+			count, err = s.CancelGatewayOrders(ctx, tenant)
+		default:
+			var resp *clobtypes.CancelAllResponse
+			resp, err = s.CancelAllOrders(ctx, tenant)
+			count = -1
+			if resp != nil && resp.Count > 0 {
+				count = resp.Count
+			}
+		}
+		if err == nil {
+			return count, nil
+		}
+		lastErr = err
+		logger.Warn("Shutdown cancel attempt failed", "tenant_id", tenant.ID, "attempt", attempt+1, "error", err)
+	}
+	return 0, lastErr
+}
+
+// CancelGatewayOrders cancels only the orders this gateway placed for the tenant.
+func (s *GatewayService) CancelGatewayOrders(ctx context.Context, tenant *model.Tenant) (int, error) {
+	ids := s.placed.list(tenant.ID)
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	client, err := s.tm.GetClientForTenant(tenant)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := client.CLOB.CancelOrders(ctx, &clobtypes.CancelOrdersRequest{OrderIDs: ids}); err != nil {
+		return 0, fmt.Errorf("failed to cancel gateway orders: %w", err)
+	}
+	for _, id := range ids {
+		s.placed.remove(tenant.ID, id)
+	}
+	return len(ids), nil
+}
+
+// OnFill is a no-op: an order is known to be done only from its order update.
+func (s *GatewayService) OnFill(fill *model.Fill) {}
+
+// OnOrderUpdate forgets an order once it is fully matched or cancelled, so the
+// shutdown sweep and session kills only target orders still resting.
+func (s *GatewayService) OnOrderUpdate(update *model.OrderUpdate) {
+	if update == nil || update.OrderID == "" || !orderDone(update) {
+		return
+	}
+	s.placed.remove(update.TenantID, update.OrderID)
+	s.sessions.removeFromAll(heartbeatKey(update.TenantID, ""), update.OrderID)
+}
+
+// orderDone reports whether an order update leaves nothing resting on the book.
+func orderDone(update *model.OrderUpdate) bool {
+	if strings.EqualFold(update.Type, market.OrderEventCancellation) {
+		return true
+	}
+	original, err1 := decimal.NewFromString(update.OriginalSize)
+	matched, err2 := decimal.NewFromString(update.SizeMatched)
+	return err1 == nil && err2 == nil && original.IsPositive() && matched.GreaterThanOrEqual(original)
+}
+
+// placedOrders remembers order IDs placed through this process, per tenant.
+type placedOrders struct {
+	mu     sync.Mutex
+	orders map[string]map[string]struct{} // TenantID -> OrderID set
+}
+
+func newPlacedOrders() *placedOrders {
+	return &placedOrders{orders: make(map[string]map[string]struct{})}
+}
+
+func (p *placedOrders) add(tenantID, orderID string) {
+	if orderID == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	set, ok := p.orders[tenantID]
+	if !ok {
+		set = make(map[string]struct{})
+		p.orders[tenantID] = set
+	}
+	set[orderID] = struct{}{}
+}
+
+func (p *placedOrders) remove(tenantID, orderID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.orders[tenantID], orderID)
+}
+
+func (p *placedOrders) clear(tenantID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.orders, tenantID)
+}
+
+func (p *placedOrders) list(tenantID string) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ids := make([]string, 0, len(p.orders[tenantID]))
+	for id := range p.orders[tenantID] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// The helpers below serve sets keyed "<tenant>/<session>" (heartbeat sessions).
+
+// removeFromAll drops orderID from every set whose key starts with prefix.
+func (p *placedOrders) removeFromAll(prefix, orderID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, set := range p.orders {
+		if strings.HasPrefix(key, prefix) {
+			delete(set, orderID)
+		}
+	}
+}
+
+// clearAll drops every set whose key starts with prefix.
+func (p *placedOrders) clearAll(prefix string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key := range p.orders {
+		if strings.HasPrefix(key, prefix) {
+			delete(p.orders, key)
+		}
+	}
+}
+
+// keyOf returns the key (starting with prefix) of the set holding orderID.
+func (p *placedOrders) keyOf(prefix, orderID string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, set := range p.orders {
+		if _, ok := set[orderID]; ok && strings.HasPrefix(key, prefix) {
+			return key, true
+		}
+	}
+	return "", false
+}
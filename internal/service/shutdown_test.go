@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/GoPolymarket/polygate/internal/config"
+	"github.com/GoPolymarket/polygate/internal/market"
+	"github.com/GoPolymarket/polygate/internal/model"
+	"github.com/GoPolymarket/polymarket-go-sdk"
+	"github.com/GoPolymarket/polymarket-go-sdk/pkg/auth"
+)
+
+func TestCancelOnShutdownSkipsTenantsWithoutCreds(t *testing.T) {
+	cfg := &config.Config{}
+	tm := NewTenantManager(cfg, nil)
+	tm.RegisterTenant(&model.Tenant{ID: "watch-only", ApiKey: "sk-watch"})
+	gw, err := NewGatewayService(cfg, tm, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("gateway init failed: %v", err)
+	}
+
+	audit := &recordingAudit{}
+	if report := gw.CancelOnShutdown(context.Background(), ShutdownCancelNone, 2, audit); report.Tenants != 0 || len(audit.actions) != 0 {
+		t.Fatalf("policy none must not touch tenants: %+v", report)
+	}
+
+	report := gw.CancelOnShutdown(context.Background(), ShutdownCancelAll, 2, audit)
+	if len(report.Skipped) != 1 || report.Skipped[0] != "watch-only" || len(report.Failed) != 0 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if len(audit.actions) != 1 || audit.actions[0] != ":shutdown_cancel" {
+		t.Fatalf("expected a shutdown summary audit entry, got %v", audit.actions)
+	}
+}
+
+func TestPlacedOrdersTracksPerTenant(t *testing.T) {
+	p := newPlacedOrders()
+	p.add("a", "o2")
+	p.add("a", "o1")
+	p.add("b", "o3")
+	p.remove("a", "o2")
+	if ids := p.list("a"); len(ids) != 1 || ids[0] != "o1" {
+		t.Fatalf("unexpected ids for a: %v", ids)
+	}
+	p.clear("b")
+	if ids := p.list("b"); len(ids) != 0 {
+		t.Fatalf("expected b to be cleared: %v", ids)
+	}
+}
+
+func TestCancelGatewayOrdersCancelsOnlyRestingOrders(t *testing.T) {
+	var mu sync.Mutex
+	var cancelled []string
+	clob := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete || r.URL.Path != "/orders" {
+			http.NotFound(w, r)
+			return
+		}
+		var ids []string
+		_ = json.NewDecoder(r.Body).Decode(&ids)
+		mu.Lock()
+		cancelled = append(cancelled, ids...)
+		mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"canceled": ids})
+	}))
+	defer clob.Close()
+
+	gw, _ := newCLOBGateway(t, clob.URL)
+	for _, id := range []string{"0xa", "0xb", "0xc", "0xd"} {
+		gw.placed.add("t1", id)
+	}
+	gw.sessions.add(heartbeatKey("t1", "s1"), "0xa")
+
+	// Fully matched and cancelled orders are no longer resting
+	gw.OnOrderUpdate(&model.OrderUpdate{TenantID: "t1", OrderID: "0xa", Type: market.OrderEventUpdate, OriginalSize: "10", SizeMatched: "10"})
+	gw.OnOrderUpdate(&model.OrderUpdate{TenantID: "t1", OrderID: "0xb", Type: market.OrderEventCancellation, OriginalSize: "10", SizeMatched: "0"})
+	gw.OnOrderUpdate(&model.OrderUpdate{TenantID: "t1", OrderID: "0xc", Type: market.OrderEventUpdate, OriginalSize: "10", SizeMatched: "4"})
+	if ids := gw.sessions.list(heartbeatKey("t1", "s1")); len(ids) != 0 {
+		t.Fatalf("expected the filled order to leave its session, got %v", ids)
+	}
+
+	report := gw.CancelOnShutdown(context.Background(), ShutdownCancelGatewayOrders, 0, nil)
+	if report.Cancelled["t1"] != 2 || len(report.Failed) != 0 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if len(cancelled) != 2 || cancelled[0] != "0xc" || cancelled[1] != "0xd" {
+		t.Fatalf("expected only the resting orders to be cancelled, got %v", cancelled)
+	}
+	if ids := gw.placed.list("t1"); len(ids) != 0 {
+		t.Fatalf("cancelled orders must be forgotten, got %v", ids)
+	}
+}
+
+// newCLOBGateway builds a gateway whose tenant t1 talks to the CLOB at url.
+func newCLOBGateway(t *testing.T, url string) (*GatewayService, *model.Tenant) {
+	t.Helper()
+	cfg := &config.Config{}
+	tm := NewTenantManager(cfg, nil)
+	tenant := &model.Tenant{ID: "t1", ApiKey: "k1", Creds: model.PolymarketCreds{
+		PrivateKey:      "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318",
+		L2ApiKey:        "key",
+		L2ApiSecret:     "c2VjcmV0",
+		L2ApiPassphrase: "pass",
+	}}
+	tm.RegisterTenant(tenant)
+	signer, err := auth.NewPrivateKeySigner(tenant.Creds.PrivateKey, 137)
+	if err != nil {
+		t.Fatalf("signer: %v", err)
+	}
+	sdkCfg := polymarket.DefaultConfig()
+	sdkCfg.BaseURLs.CLOB = url
+	tm.clients[tenant.ID] = polymarket.NewClient(polymarket.WithConfig(sdkCfg)).WithAuth(signer, &auth.APIKey{
+		Key: tenant.Creds.L2ApiKey, Secret: tenant.Creds.L2ApiSecret, Passphrase: tenant.Creds.L2ApiPassphrase,
+	})
+
+	gw, err := NewGatewayService(cfg, tm, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("gateway init failed: %v", err)
+	}
+	return gw, tenant
+}
@@ -3,7 +3,9 @@ package service
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/GoPolymarket/polygate/internal/config"
 	"github.com/GoPolymarket/polygate/internal/model"
@@ -22,6 +24,7 @@ type TenantManager struct {
 	config        *config.Config
 	defaultTenant *model.Tenant
 	repo          TenantRepo
+	heartbeats    map[string]*model.Heartbeat // Key: TenantID + "/" + SessionID
 }
 
 type TenantRepo interface {
@@ -30,11 +33,12 @@ type TenantRepo interface {
 
 func NewTenantManager(cfg *config.Config, repo TenantRepo) *TenantManager {
 	tm := &TenantManager{
-		tenants:  make(map[string]*model.Tenant),
-		clients:  make(map[string]*polymarket.Client),
-		limiters: make(map[string]*rate.Limiter),
-		config:   cfg,
-		repo:     repo,
+		tenants:    make(map[string]*model.Tenant),
+		clients:    make(map[string]*polymarket.Client),
+		limiters:   make(map[string]*rate.Limiter),
+		config:     cfg,
+		repo:       repo,
+		heartbeats: make(map[string]*model.Heartbeat),
 	}
 
 	// 配置化租户 (优先)
@@ -56,7 +60,13 @@ func NewTenantManager(cfg *config.Config, repo TenantRepo) *TenantManager {
 					MaxDailyValue:             chooseFloat(cfg.Risk.MaxDailyValue, tenantCfg.Risk.MaxDailyValue),
 					MaxDailyOrders:            chooseInt(cfg.Risk.MaxDailyOrders, tenantCfg.Risk.MaxDailyOrders),
 					MaxSlippage:               chooseFloat(cfg.Risk.MaxSlippage, tenantCfg.Risk.MaxSlippage),
+					MaxPositionSize:           chooseFloat(cfg.Risk.MaxPositionSize, tenantCfg.Risk.MaxPositionSize),
+					MaxEventValue:             chooseFloat(cfg.Risk.MaxEventValue, tenantCfg.Risk.MaxEventValue),
+					MaxOpenOrderValue:         chooseFloat(cfg.Risk.MaxOpenOrderValue, tenantCfg.Risk.MaxOpenOrderValue),
+					MaxDailyLoss:              chooseFloat(cfg.Risk.MaxDailyLoss, tenantCfg.Risk.MaxDailyLoss),
+					MaxDrawdown:               chooseFloat(cfg.Risk.MaxDrawdown, tenantCfg.Risk.MaxDrawdown),
 					RestrictedMkts:            chooseStringSlice(cfg.Risk.BlacklistedTokenIDs, tenantCfg.Risk.BlacklistedTokenIDs),
+					RoundToTick:               cfg.Risk.RoundToTick || tenantCfg.Risk.RoundToTick,
 					AllowUnverifiedSignatures: cfg.Risk.AllowUnverifiedSignatures || tenantCfg.Risk.AllowUnverifiedSignatures,
 				},
 				Rate: model.RateLimitConfig{
@@ -86,7 +96,13 @@ func NewTenantManager(cfg *config.Config, repo TenantRepo) *TenantManager {
 				MaxDailyValue:             cfg.Risk.MaxDailyValue,
 				MaxDailyOrders:            cfg.Risk.MaxDailyOrders,
 				MaxSlippage:               cfg.Risk.MaxSlippage,
+				MaxPositionSize:           cfg.Risk.MaxPositionSize,
+				MaxEventValue:             cfg.Risk.MaxEventValue,
+				MaxOpenOrderValue:         cfg.Risk.MaxOpenOrderValue,
+				MaxDailyLoss:              cfg.Risk.MaxDailyLoss,
+				MaxDrawdown:               cfg.Risk.MaxDrawdown,
 				RestrictedMkts:            cfg.Risk.BlacklistedTokenIDs,
+				RoundToTick:               cfg.Risk.RoundToTick,
 				AllowUnverifiedSignatures: cfg.Risk.AllowUnverifiedSignatures,
 			},
 			Rate: model.RateLimitConfig{
@@ -142,6 +158,11 @@ func (tm *TenantManager) RemoveTenantByID(id string) {
 			delete(tm.clients, tenant.ID)
 		}
 	}
+	for key, hb := range tm.heartbeats {
+		if hb.TenantID == id {
+			delete(tm.heartbeats, key)
+		}
+	}
 }
 
 func (tm *TenantManager) GetTenantByID(id string) (*model.Tenant, bool) {
@@ -261,6 +282,100 @@ func (tm *TenantManager) GetClientForTenant(t *model.Tenant) (*polymarket.Client
 	return client, nil
 }
 
+// Beat arms or extends a tenant's dead man's switch (optionally for one session).
+// renewed is true when an armed switch was extended with the same TTL. A switch
+// that already lapsed is left for its cancel to run: it is returned with lapsed set.
+func (tm *TenantManager) Beat(tenantID, sessionID string, ttl time.Duration, now time.Time) (hb model.Heartbeat, renewed, lapsed bool) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	key := heartbeatKey(tenantID, sessionID)
+	prev, ok := tm.heartbeats[key]
+	if ok && now.After(prev.ExpiresAt) {
+		return *prev, false, true
+	}
+	renewed = ok && prev.TTLSeconds == int(ttl/time.Second)
+	next := &model.Heartbeat{
+		TenantID:   tenantID,
+		SessionID:  sessionID,
+		TTLSeconds: int(ttl / time.Second),
+		LastBeat:   now,
+		ExpiresAt:  now.Add(ttl),
+	}
+	tm.heartbeats[key] = next
+	return *next, renewed, false
+}
+
+// Disarm removes a dead man's switch; it reports whether one was armed.
+func (tm *TenantManager) Disarm(tenantID, sessionID string) bool {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	key := heartbeatKey(tenantID, sessionID)
+	_, ok := tm.heartbeats[key]
+	delete(tm.heartbeats, key)
+	return ok
+}
+
+// DisarmLive removes a dead man's switch that has not lapsed; a lapsed one is
+// left for its cancel to run and returned with lapsed set.
+func (tm *TenantManager) DisarmLive(tenantID, sessionID string, now time.Time) (hb model.Heartbeat, lapsed bool) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	key := heartbeatKey(tenantID, sessionID)
+	prev, ok := tm.heartbeats[key]
+	if !ok {
+		return model.Heartbeat{}, false
+	}
+	if now.After(prev.ExpiresAt) {
+		return *prev, true
+	}
+	delete(tm.heartbeats, key)
+	return model.Heartbeat{}, false
+}
+
+// DisarmExpired removes a lapsed switch unless it was re-armed after expiresAt was read.
+func (tm *TenantManager) DisarmExpired(tenantID, sessionID string, expiresAt time.Time) bool {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	key := heartbeatKey(tenantID, sessionID)
+	hb, ok := tm.heartbeats[key]
+	if !ok || !hb.ExpiresAt.Equal(expiresAt) {
+		return false
+	}
+	delete(tm.heartbeats, key)
+	return true
+}
+
+// Heartbeats returns the tenant's armed switches.
+func (tm *TenantManager) Heartbeats(tenantID string) []model.Heartbeat {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	out := make([]model.Heartbeat, 0)
+	for _, hb := range tm.heartbeats {
+		if hb.TenantID == tenantID {
+			out = append(out, *hb)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].SessionID < out[j].SessionID })
+	return out
+}
+
+// ExpiredHeartbeats returns every switch whose deadline passed before now.
+func (tm *TenantManager) ExpiredHeartbeats(now time.Time) []model.Heartbeat {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	out := make([]model.Heartbeat, 0)
+	for _, hb := range tm.heartbeats {
+		if now.After(hb.ExpiresAt) {
+			out = append(out, *hb)
+		}
+	}
+	return out
+}
+
+func heartbeatKey(tenantID, sessionID string) string {
+	return tenantID + "/" + sessionID
+}
+
 func chooseFloat(base, override float64) float64 {
 	if override > 0 {
 		return override
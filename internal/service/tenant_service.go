@@ -12,8 +12,15 @@ import (
 )
 
 type TenantService struct {
-	repo    TenantRepoCRUD
-	manager *TenantManager
+	repo      TenantRepoCRUD
+	manager   *TenantManager
+	listeners []TenantListener
+}
+
+// TenantListener 在租户创建/更新/删除生效后收到通知 (如 user stream 启停)
+type TenantListener interface {
+	OnTenantUpsert(t *model.Tenant)
+	OnTenantDelete(tenantID string)
 }
 
 type TenantRepoCRUD interface {
@@ -55,6 +62,25 @@ func NewTenantService(manager *TenantManager, repo TenantRepoCRUD) *TenantServic
 	}
 }
 
+// AddListener registers a listener for tenant lifecycle changes.
+func (s *TenantService) AddListener(l TenantListener) {
+	if l != nil {
+		s.listeners = append(s.listeners, l)
+	}
+}
+
+func (s *TenantService) notifyUpsert(t *model.Tenant) {
+	for _, l := range s.listeners {
+		l.OnTenantUpsert(t)
+	}
+}
+
+func (s *TenantService) notifyDelete(id string) {
+	for _, l := range s.listeners {
+		l.OnTenantDelete(id)
+	}
+}
+
 func (s *TenantService) List(ctx context.Context, limit, offset int) ([]*model.Tenant, error) {
 	if s.repo != nil {
 		return s.repo.List(ctx, limit, offset)
@@ -96,6 +122,7 @@ func (s *TenantService) Create(ctx context.Context, req TenantCreateRequest) (*m
 		}
 	}
 	s.manager.RegisterTenant(tenant)
+	s.notifyUpsert(tenant)
 	return tenant, nil
 }
 
@@ -143,6 +170,7 @@ func (s *TenantService) Update(ctx context.Context, id string, req TenantUpdateR
 		}
 	}
 	s.manager.ReplaceTenant(tenant)
+	s.notifyUpsert(tenant)
 	return tenant, nil
 }
 
@@ -153,6 +181,7 @@ func (s *TenantService) Delete(ctx context.Context, id string) error {
 		}
 	}
 	s.manager.RemoveTenantByID(id)
+	s.notifyDelete(id)
 	return nil
 }
 
@@ -183,5 +212,6 @@ func (s *TenantService) UpdateCreds(ctx context.Context, id string, req TenantCr
 		}
 	}
 	s.manager.ReplaceTenant(tenant)
+	s.notifyUpsert(tenant)
 	return tenant, nil
 }
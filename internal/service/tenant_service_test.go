@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GoPolymarket/polygate/internal/config"
+	"github.com/GoPolymarket/polygate/internal/model"
+)
+
+type recordingListener struct {
+	upserts []string
+	deletes []string
+}
+
+func (l *recordingListener) OnTenantUpsert(t *model.Tenant) {
+	l.upserts = append(l.upserts, t.ID+":"+t.Creds.L2ApiKey)
+}
+
+func (l *recordingListener) OnTenantDelete(tenantID string) {
+	l.deletes = append(l.deletes, tenantID)
+}
+
+func TestTenantServiceNotifiesListeners(t *testing.T) {
+	manager := NewTenantManager(&config.Config{}, nil)
+	svc := NewTenantService(manager, nil)
+	listener := &recordingListener{}
+	svc.AddListener(listener)
+
+	ctx := context.Background()
+	if _, err := svc.Create(ctx, TenantCreateRequest{ID: "t1", APIKey: "sk-t1", Creds: model.PolymarketCreds{L2ApiKey: "k1"}}); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if _, err := svc.UpdateCreds(ctx, "t1", TenantCredsUpdateRequest{Creds: model.PolymarketCreds{L2ApiKey: "k2"}}); err != nil {
+		t.Fatalf("update creds failed: %v", err)
+	}
+	if err := svc.Delete(ctx, "t1"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+
+	if len(listener.upserts) != 2 || listener.upserts[0] != "t1:k1" || listener.upserts[1] != "t1:k2" {
+		t.Fatalf("unexpected upsert notifications: %v", listener.upserts)
+	}
+	if len(listener.deletes) != 1 || listener.deletes[0] != "t1" {
+		t.Fatalf("unexpected delete notifications: %v", listener.deletes)
+	}
+}
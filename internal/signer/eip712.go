@@ -14,6 +14,8 @@ const (
 	
 	// Exchange Contract Address on Polygon
 	ExchangeContractAddress = "0x4bFb41d5B3570DeFd03C39a9A4D8dE6Bd8B8982E"
+	// Neg-Risk markets settle on their own exchange, which is also the signing domain
+	NegRiskExchangeContractAddress = "0xC5d563A36AE78145C45a50134d48A1215220f80a"
 )
 
 var (
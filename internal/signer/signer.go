@@ -11,10 +11,11 @@ import (
 )
 
 type Signer struct {
-	key             *ecdsa.PrivateKey
-	address         common.Address
-	chainID         *big.Int
-	domainSeparator common.Hash
+	key                    *ecdsa.PrivateKey
+	address                common.Address
+	chainID                *big.Int
+	domainSeparator        common.Hash
+	negRiskDomainSeparator common.Hash // Neg-Risk Exchange
 }
 
 // NewSigner creates a new EIP-712 signer with pre-calculated domain separator
@@ -36,8 +37,18 @@ func NewSigner(privateKeyHex string, chainID int64) (*Signer, error) {
 	}
 	address := crypto.PubkeyToAddress(*publicKeyECDSA)
 
-	// 3. Pre-calculate Domain Separator
-	// keccak256(abi.encode(EIP712DomainTypeHash, keccak256("Polymarket CTF Exchange"), keccak256("1"), chainId, verifyingContract))
+	return &Signer{
+		key:                    key,
+		address:                address,
+		chainID:                big.NewInt(chainID),
+		domainSeparator:        computeDomainSeparator(chainID, ExchangeContractAddress),
+		negRiskDomainSeparator: computeDomainSeparator(chainID, NegRiskExchangeContractAddress),
+	}, nil
+}
+
+// computeDomainSeparator pre-calculates the EIP-712 domain of one exchange contract:
+// keccak256(abi.encode(EIP712DomainTypeHash, keccak256("Polymarket CTF Exchange"), keccak256("1"), chainId, verifyingContract))
+func computeDomainSeparator(chainID int64, contract string) common.Hash {
 	domainNameHash := crypto.Keccak256Hash([]byte(EIP712DomainName))
 	versionHash := crypto.Keccak256Hash([]byte(EIP712DomainVersion))
 	
@@ -55,22 +66,25 @@ func NewSigner(privateKeyHex string, chainID int64) (*Signer, error) {
 
 	// Verifying Contract (address -> uint256 padded)
 	// common.HexToAddress returns 20 bytes, need to pad to left
-	verifyingAddr := common.HexToAddress(ExchangeContractAddress)
+	verifyingAddr := common.HexToAddress(contract)
 	copy(domainData[128+12:160], verifyingAddr.Bytes()) // last 20 bytes
 
-	domainSeparator := crypto.Keccak256Hash(domainData)
-
-	return &Signer{
-		key:             key,
-		address:         address,
-		chainID:         big.NewInt(chainID),
-		domainSeparator: domainSeparator,
-	}, nil
+	return crypto.Keccak256Hash(domainData)
 }
 
-// SignOrder calculates the EIP-712 hash and signs it
+// SignOrder calculates the EIP-712 hash and signs it for the CTF Exchange
 // Returns (r, s, v) as per standard ECDSA signature
 func (s *Signer) SignOrder(order *Order) (string, error) {
+	return s.SignOrderFor(order, false)
+}
+
+// SignOrderFor signs for the CTF Exchange, or the Neg-Risk Exchange when negRisk is set.
+func (s *Signer) SignOrderFor(order *Order, negRisk bool) (string, error) {
+	domainSeparator := s.domainSeparator
+	if negRisk {
+		domainSeparator = s.negRiskDomainSeparator
+	}
+
 	// 1. Calculate HashStruct(Order)
 	hashStruct, err := s.hashOrder(order)
 	if err != nil {
@@ -78,7 +92,7 @@ func (s *Signer) SignOrder(order *Order) (string, error) {
 	}
 
 	// 2. Calculate EIP-191 Hash: keccak256("\x19\x01" + domainSeparator + hashStruct)
-	finalHash := crypto.Keccak256([]byte{0x19, 0x01}, s.domainSeparator.Bytes(), hashStruct)
+	finalHash := crypto.Keccak256([]byte{0x19, 0x01}, domainSeparator.Bytes(), hashStruct)
 
 	// 3. Sign
 	signature, err := crypto.Sign(finalHash, s.key)
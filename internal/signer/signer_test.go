@@ -40,6 +40,39 @@ func TestSigner_SignOrder(t *testing.T) {
 	assert.Equal(t, 132, len(sig)) // 0x + 65 bytes * 2 = 132
 }
 
+func TestSigner_SignOrderForNegRisk(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer, err := NewSigner(hexutil.Encode(crypto.FromECDSA(key))[2:], 137)
+	assert.NoError(t, err)
+
+	order := &Order{
+		Salt:        big.NewInt(123),
+		Maker:       signer.Address(),
+		Signer:      signer.Address(),
+		TokenID:     big.NewInt(999),
+		MakerAmount: big.NewInt(1000000),
+		TakerAmount: big.NewInt(500000),
+		Expiration:  big.NewInt(0),
+		Nonce:       big.NewInt(1),
+		FeeRateBps:  big.NewInt(0),
+	}
+	ctfSig, err := signer.SignOrderFor(order, false)
+	assert.NoError(t, err)
+	negRiskSig, err := signer.SignOrderFor(order, true)
+	assert.NoError(t, err)
+	assert.NotEqual(t, ctfSig, negRiskSig)
+
+	// The Neg-Risk signature recovers under the Neg-Risk Exchange domain
+	hashStruct, err := signer.hashOrder(order)
+	assert.NoError(t, err)
+	digest := crypto.Keccak256([]byte{0x19, 0x01}, computeDomainSeparator(137, NegRiskExchangeContractAddress).Bytes(), hashStruct)
+	raw := hexutil.MustDecode(negRiskSig)
+	raw[64] -= 27
+	pub, err := crypto.SigToPub(digest, raw)
+	assert.NoError(t, err)
+	assert.Equal(t, signer.Address(), crypto.PubkeyToAddress(*pub))
+}
+
 func BenchmarkSignOrder(b *testing.B) {
 	// Generate a random key for testing
 	key, _ := crypto.GenerateKey()